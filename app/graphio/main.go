@@ -0,0 +1,160 @@
+// graphio export the link graph into file or import it from file.
+//
+//	graphio -dsn ... -format jsonl export graph.jsonl
+//	graphio -dsn ... -format jsonl import graph.jsonl
+//
+// progress is saved in <file>.state, running the same command again
+// resume the interrupted transfer. the state is removed when it is complete.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/linkgraph/graphio"
+	"github.com/odit-bit/invoker/store/postgregraph"
+)
+
+func main() {
+	var (
+		dsn    string
+		format string
+	)
+	flag.StringVar(&dsn, "dsn", os.Getenv("DSN"), "uri or string for data source (database)")
+	flag.StringVar(&format, "format", string(graphio.FormatJSONL), "file format: jsonl, csv or graphml")
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		log.Fatal("usage: graphio [flags] export|import <file>")
+	}
+	cmd, file := flag.Arg(0), flag.Arg(1)
+
+	f, err := graphio.ParseFormat(format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sqlx.Open("pgx", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		log.Fatalf("failed ping: %v", err)
+	}
+	graphDB := postgregraph.New(db)
+
+	switch cmd {
+	case "export":
+		err = export(graphDB, f, file)
+	case "import":
+		err = importFile(graphDB, f, file)
+	default:
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func statePath(file string) string {
+	return file + ".state"
+}
+
+// exportState is the position of interrupted export and
+// size of the file when the position was saved
+type exportState struct {
+	graphio.Position
+	Size int64 `json:"size"`
+}
+
+func export(g graphio.ExportGraph, format graphio.Format, file string) error {
+	var state exportState
+	if b, err := os.ReadFile(statePath(file)); err == nil {
+		if err := json.Unmarshal(b, &state); err != nil {
+			return fmt.Errorf("read state: %v", err)
+		}
+		log.Printf("resume export from %v %v", state.Section, state.After)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// drop anything written after the saved position, it may end with partial record
+	if err := out.Truncate(state.Size); err != nil {
+		return err
+	}
+	if _, err := out.Seek(state.Size, io.SeekStart); err != nil {
+		return err
+	}
+
+	pos, err := graphio.Export(g, out, graphio.ExportOptions{
+		Format: format,
+		From:   state.Position,
+		Progress: func(pos graphio.Position) error {
+			size, err := out.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(exportState{Position: pos, Size: size})
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(statePath(file), b, 0644)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("export %v", pos.Section)
+	return os.Remove(statePath(file))
+}
+
+func importFile(g graphio.ImportGraph, format graphio.Format, file string) error {
+	var skip int64
+	if b, err := os.ReadFile(statePath(file)); err == nil {
+		if skip, err = strconv.ParseInt(string(b), 10, 64); err != nil {
+			return fmt.Errorf("read state: %v", err)
+		}
+		log.Printf("resume import after %v records", skip)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	res, err := graphio.Import(g, in, graphio.ImportOptions{
+		Format: format,
+		Skip:   skip,
+		Progress: func(processed int64) error {
+			return os.WriteFile(statePath(file), []byte(strconv.FormatInt(processed, 10)), 0644)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("import records:%v links:%v edges:%v dropped_edges:%v", res.Records, res.Links, res.Edges, res.DroppedEdges)
+	return os.Remove(statePath(file))
+}
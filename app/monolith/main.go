@@ -18,12 +18,25 @@ import (
 	"github.com/odit-bit/invoker/internal/privnet"
 	"github.com/odit-bit/invoker/internal/xhttpclient"
 	"github.com/odit-bit/invoker/linkcrawler"
+	"github.com/odit-bit/invoker/linkcrawler/crawlrun"
+	"github.com/odit-bit/invoker/linkgraph/gc"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"github.com/odit-bit/invoker/linkgraph/memory"
 	"github.com/odit-bit/invoker/pagerank"
 	"github.com/odit-bit/invoker/partition"
+	"github.com/odit-bit/invoker/store/bleveindex"
+	"github.com/odit-bit/invoker/store/boltgraph"
+	"github.com/odit-bit/invoker/store/postgrecrawl"
 	"github.com/odit-bit/invoker/store/postgregraph"
 	"github.com/odit-bit/invoker/store/postgreindex"
+	"github.com/odit-bit/invoker/store/postgreleader"
+	"github.com/odit-bit/invoker/store/postgrepartition"
+	"github.com/odit-bit/invoker/textIndex/index"
+	"github.com/odit-bit/invoker/textIndex/spell"
+	"github.com/odit-bit/invoker/textIndex/suggest"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.etcd.io/bbolt"
 )
 
 func connectPG(dsn string) (*sqlx.DB, error) {
@@ -50,12 +63,45 @@ func main() {
 		crawler_worker           int
 		crawler_update_interval  time.Duration
 		crawler_reindex_interval time.Duration //time.Duration
+		crawler_batch_size       int
+		crawler_fetch_by_host    bool
+		crawler_host_delay       time.Duration
+		crawler_batch_interval   time.Duration
+		crawler_change_delay     time.Duration
 	)
 
 	var (
 		dsn string
 	)
 
+	var (
+		partition_membership bool
+		partition_lease      time.Duration
+		instance_id          string
+		pagerank_election    bool
+	)
+
+	var (
+		graph_gc_interval      time.Duration
+		graph_gc_age           time.Duration
+		graph_change_retention time.Duration
+
+		graph_snapshot          string
+		graph_snapshot_interval time.Duration
+		graph_bolt              string
+	)
+
+	var (
+		index_bleve      string
+		ranking          = index.DefaultRanking()
+		suggest_interval time.Duration
+		spell_interval   time.Duration
+		collapse_by_host int
+
+		term_stats_interval time.Duration
+		host_count_interval time.Duration
+	)
+
 	dur1, err := time.ParseDuration(os.Getenv("PAGERANK_UPDATE_TIME"))
 	if err != nil {
 		dur1 = 60 * time.Minute
@@ -74,49 +120,199 @@ func main() {
 	// pagerank
 	flag.IntVar(&pagerank_worker, "pagerank-worker", runtime.NumCPU(), "pagerank computing worker")
 	flag.DurationVar(&pagerank_update_interval, "pagerank-update-interval time", dur1, "determined update rank time in minute")
+	flag.BoolVar(&pagerank_election, "pagerank-leader-election", false, "elect pagerank leader with lease in database instead of partition 0, lease duration follow partition-lease")
 
 	// crawler
 	flag.IntVar(&crawler_worker, "crawler-worker ", n2, "crawler link fetcher worker")
 	flag.DurationVar(&crawler_update_interval, "crawler-interval", dur2, "determined wake crawler time in minute")
 	flag.DurationVar(&crawler_reindex_interval, "crawler-reindex-treshold", 7*24*time.Hour, "determined time before link re-crawl again ")
+	flag.BoolVar(&crawler_fetch_by_host, "crawler-fetch-by-host", false, "fetch every link of the same host by the same worker")
+	flag.DurationVar(&crawler_host_delay, "crawler-host-delay", 0, "minimum time between request to the same host, need crawler-fetch-by-host")
+	flag.IntVar(&crawler_batch_size, "crawler-batch-size", 1, "number of crawled page written to graph and index at once, 1 disable batching")
+	flag.DurationVar(&crawler_batch_interval, "crawler-batch-interval", 2*time.Second, "maximum time crawled page wait before partial batch written")
+	flag.DurationVar(&crawler_change_delay, "crawler-change-delay", 5*time.Second, "wake crawler this long after new link recorded in the graph, 0 only wake every crawler-interval")
+
+	// graph
+	flag.DurationVar(&graph_gc_interval, "graph-gc-interval", 0, "time between orphan link removal pass, 0 disable it")
+	flag.DurationVar(&graph_gc_age, "graph-gc-age", 30*24*time.Hour, "never retrieved link without backlink is removed after this age")
+	flag.DurationVar(&graph_change_retention, "graph-change-retention", 7*24*time.Hour, "graph change older than this is pruned hourly, independent of orphan link removal, 0 keep every change")
+	flag.StringVar(&graph_snapshot, "graph-snapshot", "", "keep link graph in memory and persist it into this snapshot file instead of database")
+	flag.DurationVar(&graph_snapshot_interval, "graph-snapshot-interval", 5*time.Minute, "time between link graph snapshot")
+	flag.StringVar(&graph_bolt, "graph-bolt", "", "store link graph in this embedded bbolt file instead of database")
+
+	// index
+	flag.StringVar(&index_bleve, "index-bleve", "", "store text index in this on-disk bleve index directory instead of database")
+	flag.Float64Var(&ranking.Relevance, "rank-relevance", ranking.Relevance, "weight of text relevance in search result score")
+	flag.Float64Var(&ranking.PageRank, "rank-pagerank", ranking.PageRank, "weight of pagerank in search result score")
+	flag.Float64Var(&ranking.Freshness, "rank-freshness", ranking.Freshness, "weight of how recent the page indexed in search result score")
+	flag.Float64Var(&ranking.TitleMatch, "rank-title", ranking.TitleMatch, "weight of the query found in the title in search result score")
+	flag.Float64Var(&ranking.URLDepth, "rank-url-depth", ranking.URLDepth, "weight of shallow url path in search result score")
+	flag.DurationVar(&ranking.FreshnessHalfLife, "rank-freshness-half-life", ranking.FreshnessHalfLife, "age of page when its freshness is half")
+	flag.IntVar(&collapse_by_host, "collapse-by-host", 2, "maximum search results of the same site, 0 disable collapsing")
+	flag.DurationVar(&suggest_interval, "suggest-interval", 10*time.Minute, "time between rebuild of search-as-you-type suggestion, 0 disable suggestion")
+	flag.DurationVar(&spell_interval, "spell-interval", time.Hour, "time between rebuild of spelling correction dictionary, 0 disable \"did you mean\"")
+	flag.DurationVar(&term_stats_interval, "term-stats-interval", time.Hour, "time between recount of the postgres index term statistic used by related documents")
+	flag.DurationVar(&host_count_interval, "host-count-interval", 10*time.Minute, "time between recount of the postgres graph links and inter-host edges of every host")
+
+	// partition
+	flag.BoolVar(&partition_membership, "partition-membership", false, "assign partition from membership table, so multiple instance can share one database")
+	flag.DurationVar(&partition_lease, "partition-lease", 30*time.Second, "instance lost it's partition if not heartbeat within this duration")
+	flag.StringVar(&instance_id, "instance-id", os.Getenv("INSTANCE_ID"), "unique name of this instance in membership table, default hostname with random suffix")
 
 	// dsn
-	flag.StringVar(&dsn, "dsn ", os.Getenv("DSN"), "uri or string for data source (database)")
+	flag.StringVar(&dsn, "dsn ", os.Getenv("DSN"), "uri or string for data source (database), optional with embedded graph and index")
 	flag.Parse()
 
 	//=================
 
-	if dsn == "" {
+	// run the reindex subcommand instead of the services, it need no database
+	if flag.Arg(0) == "reindex" {
+		if err := runReindex(index_bleve); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// embedded graph and index need no database,
+	// unless it is given or the feature that only the database support is enabled.
+	embedded := (graph_bolt != "" || graph_snapshot != "") && index_bleve != ""
+	needDB := !embedded || dsn != "" || partition_membership || pagerank_election || flag.Arg(0) == "migrate"
+
+	if needDB && dsn == "" {
 		dsn = "host=localhost user=development password=credential dbname=development sslmode=disable"
 	}
 
 	//======================persistence setup
-	dbConn, err := connectPG(dsn)
-	if err != nil {
-		log.Fatal(err)
+	var dbConn *sqlx.DB
+	if needDB {
+		dbConn, err = connectPG(dsn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer dbConn.Close()
 	}
-	defer dbConn.Close()
 
-	graphDB := postgregraph.New(dbConn)
+	// run the migrate subcommand instead of the services
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), dbConn, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	indexDB, err := postgreindex.New(dbConn)
-	if err != nil {
-		log.Fatal(err)
+	var (
+		graphDB       graph.Graph
+		graphSnapshot *memory.SnapshotService
+		hostCount     *postgregraph.HostCountService
+	)
+	switch {
+	case graph_bolt != "":
+		boltDB, err := bbolt.Open(graph_bolt, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer boltDB.Close()
+
+		graphDB, err = boltgraph.New(boltDB)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case graph_snapshot != "":
+		memGraph, err := memory.Open(graph_snapshot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		graphSnapshot, err = memory.NewSnapshotService(memGraph, graph_snapshot, graph_snapshot_interval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		graphDB = memGraph
+	default:
+		pgGraph := postgregraph.New(dbConn)
+		hostCount, err = postgregraph.NewHostCountService(pgGraph, host_count_interval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		graphDB = pgGraph
+	}
+
+	var (
+		indexDB   index.Indexer
+		termStats *postgreindex.TermStatsService
+	)
+	if index_bleve != "" {
+		bleveIndex, err := bleveindex.Open(index_bleve)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer bleveIndex.Close()
+		indexDB = bleveIndex
+	} else {
+		pgIndex, err := postgreindex.New(dbConn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		indexDB = pgIndex
+
+		termStats, err = postgreindex.NewTermStatsService(pgIndex, term_stats_interval)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// the crawl history is lost on restart without database
+	var crawlRunDB crawlrun.Store = crawlrun.NewInMemory()
+	if dbConn != nil {
+		crawlRunDB, err = postgrecrawl.New(dbConn)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	//====================== Service
 	// pagerank instance
-	part := partition.Fixed{
+	var part partition.Detector = partition.Fixed{
 		Partition:     0,
 		NumPartitions: 1,
 	}
-	pagerankService, err := pagerank.NewWithConfig(pagerank.Config{
+
+	var membership *postgrepartition.Membership
+	if partition_membership {
+		membership, err = postgrepartition.New(dbConn, postgrepartition.Config{
+			InstanceID: instance_id,
+			LeaseTTL:   partition_lease,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		part = membership
+	}
+
+	var pagerankLease *postgreleader.Lease
+	if pagerank_election {
+		pagerankLease, err = postgreleader.New(dbConn, postgreleader.Config{
+			Name:       "pagerank",
+			InstanceID: instance_id,
+			LeaseTTL:   partition_lease,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	pagerankConf := pagerank.Config{
 		GraphAPI:          graphDB,
 		IndexAPI:          indexDB,
 		PartitionDetector: part,
 		ComputeWorkers:    pagerank_worker,
 		UpdateInterval:    time.Duration(pagerank_update_interval),
-	})
+	}
+	// assign only non-nil lease, typed nil would not be nil interface
+	if pagerankLease != nil {
+		pagerankConf.LeaderElector = pagerankLease
+	}
+
+	pagerankService, err := pagerank.NewWithConfig(pagerankConf)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -143,9 +339,15 @@ func main() {
 		URLGetter:         urlGetter,
 		NetDetector:       detector,
 		UpdateInterval:    time.Duration(crawler_update_interval),
+		ChangeDelay:       crawler_change_delay,
 		ReindexInterval:   time.Duration(crawler_reindex_interval),
 		PartitionDetector: part,
+		RunStore:          crawlRunDB,
 		FetchWorker:       crawler_worker,
+		FetchByHost:       crawler_fetch_by_host,
+		HostFetchDelay:    crawler_host_delay,
+		BatchSize:         crawler_batch_size,
+		BatchInterval:     crawler_batch_interval,
 		Counter:           counter.Add,
 		Logger:            nil,
 	})
@@ -153,11 +355,101 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// suggestion, only index that can list its titles
+	var (
+		suggestService *suggest.Service
+		suggestAPI     frontend.SuggestAPI
+	)
+	if titleIndex, ok := indexDB.(index.TitleIndexer); ok && suggest_interval > 0 {
+		suggestService, err = suggest.New(suggest.Config{
+			IndexAPI:      titleIndex,
+			Interval:      suggest_interval,
+			PageRankPivot: ranking.PageRankPivot,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		suggestAPI = suggestService
+	}
+
+	// spelling correction, only index that can list its vocabulary
+	var (
+		spellService *spell.Service
+		spellAPI     frontend.SpellAPI
+	)
+	if vocabularyIndex, ok := indexDB.(index.VocabularyIndexer); ok && spell_interval > 0 {
+		spellService, err = spell.New(spell.Config{
+			IndexAPI: vocabularyIndex,
+			Interval: spell_interval,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		spellAPI = spellService
+	}
+
 	//frontend instance
-	frontendService := frontend.NewDefault(graphDB, indexDB)
+	relatedAPI, _ := indexDB.(index.RelatedIndexer)
+	frontendService, err := frontend.NewWithConfig(frontend.Config{
+		GraphAPI:         graph.WithContext(graphDB),
+		IndexAPI:         index.WithContext(indexDB),
+		RelatedAPI:       relatedAPI,
+		ListenAddr:       ":8080",
+		ResultsPerPage:   10,
+		MaxSummaryLength: 256,
+		Ranking:          &ranking,
+		CollapseByHost:   collapse_by_host,
+		SuggestAPI:       suggestAPI,
+		SpellAPI:         spellAPI,
+		CrawlRunAPI:      crawlRunDB,
+		Status: map[string]frontend.StatusFunc{
+			"pagerank": func() (interface{}, error) { return pagerankService.Status() },
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	var spv Supervised
 
+	// the change log is pruned even when orphan link removal is disabled
+	_, changeFeed := graphDB.(graph.ChangeFeed)
+	if graph_gc_interval > 0 || (changeFeed && graph_change_retention > 0) {
+		graphGC, err := gc.New(gc.Config{
+			GraphAPI:        graphDB,
+			MaxAge:          graph_gc_age,
+			Interval:        graph_gc_interval,
+			ChangeRetention: graph_change_retention,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		spv = append(spv, graphGC)
+	}
+
+	// membership should keep the lease alive as long as the services run
+	if membership != nil {
+		spv = append(spv, membership)
+	}
+	if pagerankLease != nil {
+		spv = append(spv, pagerankLease)
+	}
+	if graphSnapshot != nil {
+		spv = append(spv, graphSnapshot)
+	}
+	if suggestService != nil {
+		spv = append(spv, suggestService)
+	}
+	if spellService != nil {
+		spv = append(spv, spellService)
+	}
+	if termStats != nil {
+		spv = append(spv, termStats)
+	}
+	if hostCount != nil {
+		spv = append(spv, hostCount)
+	}
+
 	// run services
 	spv = append(spv, pagerankService)
 	spv = append(spv, crawlService)
@@ -196,3 +488,26 @@ func main() {
 
 	log.Println("succesfull shutdown")
 }
+
+// rebuild the bleve index with the current mapping,
+// the old index is kept beside it until it is removed by hand.
+func runReindex(path string) error {
+	if path == "" {
+		return fmt.Errorf("reindex: -index-bleve is not set")
+	}
+	rebuilt, old := path+".new", path+".old"
+	if _, err := os.Stat(old); err == nil {
+		return fmt.Errorf("reindex: %v already exist", old)
+	}
+	if err := bleveindex.Reindex(path, rebuilt); err != nil {
+		return err
+	}
+	if err := os.Rename(path, old); err != nil {
+		return fmt.Errorf("reindex: %v", err)
+	}
+	if err := os.Rename(rebuilt, path); err != nil {
+		return fmt.Errorf("reindex: %v", err)
+	}
+	log.Printf("index rebuilt, the old index is kept at %v\n", old)
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/internal/migrate"
+	"github.com/odit-bit/invoker/store/postgrecrawl"
+	"github.com/odit-bit/invoker/store/postgregraph"
+	"github.com/odit-bit/invoker/store/postgreindex"
+	"github.com/odit-bit/invoker/store/postgreleader"
+	"github.com/odit-bit/invoker/store/postgrepartition"
+)
+
+// postgres stores used by the monolith, in the order they are migrated
+var migrationSources = []migrate.Source{
+	postgregraph.Migrations,
+	postgreindex.Migrations,
+	postgrecrawl.Migrations,
+	postgrepartition.Migrations,
+	postgreleader.Migrations,
+}
+
+const migrateUsage = `usage: monolith [flags] migrate <command>
+
+commands:
+  status [store]        list migrations and when they applied
+  up [store]            apply pending migrations
+  down <store>          revert the latest applied migration
+  to <store> <version>  apply or revert migrations until version, 0 revert everything
+
+stores: graph, index, crawl, partition, leader`
+
+// runMigrate execute the migrate subcommand, args exclude "migrate"
+func runMigrate(ctx context.Context, db *sqlx.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "status":
+		migrators, err := selectMigrators(db, args)
+		if err != nil {
+			return err
+		}
+		return printStatus(ctx, migrators)
+
+	case "up":
+		migrators, err := selectMigrators(db, args)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrators {
+			if err := m.Up(ctx); err != nil {
+				return err
+			}
+		}
+		return printStatus(ctx, migrators)
+
+	case "down":
+		if len(args) != 1 {
+			return fmt.Errorf(migrateUsage)
+		}
+		migrators, err := selectMigrators(db, args)
+		if err != nil {
+			return err
+		}
+		m := migrators[0]
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			return fmt.Errorf("store %s has no applied migration", args[0])
+		}
+		if err := m.To(ctx, m.Previous(version)); err != nil {
+			return err
+		}
+		return printStatus(ctx, migrators)
+
+	case "to":
+		if len(args) != 2 {
+			return fmt.Errorf(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		migrators, err := selectMigrators(db, args[:1])
+		if err != nil {
+			return err
+		}
+		if err := migrators[0].To(ctx, version); err != nil {
+			return err
+		}
+		return printStatus(ctx, migrators)
+	}
+	return fmt.Errorf(migrateUsage)
+}
+
+// migrator of the named store, or every store if no name given
+func selectMigrators(db *sqlx.DB, names []string) ([]*migrate.Migrator, error) {
+	var migrators []*migrate.Migrator
+	for _, src := range migrationSources {
+		if len(names) > 0 && names[0] != src.Name {
+			continue
+		}
+		m, err := migrate.New(db, src)
+		if err != nil {
+			return nil, err
+		}
+		migrators = append(migrators, m)
+	}
+	if len(migrators) == 0 {
+		return nil, fmt.Errorf("unknown store %q", names[0])
+	}
+	return migrators, nil
+}
+
+func printStatus(ctx context.Context, migrators []*migrate.Migrator) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STORE\tVERSION\tNAME\tAPPLIED")
+	for _, m := range migrators {
+		list, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, st := range list {
+			applied := "pending"
+			if !st.AppliedAt.IsZero() {
+				applied = st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", m.Store(), st.Version, st.Name, applied)
+		}
+	}
+	return w.Flush()
+}
@@ -3,6 +3,7 @@ package frontend
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -14,6 +15,8 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkcrawler/crawlrun"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 	"github.com/odit-bit/invoker/textIndex/index"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -25,19 +28,53 @@ var (
 	submitLinkEndpoint = "/submit/site"
 	indexEndpoint      = "/"
 	metricEndpoint     = "/prom"
+	statusEndpoint     = "/status/{service}"
+	linkEndpoint       = "/link"
+	suggestEndpoint    = "/suggest"
+	relatedEndpoint    = "/related"
+	crawlRunsEndpoint  = "/crawl/runs"
 
 	defaultResultsPerPage   = 10
 	defaultMaxSummaryLength = 256
+	defaultSuggestions      = 8
+	defaultCrawlRuns        = 50
 )
 
+// a list methods needed by the handlers to communicate with the link graph,
+// the query of a request that cancelled by the client is cancelled too.
+// graph.WithContext adapt graph that not accept context.
 type GraphAPI interface {
-	UpsertLink(*graph.Link) error
+	UpsertLinkContext(ctx context.Context, link *graph.Link) error
+	LookupLinkContext(ctx context.Context, id uuid.UUID) (*graph.Link, error)
+	InEdgesContext(ctx context.Context, dst uuid.UUID) (graph.EdgeIterator, error)
+	OutEdgesContext(ctx context.Context, src uuid.UUID) (graph.EdgeIterator, error)
+	DegreeContext(ctx context.Context, id uuid.UUID) (in, out int, err error)
 }
 
+// index.WithContext adapt index that not accept context
 type IndexAPI interface {
-	Search(index.Query) (index.Iterator, error)
+	SearchContext(ctx context.Context, query index.Query) (index.Iterator, error)
 }
 
+// SuggestAPI complete the partially typed query
+type SuggestAPI interface {
+	Suggest(prefix string, n int) []string
+	RecordQuery(query string)
+}
+
+// SpellAPI correct the misspelled words of query
+type SpellAPI interface {
+	Correct(text string) string
+}
+
+// CrawlRunAPI list the history of crawl runs
+type CrawlRunAPI interface {
+	Runs(offset, limit int) ([]*crawlrun.Run, error)
+}
+
+// StatusFunc return JSON-encodable status of a service
+type StatusFunc func() (interface{}, error)
+
 // Config encapsulates the settings for configuring the front-end service.
 type Config struct {
 	// An API for adding links to the link graph.
@@ -46,6 +83,10 @@ type Config struct {
 	// An API for executing queries against indexed documents.
 	IndexAPI IndexAPI
 
+	// An API for finding the pages similar to a document. Optional,
+	// the "similar pages" link is not shown if not specified.
+	RelatedAPI index.RelatedIndexer
+
 	// The port to listen for incoming requests.
 	ListenAddr string
 
@@ -58,6 +99,31 @@ type Config struct {
 	// instead.
 	MaxSummaryLength int
 
+	// The maximum number of results of the same site, the others are behind
+	// a "more results from this site" link. If not specified, the results are
+	// not collapsed.
+	CollapseByHost int
+
+	// Weights of the search result score. If not specified,
+	// index.DefaultRanking will be used instead.
+	Ranking *index.Ranking
+
+	// An API for search-as-you-type suggestions, every query with result is
+	// recorded into it. Optional, /suggest return nothing if not specified.
+	SuggestAPI SuggestAPI
+
+	// An API for spelling correction, query with fewer results than a page
+	// is checked for correction. Optional.
+	SpellAPI SpellAPI
+
+	// Status of the other services keyed by name, served as JSON on
+	// /status/{name}. Optional.
+	Status map[string]StatusFunc
+
+	// An API for the crawl history, served as JSON on /crawl/runs.
+	// Optional, the endpoint return nothing if not specified.
+	CrawlRunAPI CrawlRunAPI
+
 	// The logger to use. If not defined an output-discarding logger will
 	// be used instead.
 	// Logger *logrus.Entry
@@ -107,6 +173,11 @@ func NewDefault(graphDB GraphAPI, indexDB IndexAPI) *API {
 	return fr
 }
 
+// NewWithConfig creates a new front-end instance with the specified config.
+func NewWithConfig(cfg Config) (*API, error) {
+	return new(cfg)
+}
+
 func new(cfg Config) (*API, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
@@ -132,6 +203,16 @@ func new(cfg Config) (*API, error) {
 
 	a.router.HandleFunc("/index/json", a.indexJSON())
 
+	a.router.Get(statusEndpoint, a.serviceStatus)
+
+	a.router.Get(suggestEndpoint, a.suggest)
+
+	a.router.Get(crawlRunsEndpoint, a.crawlRuns)
+
+	a.router.Get(linkEndpoint+"/{id}", a.renderLinkPage)
+
+	a.router.Get(relatedEndpoint+"/{id}", a.renderRelatedPage)
+
 	a.router.NotFound(a.render404Page)
 
 	return &a, nil
@@ -170,7 +251,7 @@ func (a *API) metricPrometheus() http.HandlerFunc {
 
 func (a *API) indexJSON() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		iter, err := a.cfg.IndexAPI.Search(index.Query{
+		iter, err := a.cfg.IndexAPI.SearchContext(r.Context(), index.Query{
 			Type:       0,
 			Expression: "",
 			Offset:     0,
@@ -205,10 +286,196 @@ func (a *API) indexJSON() http.HandlerFunc {
 	}
 }
 
+func (a *API) serviceStatus(w http.ResponseWriter, r *http.Request) {
+	statusFn, ok := a.cfg.Status[chi.URLParam(r, "service")]
+	if !ok {
+		http.Error(w, "unknown service", http.StatusNotFound)
+		return
+	}
+
+	status, err := statusFn()
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "service status error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *API) suggest(w http.ResponseWriter, r *http.Request) {
+	suggestions := []string{}
+	if a.cfg.SuggestAPI != nil {
+		if s := a.cfg.SuggestAPI.Suggest(r.URL.Query().Get("q"), defaultSuggestions); s != nil {
+			suggestions = s
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		log.Println(err)
+	}
+}
+
+// crawl runs from the newest, paged by the offset and limit parameters
+func (a *API) crawlRuns(w http.ResponseWriter, r *http.Request) {
+	runs := []*crawlrun.Run{}
+	if a.cfg.CrawlRunAPI != nil {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil || limit <= 0 {
+			limit = defaultCrawlRuns
+		}
+
+		list, err := a.cfg.CrawlRunAPI.Runs(max(offset, 0), limit)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "crawl runs error", http.StatusInternalServerError)
+			return
+		}
+		if list != nil {
+			runs = list
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(runs); err != nil {
+		log.Println(err)
+	}
+}
+
+func (a *API) renderLinkPage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		a.render404Page(w, r)
+		return
+	}
+
+	link, err := a.cfg.GraphAPI.LookupLinkContext(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, graph.ErrNotFound) {
+			a.render404Page(w, r)
+			return
+		}
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+
+	inDegree, outDegree, err := a.cfg.GraphAPI.DegreeContext(r.Context(), id)
+	if err != nil {
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+
+	inEdges, err := a.cfg.GraphAPI.InEdgesContext(r.Context(), id)
+	if err != nil {
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+	backlinks, err := a.neighbourLinks(r.Context(), inEdges, func(e *graph.Edge) uuid.UUID { return e.Src })
+	if err != nil {
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+
+	outEdges, err := a.cfg.GraphAPI.OutEdgesContext(r.Context(), id)
+	if err != nil {
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+	outlinks, err := a.neighbourLinks(r.Context(), outEdges, func(e *graph.Edge) uuid.UUID { return e.Dst })
+	if err != nil {
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+
+	_ = a.templateFunc(linkPageTemplate, w, map[string]interface{}{
+		"indexEndpoint":  indexEndpoint,
+		"searchEndpoint": searchEndpoint,
+		"linkEndpoint":   linkEndpoint,
+		"link":           link,
+		"inDegree":       inDegree,
+		"outDegree":      outDegree,
+		"backlinks":      backlinks,
+		"outlinks":       outlinks,
+	})
+}
+
+// pages similar to the document, only if the indexer can find them
+func (a *API) renderRelatedPage(w http.ResponseWriter, r *http.Request) {
+	if a.cfg.RelatedAPI == nil {
+		a.render404Page(w, r)
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		a.render404Page(w, r)
+		return
+	}
+
+	related, err := a.cfg.RelatedAPI.RelatedContext(r.Context(), id, a.cfg.ResultsPerPage)
+	if err != nil {
+		if errors.Is(err, index.ErrNotFound) {
+			a.render404Page(w, r)
+			return
+		}
+		a.renderSearchErrorPage(w, "")
+		return
+	}
+
+	matchedDocs := make([]matchedDoc, 0, len(related))
+	for _, doc := range related {
+		matchedDocs = append(matchedDocs, matchedDoc{
+			doc:     doc,
+			summary: highlightSummary(nil, doc.Content, a.cfg.MaxSummaryLength),
+		})
+	}
+
+	if err := a.templateFunc(resultsPageTemplate, w, map[string]interface{}{
+		"indexEndpoint":   indexEndpoint,
+		"searchEndpoint":  searchEndpoint,
+		"linkEndpoint":    linkEndpoint,
+		"relatedEndpoint": relatedEndpoint,
+		"pagination":      &paginationDetails{From: 1, To: len(matchedDocs), Total: len(matchedDocs)},
+		"results":         matchedDocs,
+		"groups":          groupByHost(matchedDocs, nil, 0),
+	}); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// resolve the other end of edges into links, at most ResultsPerPage links.
+// the edge may point to link that removed in the meantime, it is skipped.
+func (a *API) neighbourLinks(ctx context.Context, it graph.EdgeIterator, otherEnd func(*graph.Edge) uuid.UUID) ([]*graph.Link, error) {
+	defer func() { _ = it.Close() }()
+
+	links := make([]*graph.Link, 0, a.cfg.ResultsPerPage)
+	for len(links) < a.cfg.ResultsPerPage && it.Next() {
+		edge := it.Edge()
+		if edge == nil {
+			break
+		}
+
+		link, err := a.cfg.GraphAPI.LookupLinkContext(ctx, otherEnd(edge))
+		if err != nil {
+			if errors.Is(err, graph.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, it.Error()
+}
+
 func (a *API) renderIndexPage(w http.ResponseWriter, r *http.Request) {
 	_ = a.templateFunc(indexPageTemplate, w, map[string]interface{}{
 		"searchEndpoint":     searchEndpoint,
 		"submitLinkEndpoint": submitLinkEndpoint,
+		"suggestEndpoint":    suggestEndpoint,
 	})
 }
 
@@ -256,7 +523,7 @@ func (a *API) submitLink(w http.ResponseWriter, r *http.Request) {
 		}
 
 		link.Fragment = ""
-		if err = a.cfg.GraphAPI.UpsertLink(&graph.Link{URL: link.String()}); err != nil {
+		if err = a.cfg.GraphAPI.UpsertLinkContext(r.Context(), &graph.Link{URL: link.String(), Source: graph.SourceUser}); err != nil {
 			// a.cfg.Logger.WithField("err", err).Errorf("could not upsert link into link graph")
 			w.WriteHeader(http.StatusInternalServerError)
 			msg = "An error occurred while adding web site to our index; please try again later."
@@ -271,75 +538,237 @@ func (a *API) submitLink(w http.ResponseWriter, r *http.Request) {
 
 func (a *API) renderSearchResults(w http.ResponseWriter, r *http.Request) {
 	searchTerms := r.URL.Query().Get("q")
-	offset, _ := strconv.ParseUint(r.URL.Query().Get("offset"), 10, 64)
-
-	matchedDocs, pagination, err := a.runQuery(searchTerms, offset)
+	page := parsePage(r.URL.Query())
+	matchedDocs, pagination, err := a.runQuery(r.Context(), searchTerms, page)
 	if err != nil {
 		// a.cfg.Logger.WithField("err", err).Errorf("search query execution failed")
 		a.renderSearchErrorPage(w, searchTerms)
 		return
 	}
 
+	// only the first page count, walking the pages is not searching again
+	if a.cfg.SuggestAPI != nil && page.cursor == "" && pagination.Total > 0 {
+		a.cfg.SuggestAPI.RecordQuery(searchTerms)
+	}
+
+	var didYouMean, didYouMeanLink string
+	if page.cursor == "" && pagination.Total < a.cfg.ResultsPerPage {
+		if didYouMean = a.correctQuery(r.Context(), searchTerms, pagination.Total); didYouMean != "" {
+			didYouMeanLink = resultPage{from: 1, debug: page.debug}.link(didYouMean)
+		}
+	}
+
+	groups := groupByHost(matchedDocs, func(host string) string {
+		return resultPage{from: 1, debug: page.debug}.link(searchTerms + " site:" + host)
+	}, a.cfg.CollapseByHost)
+
+	// the similar pages link is shown if the indexer can find them
+	var related string
+	if a.cfg.RelatedAPI != nil {
+		related = relatedEndpoint
+	}
+
 	// Render results page
 	if err := a.templateFunc(resultsPageTemplate, w, map[string]interface{}{
-		"indexEndpoint":  indexEndpoint,
-		"searchEndpoint": searchEndpoint,
-		"searchTerms":    searchTerms,
-		"pagination":     pagination,
-		"results":        matchedDocs,
+		"indexEndpoint":   indexEndpoint,
+		"searchEndpoint":  searchEndpoint,
+		"linkEndpoint":    linkEndpoint,
+		"relatedEndpoint": related,
+		"searchTerms":     searchTerms,
+		"pagination":      pagination,
+		"results":         matchedDocs,
+		"groups":          groups,
+		"didYouMean":      didYouMean,
+		"didYouMeanLink":  didYouMeanLink,
 	}); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
-func (a *API) runQuery(searchTerms string, offset uint64) ([]matchedDoc, *paginationDetails, error) {
-	var query = index.Query{Type: index.QueryTypeMatch, Expression: searchTerms, Offset: offset}
-	if strings.HasPrefix(searchTerms, `"`) && strings.HasSuffix(searchTerms, `"`) {
-		query.Type = index.QueryTypePhrase
-		searchTerms = strings.Trim(searchTerms, `"`)
+// resultGroup is the results of the same site in a page, in the order of the best of them
+type resultGroup struct {
+	Host    string
+	Results []matchedDoc
+
+	// search of the site, set when some of its results is collapsed
+	MoreLink string
+}
+
+// group the results by host, the results without host are a group of their own.
+// the more link is set for the host with more than collapse results.
+func groupByHost(docs []matchedDoc, moreLink func(host string) string, collapse int) []*resultGroup {
+	var groups []*resultGroup
+	byHost := make(map[string]*resultGroup)
+	for _, doc := range docs {
+		host := index.Host(doc.doc.URL)
+		if g, ok := byHost[host]; ok && host != "" {
+			g.Results = append(g.Results, doc)
+			continue
+		}
+		g := &resultGroup{Host: host, Results: []matchedDoc{doc}}
+		if host != "" && collapse > 0 && doc.hostCount > collapse {
+			g.MoreLink = moreLink(host)
+		}
+		byHost[host] = g
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// spelling correction of the search terms that matched total results,
+// it is suggested only if it match many more. empty if no better correction.
+func (a *API) correctQuery(ctx context.Context, searchTerms string, total int) string {
+	if a.cfg.SpellAPI == nil {
+		return ""
+	}
+	corrected := a.cfg.SpellAPI.Correct(searchTerms)
+	if corrected == searchTerms {
+		return ""
+	}
+
+	query := parseQuery(corrected)
+	query.EstimateCount = true
+	it, err := a.cfg.IndexAPI.SearchContext(ctx, query)
+	if err != nil {
+		log.Println(err)
+		return ""
+	}
+	defer func() { _ = it.Close() }()
+
+	if correctedTotal := int(it.TotalCount()); correctedTotal <= 2*total || correctedTotal == 0 {
+		return ""
+	}
+	return corrected
+}
+
+// the most cursors of previous pages carried in the link, so the URL does not grow
+// with every page. Previous link fall back to the first page once the history ran out.
+const maxPrevCursors = 10
+
+// resultPage is position of the rendered result page.
+// pages are walked with the cursor of the index, the cursors of previous pages
+// are carried in the link so the Previous link does not need offset.
+type resultPage struct {
+	// start after this cursor, empty for the first page
+	cursor string
+
+	// start cursor of the previous pages except the first, oldest first,
+	// at most maxPrevCursors of the nearest pages
+	prev []string
+
+	// position of the first result of the page, start from 1
+	from int
+
+	// show the score breakdown of each result
+	debug bool
+}
+
+func parsePage(v url.Values) resultPage {
+	p := resultPage{cursor: v.Get("cursor"), from: 1}
+	p.debug, _ = strconv.ParseBool(v.Get("debug"))
+	if prev := v.Get("prev"); prev != "" && p.cursor != "" {
+		p.prev = strings.Split(prev, ",")
+		if n := len(p.prev); n > maxPrevCursors {
+			p.prev = p.prev[n-maxPrevCursors:]
+		}
+	}
+	if from, err := strconv.Atoi(v.Get("from")); err == nil && from > 1 && p.cursor != "" {
+		p.from = from
 	}
+	return p
+}
+
+// link to the page of the search terms
+func (p resultPage) link(searchTerms string) string {
+	v := url.Values{"q": {searchTerms}}
+	if p.cursor != "" {
+		v.Set("cursor", p.cursor)
+		v.Set("from", strconv.Itoa(p.from))
+	}
+	if len(p.prev) > 0 {
+		v.Set("prev", strings.Join(p.prev, ","))
+	}
+	if p.debug {
+		v.Set("debug", "1")
+	}
+	return searchEndpoint + "?" + v.Encode()
+}
 
-	resultIt, err := a.cfg.IndexAPI.Search(query)
+func (a *API) runQuery(ctx context.Context, searchTerms string, page resultPage) ([]matchedDoc, *paginationDetails, error) {
+	query := parseQuery(searchTerms)
+	query.Cursor = page.cursor
+	// one more than the page tell whether there is next page
+	query.Limit = a.cfg.ResultsPerPage + 1
+	query.EstimateCount = true
+	query.Ranking = a.cfg.Ranking
+	query.Explain = page.debug
+	query.HighlightLength = a.cfg.MaxSummaryLength
+	// every result of site: query is from the same site
+	if query.Filters.Site == "" {
+		query.CollapseByHost = a.cfg.CollapseByHost
+	}
+
+	resultIt, err := a.cfg.IndexAPI.SearchContext(ctx, query)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer func() { _ = resultIt.Close() }()
 
-	// Wrap each result in a matchedDoc shim and generate a short summary which
-	// highlights the matching search terms.
-	summarizer := newMatchSummarizer(searchTerms, a.cfg.MaxSummaryLength)
-	highlighter := newMatchHighlighter(searchTerms)
+	// Wrap each result in a matchedDoc shim with the fragments highlighted by the indexer.
+	// the page size is checked first so the cursor stay at the last rendered result.
+	explainIt, _ := resultIt.(index.ExplainIterator)
+	collapseIt, _ := resultIt.(index.CollapseIterator)
 	matchedDocs := make([]matchedDoc, 0, a.cfg.ResultsPerPage)
-	for resCount := 0; resultIt.Next() && resCount < a.cfg.ResultsPerPage; resCount++ {
+	for len(matchedDocs) < a.cfg.ResultsPerPage && resultIt.Next() {
 		doc := resultIt.Document()
-		matchedDocs = append(matchedDocs, matchedDoc{
-			doc: doc,
-			summary: highlighter.Highlight(
-				template.HTMLEscapeString(
-					summarizer.MatchSummary(doc.Content),
-				),
-			),
-		})
+		matched := matchedDoc{
+			doc:     doc,
+			summary: highlightSummary(resultIt.Highlight(), doc.Content, a.cfg.MaxSummaryLength),
+		}
+		if explainIt != nil {
+			matched.explanation = explainIt.Explain()
+		}
+		if collapseIt != nil {
+			matched.hostCount = collapseIt.HostCount()
+		}
+		matchedDocs = append(matchedDocs, matched)
 	}
 
+	// taken before the extra result is read
+	nextCursor := resultIt.Cursor()
+	hasNext := len(matchedDocs) == a.cfg.ResultsPerPage && resultIt.Next()
+
 	if err = resultIt.Error(); err != nil {
 		return nil, nil, err
 	}
 
-	// Setup paginator and generate prev/next links
+	// Setup paginator and generate prev/next links,
+	// the total count of collapsed result include the collapsed documents
 	pagination := &paginationDetails{
-		From:  int(offset + 1),
-		To:    int(offset) + len(matchedDocs),
-		Total: int(resultIt.TotalCount()),
-	}
-	if offset > 0 {
-		pagination.PrevLink = fmt.Sprintf("%s?q=%s", searchEndpoint, searchTerms)
-		if prevOffset := int(offset) - a.cfg.ResultsPerPage; prevOffset > 0 {
-			pagination.PrevLink += fmt.Sprintf("&offset=%d", prevOffset)
+		From:      page.from,
+		To:        page.from + len(matchedDocs) - 1,
+		Total:     int(resultIt.TotalCount()),
+		Collapsed: query.CollapseByHost > 0,
+	}
+	if page.cursor != "" {
+		prev := resultPage{from: page.from - a.cfg.ResultsPerPage, debug: page.debug}
+		if n := len(page.prev); n > 0 {
+			prev.cursor, prev.prev = page.prev[n-1], page.prev[:n-1]
+		}
+		if prev.cursor == "" || prev.from < 1 {
+			prev = resultPage{from: 1, debug: page.debug}
 		}
+		pagination.PrevLink = prev.link(searchTerms)
 	}
-	if nextPageOffset := int(offset) + len(matchedDocs); nextPageOffset < pagination.Total {
-		pagination.NextLink = fmt.Sprintf("%s?q=%s&offset=%d", searchEndpoint, searchTerms, nextPageOffset)
+	if hasNext {
+		next := resultPage{cursor: nextCursor, from: pagination.To + 1, debug: page.debug}
+		if page.cursor != "" {
+			next.prev = append(append(next.prev, page.prev...), page.cursor)
+			if n := len(next.prev); n > maxPrevCursors {
+				next.prev = next.prev[n-maxPrevCursors:]
+			}
+		}
+		pagination.NextLink = next.link(searchTerms)
 	}
 
 	return matchedDocs, pagination, nil
@@ -352,6 +781,9 @@ type paginationDetails struct {
 	Total    int
 	PrevLink string
 	NextLink string
+
+	// Total count the documents hidden by collapsing too
+	Collapsed bool
 }
 
 // mathcedDoc wraps an index.Document and provides convenience methods for
@@ -359,10 +791,20 @@ type paginationDetails struct {
 type matchedDoc struct {
 	doc     *index.Document
 	summary string
+
+	// matched documents of the host including the collapsed ones,
+	// zero when the result is not collapsed
+	hostCount int
+
+	// only set in debug mode
+	explanation *index.Explanation
 }
 
+func (d *matchedDoc) Explanation() *index.Explanation { return d.explanation }
+
 func (d *matchedDoc) HighlightedSummary() template.HTML { return template.HTML(d.summary) }
 func (d *matchedDoc) URL() string                       { return d.doc.URL }
+func (d *matchedDoc) LinkID() string                    { return d.doc.LinkID.String() }
 func (d *matchedDoc) Title() string {
 	if d.doc.Title != "" {
 		return d.doc.Title
@@ -0,0 +1,223 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkcrawler/crawlrun"
+	"github.com/odit-bit/invoker/textIndex/index"
+	"github.com/odit-bit/invoker/textIndex/store/memory"
+)
+
+func Test_groupByHost(t *testing.T) {
+	doc := func(url string, hostCount int) matchedDoc {
+		return matchedDoc{doc: &index.Document{LinkID: uuid.New(), URL: url}, hostCount: hostCount}
+	}
+	docs := []matchedDoc{
+		doc("https://go.dev/a", 5),
+		doc("https://pkg.go.dev", 1),
+		doc("https://GO.dev/b", 5),
+		doc("www.no-host.com", 1),
+		doc("www.no-host.com/2", 1),
+		doc("https://golang.org", 2),
+	}
+	groups := groupByHost(docs, func(host string) string { return "more " + host }, 2)
+
+	var got []string
+	for _, g := range groups {
+		got = append(got, fmt.Sprintf("%v:%v:%v", g.Host, len(g.Results), g.MoreLink))
+	}
+	expect := []string{"go.dev:2:more go.dev", "pkg.go.dev:1:", ":1:", ":1:", "golang.org:1:"}
+	if fmt.Sprint(got) != fmt.Sprint(expect) {
+		t.Fatalf("\ngot:%v\nexpect:%v", got, expect)
+	}
+
+	// the groups can be rendered
+	err := resultsPageTemplate.Execute(io.Discard, map[string]interface{}{
+		"pagination": &paginationDetails{From: 1, To: len(docs), Total: len(docs)},
+		"results":    docs,
+		"groups":     groups,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// related indexer that return the documents of the map
+type relatedIndex struct {
+	related map[uuid.UUID][]*index.Document
+}
+
+func (ri *relatedIndex) Related(linkID uuid.UUID, n int) ([]*index.Document, error) {
+	docs, ok := ri.related[linkID]
+	if !ok {
+		return nil, fmt.Errorf("related: %w", index.ErrNotFound)
+	}
+	return docs, nil
+}
+
+func (ri *relatedIndex) RelatedContext(_ context.Context, linkID uuid.UUID, n int) ([]*index.Document, error) {
+	return ri.Related(linkID, n)
+}
+
+func Test_renderRelatedPage(t *testing.T) {
+	source := uuid.New()
+	similar := &index.Document{LinkID: uuid.New(), URL: "https://go.dev/gopher", Title: "gopher"}
+	api := &API{router: chi.NewMux(), cfg: Config{
+		RelatedAPI:       &relatedIndex{related: map[uuid.UUID][]*index.Document{source: {similar}}},
+		ResultsPerPage:   defaultResultsPerPage,
+		MaxSummaryLength: defaultMaxSummaryLength,
+	}, templateFunc: func(tpl *template.Template, w io.Writer, data map[string]interface{}) error {
+		return tpl.Execute(w, data)
+	}}
+	api.router.Get(relatedEndpoint+"/{id}", api.renderRelatedPage)
+
+	tests := []struct {
+		id     string
+		status int
+		expect string
+	}{
+		{id: source.String(), status: http.StatusOK, expect: relatedEndpoint + "/" + similar.LinkID.String()},
+		{id: uuid.NewString(), status: http.StatusOK, expect: "Page not found"},
+		{id: "not-uuid", status: http.StatusOK, expect: "Page not found"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		api.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, relatedEndpoint+"/"+test.id, nil))
+		if rec.Code != test.status || !strings.Contains(rec.Body.String(), test.expect) {
+			t.Fatalf("\nid:%v\ngot:%v\nexpect:%v %v", test.id, rec.Code, test.status, test.expect)
+		}
+	}
+}
+
+func Test_crawlRuns(t *testing.T) {
+	runs := crawlrun.NewInMemory()
+	for i := 0; i < 3; i++ {
+		if err := runs.StartRun(&crawlrun.Run{Partition: i, NumPartitions: 3}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api := &API{cfg: Config{CrawlRunAPI: runs}}
+
+	tests := []struct {
+		query  string
+		expect int
+	}{
+		{query: "", expect: 3},
+		{query: "?limit=2", expect: 2},
+		{query: "?offset=2&limit=2", expect: 1},
+		{query: "?offset=5", expect: 0},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		api.crawlRuns(rec, httptest.NewRequest(http.MethodGet, crawlRunsEndpoint+test.query, nil))
+
+		var got []*crawlrun.Run
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != test.expect {
+			t.Fatalf("\nquery:%v\ngot:%v\nexpect:%v", test.query, len(got), test.expect)
+		}
+	}
+}
+
+func Test_runQuery_next(t *testing.T) {
+	idx, err := memory.NewInMemoryIndexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		doc := &index.Document{LinkID: uuid.New(), URL: fmt.Sprintf("https://go.dev/%v", i), Title: "gopher", Content: "gopher"}
+		if err := idx.Index(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the next link is shown only when there is document after the page
+	tests := []struct {
+		perPage int
+		next    bool
+	}{
+		{perPage: 2, next: true},
+		{perPage: 4, next: false},
+	}
+	for _, test := range tests {
+		api := &API{cfg: Config{IndexAPI: index.WithContext(idx), ResultsPerPage: test.perPage}}
+		docs, pagination, err := api.runQuery(context.TODO(), "gopher", resultPage{from: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != test.perPage || (pagination.NextLink != "") != test.next {
+			t.Fatalf("\nper page:%v\ngot:%v %q\nexpect next:%v", test.perPage, len(docs), pagination.NextLink, test.next)
+		}
+	}
+}
+
+func Test_runQuery_prev_history(t *testing.T) {
+	idx, err := memory.NewInMemoryIndexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := maxPrevCursors + 4
+	for i := 0; i < pages; i++ {
+		doc := &index.Document{LinkID: uuid.New(), URL: fmt.Sprintf("https://go.dev/%v", i), Title: "gopher", Content: "gopher"}
+		if err := idx.Index(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// follow the next link to the last page, the history stay capped
+	api := &API{cfg: Config{IndexAPI: index.WithContext(idx), ResultsPerPage: 1}}
+	page := resultPage{from: 1}
+	var pagination *paginationDetails
+	for i := 1; i < pages; i++ {
+		if _, pagination, err = api.runQuery(context.TODO(), "gopher", page); err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(pagination.NextLink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page = parsePage(u.Query())
+		if len(page.prev) > maxPrevCursors {
+			t.Fatalf("\ngot:%v\nexpect at most:%v", len(page.prev), maxPrevCursors)
+		}
+	}
+	if page.from != pages {
+		t.Fatalf("\ngot:%v\nexpect:%v", page.from, pages)
+	}
+
+	// walk back, the pages that dropped from the history fall back to the first page
+	for i := 0; i < maxPrevCursors; i++ {
+		if _, pagination, err = api.runQuery(context.TODO(), "gopher", page); err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(pagination.PrevLink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev := parsePage(u.Query())
+		if prev.from != page.from-1 {
+			t.Fatalf("\ngot:%v\nexpect:%v", prev.from, page.from-1)
+		}
+		page = prev
+	}
+	if _, pagination, err = api.runQuery(context.TODO(), "gopher", page); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(pagination.PrevLink)
+	if first := parsePage(u.Query()); first.from != 1 || first.cursor != "" {
+		t.Fatalf("\ngot:%+v\nexpect first page", first)
+	}
+}
@@ -1,42 +1,53 @@
 package frontend
 
 import (
-	"fmt"
-	"regexp"
+	"html/template"
 	"strings"
-)
 
-type matchHighlighter struct {
-	// A regex for matching terms as standalone words at the beginning, middle or
-	// end of a sentence
-	regexes []*regexp.Regexp
-}
+	"github.com/odit-bit/invoker/textIndex/index"
+)
 
-// newMatchHighlighter returns a matchHighlighter instance that can highlight
-// the specified searchTerms in blocks of text.
-func newMatchHighlighter(searchTerms string) *matchHighlighter {
-	var regexes []*regexp.Regexp
-	for _, token := range strings.Fields(strings.Trim(searchTerms, `"`)) {
-		re, err := regexp.Compile(
-			fmt.Sprintf(`(?i)%s`, regexp.QuoteMeta(token)),
-		)
-		if err != nil {
-			continue
-		}
+// between the fragments, they are not adjacent in the content
+const fragmentSeparator = " … "
 
-		regexes = append(regexes, re)
+// highlightSummary render the fragments of the indexer as HTML with the matched terms in <em> tags.
+// indexer that can not highlight return the content instead,
+// the beginning of it is the summary.
+func highlightSummary(hl *index.Highlight, content string, maxLen int) string {
+	if hl == nil {
+		return template.HTMLEscapeString(truncate(strings.TrimSpace(content), maxLen))
 	}
 
-	return &matchHighlighter{regexes: regexes}
+	var sb strings.Builder
+	for i, f := range hl.Fragments {
+		if i > 0 {
+			sb.WriteString(fragmentSeparator)
+		}
+		last := 0
+		for _, m := range f.Matches {
+			// offsets are from the indexer, skip the one that not fit
+			if m.Start < last || m.End > len(f.Text) || m.Start >= m.End {
+				continue
+			}
+			sb.WriteString(template.HTMLEscapeString(f.Text[last:m.Start]))
+			sb.WriteString("<em>")
+			sb.WriteString(template.HTMLEscapeString(f.Text[m.Start:m.End]))
+			sb.WriteString("</em>")
+			last = m.End
+		}
+		sb.WriteString(template.HTMLEscapeString(f.Text[last:]))
+	}
+	return strings.TrimSpace(sb.String())
 }
 
-// Highlight the configured search terms in the provided sentence by wrapping
-// them in <em> tags.
-func (h *matchHighlighter) Highlight(sentence string) string {
-	for _, re := range h.regexes {
-		sentence = re.ReplaceAllStringFunc(sentence, func(match string) string {
-			return "<em>" + match + "</em>"
-		})
+// cut the text into maxLen runes
+func truncate(text string, maxLen int) string {
+	n := 0
+	for i := range text {
+		if n == maxLen {
+			return text[:i] + "..."
+		}
+		n++
 	}
-	return sentence
+	return text
 }
@@ -0,0 +1,31 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+func Test_highlightSummary(t *testing.T) {
+	tests := []struct {
+		hl      *index.Highlight
+		content string
+		expect  string
+	}{
+		{
+			hl: &index.Highlight{Fragments: []index.Fragment{
+				{Text: "the <gopher> was running", Matches: []index.Span{{Start: 5, End: 11}, {Start: 17, End: 24}}},
+				{Text: "run again", Matches: []index.Span{{Start: 0, End: 3}, {Start: 2, End: 5}, {Start: 4, End: 99}}},
+			}},
+			content: "not used",
+			expect:  "the &lt;<em>gopher</em>&gt; was <em>running</em> … <em>run</em> again",
+		},
+		{hl: &index.Highlight{}, content: "not used", expect: ""},
+		{hl: nil, content: "  content <b> of the page", expect: "content &lt;b..."},
+	}
+	for _, test := range tests {
+		if got := highlightSummary(test.hl, test.content, 10); got != test.expect {
+			t.Fatalf("\ngot:%v\nexpect:%v", got, test.expect)
+		}
+	}
+}
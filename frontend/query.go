@@ -0,0 +1,297 @@
+package frontend
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// date layout of after: and before: operator
+const queryDateLayout = "2006-01-02"
+
+// operators that set filter of the query, other "name:" is a plain term
+var queryOperators = map[string]bool{
+	"site":    true,
+	"inurl":   true,
+	"intitle": true,
+	"lang":    true,
+	"after":   true,
+	"before":  true,
+}
+
+// parseQuery turn the search input into index.Query.
+// supported syntax:
+//
+//	site:go.dev        url host is go.dev or its subdomain
+//	inurl:blog         url contain the substring
+//	intitle:gopher     title contain the term, quoted value for many terms
+//	lang:en            document language
+//	after:2023-01-02   indexed at or after the date (UTC)
+//	before:2023-01-02  indexed before the date (UTC)
+//	"exact phrase"     document contain the phrase
+//	-foo -"foo bar"    document not contain the term or phrase
+//	go*  g?pher        term with wildcard
+//	gophr~  gophr~2    term within edit distance, 1 if not specified
+//	a OR (b AND NOT c) boolean grouping, terms next to each other are AND
+//
+// input with wildcard, fuzzy term, parentheses or AND/OR/NOT keyword become boolean query.
+// malformed operator value is searched as plain term, negated operator is ignored.
+func parseQuery(input string) index.Query {
+	var (
+		q     = index.Query{Type: index.QueryTypeMatch}
+		terms []string
+		rest  []queryToken
+	)
+	for _, tok := range tokenizeQuery(input) {
+		value := strings.TrimSpace(tok.value)
+		if value == "" && tok.group == 0 {
+			continue
+		}
+
+		switch {
+		case tok.negate && tok.op != "":
+		case tok.op == "site":
+			q.Filters.Site = strings.ToLower(value)
+		case tok.op == "inurl":
+			q.Filters.InURL = value
+		case tok.op == "intitle":
+			q.Filters.InTitle = strings.TrimSpace(q.Filters.InTitle + " " + value)
+		case tok.op == "lang" && index.NormalizeLanguage(value) != "":
+			q.Filters.Language = index.NormalizeLanguage(value)
+		case tok.op == "after" && isQueryDate(value):
+			q.Filters.IndexedAfter, _ = time.Parse(queryDateLayout, value)
+		case tok.op == "before" && isQueryDate(value):
+			q.Filters.IndexedBefore, _ = time.Parse(queryDateLayout, value)
+		case tok.op != "":
+			rest = append(rest, queryToken{value: tok.op + ":" + value})
+		default:
+			tok.value = value
+			rest = append(rest, tok)
+		}
+	}
+
+	if isBooleanQuery(rest) {
+		if tree := parseClauses(rest); tree != nil {
+			q.Type = index.QueryTypeBoolean
+			q.Boolean = tree
+		}
+		return q
+	}
+
+	for _, tok := range rest {
+		switch {
+		case tok.negate:
+			q.Exclude = append(q.Exclude, tok.value)
+		case tok.quoted:
+			q.Phrases = append(q.Phrases, tok.value)
+		default:
+			terms = append(terms, tok.value)
+		}
+	}
+	q.Expression = strings.Join(terms, " ")
+	return q
+}
+
+func isQueryDate(value string) bool {
+	_, err := time.Parse(queryDateLayout, value)
+	return err == nil
+}
+
+// fuzzy term and its optional edit distance, "gophr~1"
+var fuzzyTermRegex = regexp.MustCompile(`^(.+)~([0-9]?)$`)
+
+func isBooleanQuery(tokens []queryToken) bool {
+	for _, tok := range tokens {
+		switch {
+		case tok.group != 0:
+			return true
+		case tok.quoted:
+		case isKeyword(tok, "AND"), isKeyword(tok, "OR"), isKeyword(tok, "NOT"):
+			return true
+		case strings.ContainsAny(tok.value, "*?"), fuzzyTermRegex.MatchString(tok.value):
+			return true
+		}
+	}
+	return false
+}
+
+func isKeyword(tok queryToken, keyword string) bool {
+	return !tok.quoted && !tok.negate && tok.group == 0 && tok.value == keyword
+}
+
+// parse the tokens into clause tree, NOT bind tighter than AND then OR.
+// unbalanced parentheses are tolerated.
+func parseClauses(tokens []queryToken) *index.Clause {
+	p := clauseParser{tokens: tokens}
+	var clauses []*index.Clause
+	for p.pos < len(p.tokens) {
+		if c := p.parseOr(); c != nil {
+			clauses = append(clauses, c)
+		}
+		// skip stray closing parenthesis
+		if p.pos < len(p.tokens) {
+			p.pos++
+		}
+	}
+	return clauseGroup(index.ClauseAnd, clauses)
+}
+
+type clauseParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *clauseParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *clauseParser) parseOr() *index.Clause {
+	var clauses []*index.Clause
+	for {
+		if c := p.parseAnd(); c != nil {
+			clauses = append(clauses, c)
+		}
+		tok, ok := p.peek()
+		if !ok || !isKeyword(tok, "OR") {
+			return clauseGroup(index.ClauseOr, clauses)
+		}
+		p.pos++
+	}
+}
+
+func (p *clauseParser) parseAnd() *index.Clause {
+	var clauses []*index.Clause
+	for {
+		tok, ok := p.peek()
+		switch {
+		case !ok, tok.group == ')', isKeyword(tok, "OR"):
+			return clauseGroup(index.ClauseAnd, clauses)
+		case isKeyword(tok, "AND"):
+			p.pos++
+		default:
+			if c := p.parseUnary(); c != nil {
+				clauses = append(clauses, c)
+			}
+		}
+	}
+}
+
+func (p *clauseParser) parseUnary() *index.Clause {
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	var c *index.Clause
+	switch {
+	case isKeyword(tok, "NOT"):
+		if next, ok := p.peek(); !ok || next.group == ')' {
+			return nil
+		}
+		return clauseNot(p.parseUnary())
+	case tok.group == '(':
+		c = p.parseOr()
+		if next, ok := p.peek(); ok && next.group == ')' {
+			p.pos++
+		}
+	default:
+		c = leafClause(tok)
+	}
+
+	if tok.negate {
+		return clauseNot(c)
+	}
+	return c
+}
+
+func leafClause(tok queryToken) *index.Clause {
+	c := &index.Clause{Op: index.ClauseLeaf, Type: index.QueryTypeMatch, Text: tok.value}
+	switch matched := fuzzyTermRegex.FindStringSubmatch(tok.value); {
+	case tok.quoted:
+		c.Type = index.QueryTypePhrase
+	case matched != nil:
+		c.Type, c.Text = index.QueryTypeFuzzy, matched[1]
+		c.Fuzziness, _ = strconv.Atoi(matched[2])
+	case strings.ContainsAny(tok.value, "*?"):
+		c.Type = index.QueryTypePrefix
+	}
+	return c
+}
+
+func clauseNot(c *index.Clause) *index.Clause {
+	if c == nil {
+		return nil
+	}
+	return &index.Clause{Op: index.ClauseNot, Clauses: []*index.Clause{c}}
+}
+
+// single clause is not wrapped
+func clauseGroup(op index.ClauseOp, clauses []*index.Clause) *index.Clause {
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0]
+	}
+	return &index.Clause{Op: op, Clauses: clauses}
+}
+
+type queryToken struct {
+	negate bool
+	op     string
+	value  string
+	quoted bool
+
+	// '(' or ')' of grouping token
+	group byte
+}
+
+// split input by space and parentheses, quoted value is one token
+// and unterminated quote end at the input end
+func tokenizeQuery(input string) []queryToken {
+	var tokens []queryToken
+	for {
+		input = strings.TrimLeftFunc(input, unicode.IsSpace)
+		if input == "" {
+			return tokens
+		}
+
+		var tok queryToken
+		if len(input) > 1 && input[0] == '-' {
+			tok.negate = true
+			input = input[1:]
+		}
+		if input[0] == '(' || input[0] == ')' {
+			tok.group, input = input[0], input[1:]
+			tokens = append(tokens, tok)
+			continue
+		}
+		if i := strings.IndexAny(input, ": \t\n\"()"); i > 0 && input[i] == ':' && queryOperators[strings.ToLower(input[:i])] {
+			tok.op = strings.ToLower(input[:i])
+			input = input[i+1:]
+		}
+
+		if strings.HasPrefix(input, `"`) {
+			tok.quoted = true
+			if end := strings.IndexByte(input[1:], '"'); end >= 0 {
+				tok.value, input = input[1:end+1], input[end+2:]
+			} else {
+				tok.value, input = input[1:], ""
+			}
+		} else {
+			end := strings.IndexFunc(input, func(r rune) bool {
+				return unicode.IsSpace(r) || r == '(' || r == ')'
+			})
+			if end < 0 {
+				end = len(input)
+			}
+			tok.value, input = input[:end], input[end:]
+		}
+		tokens = append(tokens, tok)
+	}
+}
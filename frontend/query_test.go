@@ -0,0 +1,99 @@
+package frontend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+func Test_parseQuery(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect index.Query
+	}{
+		{"gopher", index.Query{Expression: "gopher"}},
+		{`site:Go.dev -foo "exact phrase" gopher`, index.Query{
+			Expression: "gopher",
+			Phrases:    []string{"exact phrase"},
+			Exclude:    []string{"foo"},
+			Filters:    index.Filters{Site: "go.dev"},
+		}},
+		{`inurl:/blog intitle:"go news" intitle:release -"bad word"`, index.Query{
+			Exclude: []string{"bad word"},
+			Filters: index.Filters{InURL: "/blog", InTitle: "go news release"},
+		}},
+		{"lang:en-US after:2023-01-02 before:2023-02-01", index.Query{
+			Filters: index.Filters{
+				Language:      "en",
+				IndexedAfter:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				IndexedBefore: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+		}},
+		// malformed value and unknown operator are plain term
+		{"after:yesterday http://go.dev", index.Query{Expression: "after:yesterday http://go.dev"}},
+		// negated operator ignored, unterminated quote end at the input end
+		{`-site:go.dev "open phrase`, index.Query{Phrases: []string{"open phrase"}}},
+		{`  - "" `, index.Query{}},
+	}
+	for _, test := range tests {
+		got := parseQuery(test.input)
+		if fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", test.expect) {
+			t.Fatalf("\ninput:%v\ngot:%+v\nexpect:%+v", test.input, got, test.expect)
+		}
+	}
+}
+
+func Test_parseQuery_boolean(t *testing.T) {
+	leaf := func(typ index.QueryType, text string, fuzziness int) *index.Clause {
+		return &index.Clause{Type: typ, Text: text, Fuzziness: fuzziness}
+	}
+	match := func(text string) *index.Clause { return leaf(index.QueryTypeMatch, text, 0) }
+	group := func(op index.ClauseOp, clauses ...*index.Clause) *index.Clause {
+		return &index.Clause{Op: op, Clauses: clauses}
+	}
+
+	tests := []struct {
+		input  string
+		expect *index.Clause
+	}{
+		{"go*", leaf(index.QueryTypePrefix, "go*", 0)},
+		{"gophr~ rust~2", group(index.ClauseAnd, leaf(index.QueryTypeFuzzy, "gophr", 0), leaf(index.QueryTypeFuzzy, "rust", 2))},
+		{`a OR b c`, group(index.ClauseOr, match("a"), group(index.ClauseAnd, match("b"), match("c")))},
+		{`(a OR b) AND NOT "c d" -e`, group(index.ClauseAnd,
+			group(index.ClauseOr, match("a"), match("b")),
+			group(index.ClauseNot, leaf(index.QueryTypePhrase, "c d", 0)),
+			group(index.ClauseNot, match("e")),
+		)},
+		{`-(a OR b`, group(index.ClauseNot, group(index.ClauseOr, match("a"), match("b")))},
+		{`a) OR NOT`, match("a")},
+	}
+	for _, test := range tests {
+		got := parseQuery(test.input)
+		if got.Type != index.QueryTypeBoolean || !equalClause(got.Boolean, test.expect) {
+			t.Fatalf("\ninput:%v\ngot:%v\nexpect:%v", test.input, clauseString(got.Boolean), clauseString(test.expect))
+		}
+	}
+
+	// filters are not part of the tree
+	got := parseQuery("site:go.dev go* ()")
+	if got.Filters.Site != "go.dev" || !equalClause(got.Boolean, leaf(index.QueryTypePrefix, "go*", 0)) {
+		t.Fatalf("\ngot:%+v", got)
+	}
+}
+
+func equalClause(a, b *index.Clause) bool {
+	return clauseString(a) == clauseString(b)
+}
+
+func clauseString(c *index.Clause) string {
+	if c == nil {
+		return "<nil>"
+	}
+	s := fmt.Sprintf("{%v %v %q %v", c.Op, c.Type, c.Text, c.Fuzziness)
+	for _, child := range c.Clauses {
+		s += " " + clauseString(child)
+	}
+	return s + "}"
+}
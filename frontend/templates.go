@@ -31,13 +31,43 @@ var (
     </header>
     <section class="tc">
       <form action="{{.searchEndpoint}}">
-      <input class="t" type="text" name="q" placeholder="Enter search ex: github"/>
+      <input class="t" id="q" type="text" name="q" placeholder="Enter search ex: github" autocomplete="off" list="suggestions"/>
+      <datalist id="suggestions"></datalist>
       <br>
       <input class="sb" type="submit" value="Search"/>
       </form>
 			<br/><br/>
       <a rel="nofollow" href="{{.submitLinkEndpoint}}">Submit Web Site</a>
     </section>
+    <script>
+      (function() {
+        var input = document.getElementById("q");
+        var list = document.getElementById("suggestions");
+        var timer;
+        // wait until the typing pause, every keystroke is not a request
+        input.addEventListener("input", function() {
+          clearTimeout(timer);
+          timer = setTimeout(function() {
+            var q = input.value;
+            if (q.trim() === "") {
+              list.innerHTML = "";
+              return;
+            }
+            fetch("{{.suggestEndpoint}}?q=" + encodeURIComponent(q))
+              .then(function(res) { return res.json(); })
+              .then(function(suggestions) {
+                list.innerHTML = "";
+                suggestions.forEach(function(s) {
+                  var opt = document.createElement("option");
+                  opt.value = s;
+                  list.appendChild(opt);
+                });
+              })
+              .catch(function() {});
+          }, 150);
+        });
+      })();
+    </script>
   </body>
 </html>
 `))
@@ -115,6 +145,10 @@ var (
 			.rc cite{color:green;font-size:0.8em;display:block;margin-bottom:2px;}
 			.rc .ms {text-align:justify;font-size:0.9em;}
 			.rc .ms em{background-color:yellow;font-weight:bold;}
+			.rc.sr {padding-left:50px;}
+			.rc .mr {font-size:0.8em;color:blue;text-decoration:none;}
+			.rc .dm {font-size:1.0em;}
+			.rc .dm a{color:blue;font-weight:bold;font-style:italic;text-decoration:none;}
 			.nb{padding:15px 20px;border-top:1px solid gray;}
 			.nb a{padding-right:15px;text-decoration:none;color:blue;}
 			.nb a:visited{color:blue;}
@@ -138,17 +172,28 @@ var (
       </section>
     </header>
     <hr/>
-		{{if .results}}
+		{{if .didYouMean}}
     <section class="rc">
-      <span class="rt">Displaying results {{.pagination.From}} to {{.pagination.To}} from {{.pagination.Total}}.</span>
+      <span class="dm">Did you mean <a rel="nofollow" href="{{.didYouMeanLink}}">{{.didYouMean}}</a>?</span>
     </section>
-		{{range .results}}
+		{{end}}
+		{{if .results}}
     <section class="rc">
+      <span class="rt">Displaying results {{.pagination.From}} to {{.pagination.To}} {{if .pagination.Collapsed}}of {{.pagination.Total}} matches, similar results of the same site are hidden{{else}}from {{.pagination.Total}}{{end}}.</span>
+    </section>
+		{{range .groups}}
+		{{range $i, $result := .Results}}
+    <section class="rc{{if $i}} sr{{end}}">
       <a class="ml" rel="nofollow" href="{{.URL}}">{{.Title}}</a>
-			<cite>{{.URL}}</cite>
+			<cite>{{.URL}} <a rel="nofollow" href="{{$.linkEndpoint}}/{{.LinkID}}">links</a>{{if $.relatedEndpoint}} <a rel="nofollow" href="{{$.relatedEndpoint}}/{{.LinkID}}">similar pages</a>{{end}}</cite>
       <section class="ms">{{.HighlightedSummary}}</section>
+      {{with .Explanation}}<section class="ms"><code>score {{printf "%.4f" .Score}}: relevance {{printf "%.3f" .Relevance}}, pagerank {{printf "%.3f" .PageRank}}, freshness {{printf "%.3f" .Freshness}}, title {{printf "%.0f" .TitleMatch}}, depth {{printf "%.3f" .URLDepth}}</code></section>{{end}}
     </section>
 		{{end}}
+		{{if .MoreLink}}
+    <section class="rc sr"><a class="mr" rel="nofollow" href="{{.MoreLink}}">More results from {{.Host}}</a></section>
+		{{end}}
+		{{end}}
     <section class="nb">
 		  {{if .pagination.PrevLink}}<a rel="nofollow" href="{{.pagination.PrevLink}}">Previous</a>{{end}}
 		  {{if .pagination.NextLink}}<a rel="nofollow" href="{{.pagination.NextLink}}">Next</a>{{end}}
@@ -205,5 +250,69 @@ var (
     </section>
   </body>
 </html>
+`))
+
+	linkPageTemplate = template.Must(template.New("link").Parse(`
+<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="UTF-8">
+    <title>bukan GatotKaca | {{.link.URL}}</title>
+    <style>
+      .is{display:inline;}
+      .l{font-size:2em;font-weight:bold;text-shadow: 1px 1px 1px rgba(0,0,0,0.4);}
+			.l a{text-decoration: none;}
+      .r{color:red;}
+      .g{color:green;}
+      .b{color:blue;}
+      .o{color:white;}
+      .t{border:1px solid lightgray;border-radius:24px;padding:10px;width:40%;}
+      .sb{padding:10px;margin-top:20px;}
+			form{display:inline;padding-left:10px;}
+      hr{border:1px solid gray;}
+      .rc{padding:10px 20px;}
+      .rc .rt {color:grey;font-size:0.9em;}
+			.rc .ml {text-decoration:none;display:inline-block;font-size:1.0em;font-weight:bold;margin-bottom:0;}
+			.rc cite{color:green;font-size:0.8em;display:block;margin-bottom:2px;}
+			.rc ul{font-size:0.9em;}
+      input:focus{outline: none;}
+    </style>
+  </head>
+  <body>
+    <header>
+      <section class="l is">
+			  <a rel="nofollow" href="{{.indexEndpoint}}">
+          <span class="b">bukan</span> <span class="r">-</span>
+          <span class="r">GATOT</span> 
+          <span class="o">KACA</span> 
+				</a>
+      </section>
+      <section class="is">
+      <form action="{{.searchEndpoint}}">
+        <input class="t" type="text" name="q"/>
+        <input class="sb" type="submit" value="Search"/>
+      </form>
+      </section>
+    </header>
+    <hr/>
+    <section class="rc">
+      <a class="ml" rel="nofollow" href="{{.link.URL}}">{{.link.URL}}</a>
+			<cite>retrieved at {{.link.RetrievedAt.Format "2006-01-02 15:04:05"}}</cite>
+      <span class="rt">{{.inDegree}} backlinks, {{.outDegree}} outlinks.</span>
+    </section>
+    <section class="rc">
+      <span class="rt">Linked from</span>
+      <ul>
+		  {{range .backlinks}}<li><a rel="nofollow" href="{{$.linkEndpoint}}/{{.ID}}">{{.URL}}</a></li>{{end}}
+      </ul>
+    </section>
+    <section class="rc">
+      <span class="rt">Links to</span>
+      <ul>
+		  {{range .outlinks}}<li><a rel="nofollow" href="{{$.linkEndpoint}}/{{.ID}}">{{.URL}}</a></li>{{end}}
+      </ul>
+    </section>
+  </body>
+</html>
 `))
 )
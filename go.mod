@@ -1,9 +1,10 @@
 module github.com/odit-bit/invoker
 
-go 1.20
+go 1.21
 
 require (
 	github.com/blevesearch/bleve/v2 v2.3.10
+	github.com/blevesearch/bleve_index_api v1.0.6
 	github.com/go-chi/chi/v5 v5.0.10
 	github.com/google/uuid v1.3.1
 	github.com/jackc/pgx/v5 v5.4.3
@@ -12,6 +13,7 @@ require (
 	github.com/nsqio/go-nsq v1.1.0
 	github.com/odit-bit/pipeline v0.0.0-20231025195452-f57e15508349
 	github.com/prometheus/client_golang v1.17.0
+	go.etcd.io/bbolt v1.3.7
 	go.uber.org/mock v0.3.0
 	go.uber.org/multierr v1.11.0
 )
@@ -21,7 +23,6 @@ require (
 	github.com/aymerick/douceur v0.2.0 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/bits-and-blooms/bitset v1.10.0 // indirect
-	github.com/blevesearch/bleve_index_api v1.0.6 // indirect
 	github.com/blevesearch/geo v0.1.18 // indirect
 	github.com/blevesearch/go-porterstemmer v1.0.3 // indirect
 	github.com/blevesearch/gtreap v0.1.1 // indirect
@@ -51,7 +52,6 @@ require (
 	github.com/prometheus/client_model v0.5.0 // indirect
 	github.com/prometheus/common v0.45.0 // indirect
 	github.com/prometheus/procfs v0.12.0 // indirect
-	go.etcd.io/bbolt v1.3.7 // indirect
 	golang.org/x/crypto v0.14.0 // indirect
 	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sys v0.13.0 // indirect
@@ -2,6 +2,7 @@ package iploc
 
 import (
 	"context"
+	"embed"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -9,21 +10,18 @@ import (
 	"os"
 	"path"
 	"strconv"
+
+	"github.com/odit-bit/invoker/internal/migrate"
 )
 
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations is the versioned schema of the ip table
+var Migrations = migrate.Source{Name: "iploc", FS: migrationFiles, Dir: "migrations"}
+
 func (t *table) createIPTable() error {
-	// CREATE DATABASE ip2location WITH ENCODING 'UTF8';
-	query := `
-	CREATE TABLE IF NOT EXISTS ip2location_db1(
-		ip_from bigint NOT NULL,
-		ip_to bigint NOT NULL,
-		country_code character(2) NOT NULL,
-		country_name character varying(64) NOT NULL,
-		CONSTRAINT ip2location_db1_pkey PRIMARY KEY (ip_from, ip_to)
-	);
-	`
-	_, err := t.db.ExecContext(context.TODO(), query)
-	return err
+	return migrate.Up(context.TODO(), t.db, Migrations)
 }
 
 func (t *table) importFromFile() error {
@@ -71,12 +69,11 @@ func (t *table) importFromFile() error {
 	return nil
 }
 
-// CREATE INDEX
-
+// revert every migration
 func (t *table) drop() error {
-	query := `
-		DROP TABLE IF EXISTS ip2location_db1
-	`
-	_, err := t.db.ExecContext(context.TODO(), query)
-	return err
+	m, err := migrate.New(t.db, Migrations)
+	if err != nil {
+		return err
+	}
+	return m.To(context.TODO(), 0)
 }
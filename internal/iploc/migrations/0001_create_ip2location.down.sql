@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ip2location_db1;
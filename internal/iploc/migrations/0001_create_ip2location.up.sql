@@ -0,0 +1,8 @@
+-- IF NOT EXISTS so database created before versioned migrations is adopted as it is
+CREATE TABLE IF NOT EXISTS ip2location_db1(
+	ip_from bigint NOT NULL,
+	ip_to bigint NOT NULL,
+	country_code character(2) NOT NULL,
+	country_name character varying(64) NOT NULL,
+	CONSTRAINT ip2location_db1_pkey PRIMARY KEY (ip_from, ip_to)
+);
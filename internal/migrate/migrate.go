@@ -0,0 +1,331 @@
+// Package migrate apply numbered schema migrations of the Postgres stores.
+//
+// every store embed its migrations as pairs of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+// applied versions are recorded per store in schema_migrations table,
+// and concurrent instances are serialized by advisory lock.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Source is the migration files of one store
+type Source struct {
+	// name of the store in schema_migrations
+	Name string
+
+	FS fs.FS
+
+	// directory of the files in FS
+	Dir string
+}
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load read the migrations of src, sorted by version.
+// every version must have both up and down file.
+func Load(src Source) ([]*Migration, error) {
+	files, err := fs.Glob(src.FS, path.Join(src.Dir, "*.sql"))
+	if err != nil {
+		return nil, fmt.Errorf("load %s migrations: %v", src.Name, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		base := path.Base(file)
+		version, name, direction, err := parseFileName(base)
+		if err != nil {
+			return nil, fmt.Errorf("load %s migrations: %v", src.Name, err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("load %s migrations: version %d has different names %q and %q", src.Name, version, m.Name, name)
+		}
+
+		content, err := fs.ReadFile(src.FS, file)
+		if err != nil {
+			return nil, fmt.Errorf("load %s migrations: %v", src.Name, err)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("load %s migrations: version %d missing up or down file", src.Name, m.Version)
+		}
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// split 0001_create_links.up.sql into 1, create_links, up
+func parseFileName(base string) (int, string, string, error) {
+	stem := strings.TrimSuffix(base, ".sql")
+	var direction string
+	switch {
+	case strings.HasSuffix(stem, ".up"):
+		direction = "up"
+	case strings.HasSuffix(stem, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("file %q is not .up.sql or .down.sql", base)
+	}
+	stem = strings.TrimSuffix(stem, "."+direction)
+
+	num, name, ok := strings.Cut(stem, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("file %q has no <version>_<name> prefix", base)
+	}
+	version, err := strconv.Atoi(num)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("file %q has invalid version", base)
+	}
+	return version, name, direction, nil
+}
+
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		store text NOT NULL,
+		version int NOT NULL,
+		name text NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+		PRIMARY KEY (store, version)
+	);
+`
+
+// one lock for every store, migrations are short and rarely run
+const (
+	lockQuery   = `SELECT pg_advisory_lock(hashtext('schema_migrations'))`
+	unlockQuery = `SELECT pg_advisory_unlock(hashtext('schema_migrations'))`
+)
+
+const appliedQuery = `
+	SELECT version, applied_at FROM schema_migrations WHERE store = $1
+`
+
+const insertVersionQuery = `
+	INSERT INTO schema_migrations (store, version, name) VALUES ($1, $2, $3)
+`
+
+const deleteVersionQuery = `
+	DELETE FROM schema_migrations WHERE store = $1 AND version = $2
+`
+
+// Migrator apply the migrations of one store
+type Migrator struct {
+	db         *sqlx.DB
+	store      string
+	migrations []*Migration
+}
+
+func New(db *sqlx.DB, src Source) (*Migrator, error) {
+	migrations, err := Load(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, store: src.Name, migrations: migrations}, nil
+}
+
+// Up apply the migrations of src that not applied yet
+func Up(ctx context.Context, db *sqlx.DB, src Source) error {
+	m, err := New(db, src)
+	if err != nil {
+		return err
+	}
+	return m.Up(ctx)
+}
+
+// Store return the name of the store
+func (m *Migrator) Store() string {
+	return m.store
+}
+
+// Latest return the version of the last migration, 0 if there is none
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up apply every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// To apply or revert migrations until version is the latest applied one,
+// version 0 revert every migration.
+func (m *Migrator) To(ctx context.Context, version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("migrate %s: unknown version %d", m.store, version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// revert the newest first
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok || mig.Version <= version {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig.Down, deleteVersionQuery, m.store, mig.Version); err != nil {
+				return fmt.Errorf("migrate %s: revert %d_%s: %v", m.store, mig.Version, mig.Name, err)
+			}
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig.Up, insertVersionQuery, m.store, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("migrate %s: apply %d_%s: %v", m.store, mig.Version, mig.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Status of one migration
+type Status struct {
+	Version int
+	Name    string
+
+	// zero if the migration is pending
+	AppliedAt time.Time
+}
+
+// Status return every migration and when it applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var list []Status
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			list = append(list, Status{Version: mig.Version, Name: mig.Name, AppliedAt: applied[mig.Version]})
+		}
+		return nil
+	})
+	return list, err
+}
+
+// Version return the latest applied version, 0 if nothing applied
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	list, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for _, st := range list {
+		if !st.AppliedAt.IsZero() {
+			version = st.Version
+		}
+	}
+	return version, nil
+}
+
+// Previous return the version before the given one, 0 if it is the first
+func (m *Migrator) Previous(version int) int {
+	prev := 0
+	for _, mig := range m.migrations {
+		if mig.Version >= version {
+			break
+		}
+		prev = mig.Version
+	}
+	return prev
+}
+
+// run fn on one connection holding the advisory lock
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate %s: %v", m.store, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, lockQuery); err != nil {
+		return fmt.Errorf("migrate %s: lock: %v", m.store, err)
+	}
+	defer conn.ExecContext(context.Background(), unlockQuery)
+
+	if _, err := conn.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("migrate %s: create table: %v", m.store, err)
+	}
+	return fn(conn)
+}
+
+// applied versions of the store and when they applied
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, appliedQuery, m.store)
+	if err != nil {
+		return nil, fmt.Errorf("migrate %s: %v", m.store, err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("migrate %s: %v", m.store, err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("migrate %s: %v", m.store, err)
+	}
+	return applied, nil
+}
+
+// run the migration and record it in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
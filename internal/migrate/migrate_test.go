@@ -0,0 +1,71 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func Test_load(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_index.up.sql":      {Data: []byte("CREATE INDEX")},
+		"migrations/0002_add_index.down.sql":    {Data: []byte("DROP INDEX")},
+		"migrations/0001_create_table.up.sql":   {Data: []byte("CREATE TABLE")},
+		"migrations/0001_create_table.down.sql": {Data: []byte("DROP TABLE")},
+		"migrations/README":                     {Data: []byte("not a migration")},
+	}
+
+	list, err := Load(Source{Name: "test", FS: fsys, Dir: "migrations"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(list), 2)
+	}
+
+	expect := []Migration{
+		{Version: 1, Name: "create_table", Up: "CREATE TABLE", Down: "DROP TABLE"},
+		{Version: 2, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+	}
+	for i, m := range list {
+		if *m != expect[i] {
+			t.Fatalf("\ngot:%+v\nexpect:%+v", *m, expect[i])
+		}
+	}
+}
+
+func Test_load_invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_create_table.up.sql": {Data: []byte("CREATE TABLE")},
+		},
+		"no direction": {
+			"0001_create_table.sql": {Data: []byte("CREATE TABLE")},
+		},
+		"no version": {
+			"create_table.up.sql":   {Data: []byte("CREATE TABLE")},
+			"create_table.down.sql": {Data: []byte("DROP TABLE")},
+		},
+		"different name": {
+			"0001_create_table.up.sql":   {Data: []byte("CREATE TABLE")},
+			"0001_create_other.down.sql": {Data: []byte("DROP TABLE")},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(Source{Name: "test", FS: fsys, Dir: "."}); err == nil {
+				t.Fatalf("expect error")
+			}
+		})
+	}
+}
+
+func Test_parse_file_name(t *testing.T) {
+	version, name, direction, err := parseFileName("0012_link_metadata.down.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 12 || name != "link_metadata" || direction != "down" {
+		t.Fatalf("\ngot:%v %v %v", version, name, direction)
+	}
+}
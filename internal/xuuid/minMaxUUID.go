@@ -7,3 +7,16 @@ var MAX = uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
 
 // all 0 value of uuid
 var MIN = uuid.Nil
+
+// Next return the uuid that follow id in byte order,
+// ok is false if id is already MAX.
+func Next(id uuid.UUID) (next uuid.UUID, ok bool) {
+	next = id
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next, true
+		}
+	}
+	return id, false
+}
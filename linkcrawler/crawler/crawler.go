@@ -8,8 +8,11 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	// "github.com/odit-bit/invoker/linkcrawler/pipeline"
+	lpipeline "github.com/odit-bit/invoker/linkcrawler/pipeline"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 	"github.com/odit-bit/pipeline"
 )
@@ -27,6 +30,9 @@ var _ pipeline.Source = (*LinkSource)(nil)
 // poppulate link from graph as source of pipeline
 type LinkSource struct {
 	linkIter graph.LinkIterator
+
+	// optional, track emitted payload
+	progress *progress
 }
 
 // Error implements pipeline.Source.
@@ -47,6 +53,11 @@ func (ls *LinkSource) Payload() pipeline.Payload {
 	p.LinkID = link.ID
 	p.URL = link.URL
 	p.RetrievedAt = link.RetrievedAt
+	p.Depth = link.Depth
+	p.progress = ls.progress
+	if ls.progress != nil {
+		ls.progress.emit(link.ID)
+	}
 
 	return p
 }
@@ -55,6 +66,9 @@ var _ pipeline.Destination = (*countingSink)(nil)
 
 type countingSink struct {
 	count int
+
+	// number of payloads that reach the sink for every crawled link
+	perLink int
 }
 
 func (s *countingSink) Consume(_ context.Context, p pipeline.Payload) error {
@@ -63,9 +77,12 @@ func (s *countingSink) Consume(_ context.Context, p pipeline.Payload) error {
 }
 
 func (s *countingSink) getCount() int {
-	// The broadcast split-stage sends out two payloads for each incoming link
-	// so we need to divide the total count by 2.
-	return s.count / 2
+	// every link reach the sink perLink times, the broadcast split-stage
+	// sends out one payload per processor while the batch writer only one.
+	if s.perLink <= 1 {
+		return s.count
+	}
+	return s.count / s.perLink
 }
 
 // crawler
@@ -78,6 +95,35 @@ type Config struct {
 	GraphUpdater GraphUpdater
 
 	FetchWorker int
+
+	// route every link of the same host to the same fetch worker,
+	// so the host is fetched in-order by one worker at a time.
+	FetchByHost bool
+
+	// minimum time between request to the same host,
+	// only used when FetchByHost enabled
+	HostFetchDelay time.Duration
+
+	// maximum number of crawled page written to graph and index in one batch.
+	// value <= 1 write every page as it arrive.
+	// when enabled, GraphUpdater must implement BatchGraphUpdater and
+	// Indexer must implement BatchIndexer
+	BatchSize int
+
+	// maximum time a crawled page wait for the batch to be full
+	BatchInterval time.Duration
+
+	// NextCrawlAt of the crawled link is the fetch time plus this,
+	// zero leave it unset.
+	RecrawlInterval time.Duration
+
+	// optional, every fetch is recorded into it with the robots.txt status of the host,
+	// the robots.txt of every host is requested once a day when it is set
+	HostStats HostStatsRecorder
+
+	// optional, the graph writes of a batch are applied through it in one transaction,
+	// only used when BatchSize enabled
+	GraphBatch graph.BatchGraph
 }
 
 func (c *Config) validate() error {
@@ -97,11 +143,23 @@ func (c *Config) validate() error {
 	if c.NetDetector == nil {
 		return fmt.Errorf("netDetector not been provided")
 	}
+
+	if c.BatchSize > 1 {
+		if _, ok := c.GraphUpdater.(BatchGraphUpdater); !ok {
+			return fmt.Errorf("graphUpdater not support batch write")
+		}
+		if _, ok := c.Indexer.(BatchIndexer); !ok {
+			return fmt.Errorf("indexer not support batch write")
+		}
+	}
 	return nil
 }
 
 type Crawler struct {
 	pipe *pipeline.Pipe
+
+	// payloads per link that reach the sink
+	perLink int
 }
 
 // Crawler implements a web-page crawling pipeline consisting of the following
@@ -118,13 +176,54 @@ func New(cfg *Config) (*Crawler, error) {
 		return nil, err
 	}
 
-	stg1 := pipeline.NewMuxStage(cfg.FetchWorker, newLinkFetcher(cfg.URLGetter, cfg.NetDetector))
+	// the robots status is recorded with the fetch statistic
+	var robots *robotsChecker
+	if cfg.HostStats != nil {
+		robots = newRobotsChecker(cfg.URLGetter)
+	}
+	newFetcher := func() *linkFetcher {
+		fetcher := newLinkFetcher(cfg.URLGetter, cfg.NetDetector)
+		fetcher.robots = robots
+		return fetcher
+	}
+
+	var stg1 pipeline.Stage
+	if cfg.FetchByHost {
+		stg1 = newRunnerStage(lpipeline.KeyPartition(bridgedHost, cfg.FetchWorker, func(_ int) lpipeline.Processor {
+			// every worker own it's fetcher
+			var fetcher pipeline.Processor = newFetcher()
+			if cfg.HostFetchDelay > 0 {
+				fetcher = newHostThrottle(cfg.HostFetchDelay, fetcher)
+			}
+			return &bridgedProcessor{proc: fetcher}
+		}))
+	} else {
+		stg1 = pipeline.NewMuxStage(cfg.FetchWorker, newFetcher())
+	}
 	stg2 := pipeline.NewFifo(newLinkExtractor(cfg.NetDetector))
 	stg3 := pipeline.NewFifo(newTextExtractor())
-	stg4 := pipeline.NewBroadcast(
-		newUpdater(cfg.GraphUpdater),
-		newTextIndexer(cfg.Indexer),
+
+	var (
+		stg4    pipeline.Stage
+		perLink int
 	)
+	if cfg.BatchSize > 1 {
+		writer := newBatchWriter(cfg.GraphUpdater.(BatchGraphUpdater), cfg.Indexer.(BatchIndexer))
+		writer.recrawlInterval = cfg.RecrawlInterval
+		writer.hostStats = cfg.HostStats
+		writer.graphBatch = cfg.GraphBatch
+		stg4 = newRunnerStage(lpipeline.Batch(writer, cfg.BatchSize, cfg.BatchInterval))
+		perLink = 1
+	} else {
+		updater := newUpdater(cfg.GraphUpdater)
+		updater.recrawlInterval = cfg.RecrawlInterval
+		updater.hostStats = cfg.HostStats
+		stg4 = pipeline.NewBroadcast(
+			updater,
+			newTextIndexer(cfg.Indexer),
+		)
+		perLink = 2
+	}
 
 	pipe := *pipeline.NewPipe(&pipeline.Config{},
 		stg1,
@@ -133,7 +232,8 @@ func New(cfg *Config) (*Crawler, error) {
 		stg4,
 	)
 	return &Crawler{
-		pipe: &pipe,
+		pipe:    &pipe,
+		perLink: perLink,
 	}, nil
 
 }
@@ -143,7 +243,72 @@ func (c *Crawler) Crawl(ctx context.Context, linkIterator graph.LinkIterator) (i
 		linkIter: linkIterator,
 	}
 
-	dst := new(countingSink)
+	dst := &countingSink{perLink: c.perLink}
 	err := c.pipe.Run(ctx, &src, dst)
 	return dst.getCount(), err
 }
+
+const defaultCheckpointInterval = 30 * time.Second
+
+// CheckpointFunc is called with the highest link ID that every link before it
+// (in iteration order) has left the pipeline, and the number of those links.
+type CheckpointFunc func(highWater uuid.UUID, processed int) error
+
+// CrawlWithCheckpoint is like Crawl but call fn every interval and once more after
+// the pipeline finished, so the caller can save the progress of the pass.
+// the iterator must return links in ascending ID order.
+// error returned by fn does not stop the crawl, the first of it is returned
+// if the crawl itself succeed.
+func (c *Crawler) CrawlWithCheckpoint(ctx context.Context, linkIterator graph.LinkIterator, interval time.Duration, fn CheckpointFunc) (int, error) {
+	if interval <= 0 {
+		interval = defaultCheckpointInterval
+	}
+
+	prog := newProgress()
+	src := LinkSource{
+		linkIter: linkIterator,
+		progress: prog,
+	}
+
+	var (
+		fnErr error
+		last  uuid.UUID
+	)
+	checkpoint := func() {
+		highWater, processed, ok := prog.HighWater()
+		if !ok || highWater == last {
+			return
+		}
+		last = highWater
+		if err := fn(highWater, processed); err != nil && fnErr == nil {
+			fnErr = err
+		}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				checkpoint()
+			}
+		}
+	}()
+
+	dst := &countingSink{perLink: c.perLink}
+	err := c.pipe.Run(ctx, &src, dst)
+	close(done)
+	<-stopped
+	checkpoint()
+
+	if err != nil {
+		return dst.getCount(), err
+	}
+	return dst.getCount(), fnErr
+}
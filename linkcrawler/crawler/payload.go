@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
 	"github.com/odit-bit/pipeline"
 )
 
@@ -17,6 +18,20 @@ type payload struct {
 	LinkID      uuid.UUID
 	URL         string
 	RetrievedAt time.Time
+	Depth       int
+
+	// populated by link fetcher
+	StatusCode   int
+	ContentType  string
+	ContentHash  string
+	ResponseTime time.Duration
+
+	// set when the robots.txt of the host checked before the fetch
+	RobotsStatus graph.RobotsStatus
+
+	// the fetch failed (request error, non-2xx or non-html), the payload carry
+	// only the fetch result so the link is updated but not extracted nor indexed
+	FetchFailed bool
 
 	NoFollowLinks []string
 	RawContent    bytes.Buffer
@@ -24,6 +39,10 @@ type payload struct {
 	Links       []string
 	Title       []byte
 	TextContent []byte
+	Language    string
+
+	// track the payload while in the pipeline, nil if not tracked
+	progress *progress
 }
 
 // Clone implements pipeline.Payload.
@@ -32,10 +51,22 @@ func (p *payload) Clone() pipeline.Payload {
 	cloneP.LinkID = p.LinkID
 	cloneP.URL = p.URL
 	cloneP.RetrievedAt = p.RetrievedAt
+	cloneP.Depth = p.Depth
+	cloneP.StatusCode = p.StatusCode
+	cloneP.ContentType = p.ContentType
+	cloneP.ContentHash = p.ContentHash
+	cloneP.ResponseTime = p.ResponseTime
+	cloneP.RobotsStatus = p.RobotsStatus
+	cloneP.FetchFailed = p.FetchFailed
 	cloneP.NoFollowLinks = append([]string(nil), p.NoFollowLinks...)
 	cloneP.Links = append([]string(nil), p.Links...)
 	cloneP.Title = p.Title
 	cloneP.TextContent = p.TextContent
+	cloneP.Language = p.Language
+	cloneP.progress = p.progress
+	if p.progress != nil {
+		p.progress.clone(p.LinkID)
+	}
 
 	_, err := io.Copy(&cloneP.RawContent, &p.RawContent)
 	if err != nil {
@@ -46,11 +77,22 @@ func (p *payload) Clone() pipeline.Payload {
 
 // MarkAsProcessed implements pipeline.Payload.
 func (p *payload) MarkAsProcessed() {
+	if p.progress != nil {
+		p.progress.done(p.LinkID)
+		p.progress = nil
+	}
 	p.URL = p.URL[:0]
+	p.StatusCode = 0
+	p.ContentType = ""
+	p.ContentHash = ""
+	p.ResponseTime = 0
+	p.RobotsStatus = graph.RobotsUnknown
+	p.FetchFailed = false
 	p.Links = p.Links[:0]
 	p.NoFollowLinks = p.NoFollowLinks[:0]
 	p.Title = p.Title[:0]
 	p.TextContent = p.TextContent[:0]
+	p.Language = ""
 	p.RawContent.Reset()
 	payloadPool.Put(p)
 }
@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	lpipeline "github.com/odit-bit/invoker/linkcrawler/pipeline"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// BatchGraphUpdater is implemented by graph that can upsert many links and edges
+// in a single round-trip
+type BatchGraphUpdater interface {
+	GraphUpdater
+	UpsertLinksContext(ctx context.Context, links []*graph.Link) error
+	UpsertEdgesContext(ctx context.Context, edges []*graph.Edge) error
+}
+
+// BatchIndexer is implemented by indexer that can index many documents
+// in a single round-trip
+type BatchIndexer interface {
+	Indexer
+	IndexBatchContext(ctx context.Context, docs []*index.Document) error
+}
+
+var _ lpipeline.BatchProcessor = (*batchWriter)(nil)
+
+// batchWriter does the same job as updater and textIndexer combined,
+// but for a batch of payload so the number of queries no longer grow with
+// the number of links discovered in every page.
+type batchWriter struct {
+	graphUpdater BatchGraphUpdater
+	indexer      BatchIndexer
+
+	// nil if graph not keep host statistic
+	hostStats HostStatsRecorder
+
+	// nil if graph can not apply the batch atomically
+	graphBatch graph.BatchGraph
+
+	// time until the crawled link is due again, zero leave NextCrawlAt unset
+	recrawlInterval time.Duration
+}
+
+func newBatchWriter(gu BatchGraphUpdater, idx BatchIndexer) *batchWriter {
+	return &batchWriter{
+		graphUpdater: gu,
+		indexer:      idx,
+	}
+}
+
+// ProcessBatch implements lpipeline.BatchProcessor.
+func (bw *batchWriter) ProcessBatch(ctx context.Context, batch []lpipeline.Payload) ([]lpipeline.Payload, error) {
+	payloads := make([]*payload, len(batch))
+	for i, p := range batch {
+		cp, err := crawlerPayload(p)
+		if err != nil {
+			return nil, err
+		}
+		payloads[i] = cp
+	}
+
+	now := time.Now()
+
+	// the crawled links, every discovered link and the edges between them
+	update := &graph.Batch{}
+	for _, p := range payloads {
+		src := crawledLink(p, now, bw.recrawlInterval)
+		update.Links = append(update.Links, src)
+
+		// failed fetch has no link, the edges of the last successful fetch are kept
+		if p.FetchFailed {
+			continue
+		}
+		update.StaleSources = append(update.StaleSources, src)
+		for _, dstLink := range p.Links {
+			dst := discoveredLink(p, dstLink)
+			update.Links = append(update.Links, dst)
+			update.Edges = append(update.Edges, &graph.BatchEdge{Src: src, Dst: dst})
+		}
+	}
+	if bw.hostStats != nil {
+		for _, p := range payloads {
+			update.Fetches = append(update.Fetches, hostFetch(p, now))
+		}
+	}
+	if err := bw.updateGraph(ctx, update); err != nil {
+		return nil, err
+	}
+
+	docs := make([]*index.Document, 0, len(payloads))
+	for _, p := range payloads {
+		if p.FetchFailed {
+			continue
+		}
+		docs = append(docs, &index.Document{
+			LinkID:    p.LinkID,
+			URL:       p.URL,
+			Title:     string(p.Title),
+			Content:   string(p.TextContent),
+			IndexedAt: now,
+			PageRank:  0,
+			Language:  p.Language,
+		})
+	}
+	if len(docs) > 0 {
+		if err := bw.indexer.IndexBatchContext(ctx, docs); err != nil {
+			return nil, err
+		}
+	}
+
+	return batch, nil
+}
+
+// apply the graph writes of the batch in one transaction when the graph support it,
+// otherwise one query per kind of write.
+func (bw *batchWriter) updateGraph(ctx context.Context, update *graph.Batch) error {
+	if bw.graphBatch != nil {
+		return bw.graphBatch.UpdateBatchContext(ctx, update)
+	}
+
+	if err := bw.graphUpdater.UpsertLinksContext(ctx, update.Links); err != nil {
+		return err
+	}
+
+	// edges that not touched by this batch are stale,
+	// the graphs without batch support are in-process, they share the clock
+	removeEdgeBefore := time.Now()
+
+	edges := make([]*graph.Edge, len(update.Edges))
+	for i, edge := range update.Edges {
+		edges[i] = &graph.Edge{Src: edge.Src.ID, Dst: edge.Dst.ID}
+	}
+	if err := bw.graphUpdater.UpsertEdgesContext(ctx, edges); err != nil {
+		return err
+	}
+
+	for _, src := range update.StaleSources {
+		if err := bw.graphUpdater.RemoveStaleEdgesContext(ctx, src.ID, removeEdgeBefore); err != nil {
+			return err
+		}
+	}
+
+	if len(update.Fetches) > 0 {
+		if err := bw.hostStats.RecordFetchesContext(ctx, update.Fetches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	lpipeline "github.com/odit-bit/invoker/linkcrawler/pipeline"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	graphmemory "github.com/odit-bit/invoker/linkgraph/memory"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ BatchIndexer = (*mockBatchIndexer)(nil)
+
+type mockBatchIndexer struct {
+	mockIndexer
+	docs []*index.Document
+}
+
+// IndexBatchContext implements BatchIndexer.
+func (mi *mockBatchIndexer) IndexBatchContext(ctx context.Context, docs []*index.Document) error {
+	mi.docs = append(mi.docs, docs...)
+	return nil
+}
+
+func Test_batch_writer(t *testing.T) {
+	g := graphmemory.New()
+
+	src1 := graphLink(t, g, "http://source_1.com")
+	src2 := graphLink(t, g, "http://source_2.com")
+
+	idx := &mockBatchIndexer{}
+	bw := newBatchWriter(graph.WithContext(g), idx)
+
+	batch := []lpipeline.Payload{
+		&bridgedPayload{&payload{
+			LinkID: src1,
+			URL:    "http://source_1.com",
+			Links:  []string{"http://follow_1.com", "http://follow_2.com"},
+		}},
+		&bridgedPayload{&payload{
+			LinkID: src2,
+			URL:    "http://source_2.com",
+			Links:  []string{"http://follow_1.com"},
+		}},
+	}
+
+	out, err := bw.ProcessBatch(context.TODO(), batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(batch) {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(out), len(batch))
+	}
+
+	if len(idx.docs) != 2 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(idx.docs), 2)
+	}
+
+	edgeIt, err := g.Edges(uuid.Nil, uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff"), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for edgeIt.Next() {
+		_ = edgeIt.Edge()
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 3)
+	}
+}
+
+func graphLink(t *testing.T, g *graphmemory.InMemory, url string) uuid.UUID {
+	link := &graph.Link{URL: url}
+	if err := g.UpsertLink(link); err != nil {
+		t.Fatal(err)
+	}
+	return link.ID
+}
+
+func Test_batch_writer_failed_fetch(t *testing.T) {
+	g := graphmemory.New()
+	src := graphLink(t, g, "http://source.com")
+	if err := g.UpsertEdge(&graph.Edge{Src: src, Dst: graphLink(t, g, "http://follow.com")}); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := &mockBatchIndexer{}
+	bw := newBatchWriter(graph.WithContext(g), idx)
+	bw.recrawlInterval = time.Hour
+
+	batch := []lpipeline.Payload{
+		&bridgedPayload{&payload{LinkID: src, URL: "http://source.com", StatusCode: 503, ContentType: "text/html", FetchFailed: true}},
+	}
+	if _, err := bw.ProcessBatch(context.TODO(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	// the status is stored, not indexed and the edges are kept
+	link, err := g.LookupLink(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.StatusCode != 503 || link.RetrievedAt.IsZero() || !link.NextCrawlAt.Equal(link.RetrievedAt.Add(time.Hour)) {
+		t.Fatalf("\ngot:%+v\nexpect status 503 and next crawl an hour after retrieved", link)
+	}
+	if len(idx.docs) != 0 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(idx.docs), 0)
+	}
+	edgeIt, err := g.OutEdges(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for edgeIt.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 1)
+	}
+}
+
+type mockBatchGraph struct {
+	batches []*graph.Batch
+}
+
+// UpdateBatchContext implements graph.BatchGraph.
+func (mg *mockBatchGraph) UpdateBatchContext(ctx context.Context, batch *graph.Batch) error {
+	mg.batches = append(mg.batches, batch)
+	return nil
+}
+
+func Test_batch_writer_graph_batch(t *testing.T) {
+	g := graphmemory.New()
+	mg := &mockBatchGraph{}
+
+	idx := &mockBatchIndexer{}
+	bw := newBatchWriter(graph.WithContext(g), idx)
+	bw.graphBatch = mg
+	bw.hostStats = graph.HostsWithContext(g)
+
+	batch := []lpipeline.Payload{
+		&bridgedPayload{&payload{
+			LinkID:     uuid.New(),
+			URL:        "http://source_1.com",
+			StatusCode: 200,
+			Links:      []string{"http://follow_1.com", "http://follow_2.com"},
+		}},
+		&bridgedPayload{&payload{
+			LinkID:      uuid.New(),
+			URL:         "http://source_2.com",
+			StatusCode:  503,
+			FetchFailed: true,
+		}},
+	}
+	if _, err := bw.ProcessBatch(context.TODO(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	// every graph write go through the batch
+	if len(mg.batches) != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(mg.batches), 1)
+	}
+	update := mg.batches[0]
+	if len(update.Links) != 4 || len(update.Edges) != 2 || len(update.Fetches) != 2 {
+		t.Fatalf("\ngot:%v %v %v\nexpect:4 2 2", len(update.Links), len(update.Edges), len(update.Fetches))
+	}
+	if len(update.StaleSources) != 1 || update.StaleSources[0].URL != "http://source_1.com" {
+		t.Fatalf("\ngot:%v\nexpect only source_1", update.StaleSources)
+	}
+	if _, err := g.LookupLinkByURL("http://source_1.com"); !errors.Is(err, graph.ErrNotFound) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+}
@@ -15,6 +15,12 @@ var _ pipeline.Processor = (*updater)(nil)
 // will update payload into graph
 type updater struct {
 	graphUpdater GraphUpdater
+
+	// nil if graph not keep host statistic
+	hostStats HostStatsRecorder
+
+	// time until the crawled link is due again, zero leave NextCrawlAt unset
+	recrawlInterval time.Duration
 }
 
 func newUpdater(gu GraphUpdater) *updater {
@@ -30,16 +36,20 @@ func (u *updater) Process(ctx context.Context, p pipeline.Payload) (pipeline.Pay
 		return nil, fmt.Errorf("payload underlying type is not crawler's payload: %t", p)
 	}
 	// upsert link
-	linkSrc := &graph.Link{
-		ID:          payload.LinkID,
-		URL:         payload.URL,
-		RetrievedAt: time.Now(),
-	}
-	err := u.graphUpdater.UpsertLink(linkSrc)
+	linkSrc := crawledLink(payload, time.Now(), u.recrawlInterval)
+	err := u.graphUpdater.UpsertLinkContext(ctx, linkSrc)
 	if err != nil {
 		return nil, err
 	}
 
+	// failed fetch has no link, the edges of the last successful fetch are kept
+	if payload.FetchFailed {
+		if err := u.recordFetch(ctx, payload, linkSrc.RetrievedAt); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
 	// insert nofollow link, without create an edge
 	// TODO: deprecating insert nofollowlinks
 	// for _, dstLink := range payload.NoFollowLinks {
@@ -53,11 +63,9 @@ func (u *updater) Process(ctx context.Context, p pipeline.Payload) (pipeline.Pay
 	//
 
 	for _, dstLink := range payload.Links {
-		dst := &graph.Link{
-			URL: dstLink,
-		}
+		dst := discoveredLink(payload, dstLink)
 		//insert link to follow
-		err := u.graphUpdater.UpsertLink(dst)
+		err := u.graphUpdater.UpsertLinkContext(ctx, dst)
 		if err != nil {
 			return nil, err
 		}
@@ -67,7 +75,7 @@ func (u *updater) Process(ctx context.Context, p pipeline.Payload) (pipeline.Pay
 			Src: linkSrc.ID,
 			Dst: dst.ID,
 		}
-		err = u.graphUpdater.UpsertEdge(e)
+		err = u.graphUpdater.UpsertEdgeContext(ctx, e)
 		if err != nil {
 			return nil, err
 		}
@@ -75,19 +83,76 @@ func (u *updater) Process(ctx context.Context, p pipeline.Payload) (pipeline.Pay
 	}
 
 	removeEdgeBefore := time.Now()
-	err = u.graphUpdater.RemoveStaleEdges(linkSrc.ID, removeEdgeBefore)
+	err = u.graphUpdater.RemoveStaleEdgesContext(ctx, linkSrc.ID, removeEdgeBefore)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := u.recordFetch(ctx, payload, linkSrc.RetrievedAt); err != nil {
+		return nil, err
+	}
+
 	return p, nil
 
 }
 
+func (u *updater) recordFetch(ctx context.Context, p *payload, fetchedAt time.Time) error {
+	if u.hostStats == nil {
+		return nil
+	}
+	return u.hostStats.RecordFetchesContext(ctx, []*graph.HostFetch{hostFetch(p, fetchedAt)})
+}
+
+// link of the crawled payload with the metadata of fetch result,
+// it is due to be crawled again after recrawl unless recrawl is zero.
+func crawledLink(p *payload, retrievedAt time.Time, recrawl time.Duration) *graph.Link {
+	link := &graph.Link{
+		ID:          p.LinkID,
+		URL:         p.URL,
+		RetrievedAt: retrievedAt,
+		Depth:       p.Depth,
+		StatusCode:  p.StatusCode,
+		ContentType: p.ContentType,
+		ContentHash: p.ContentHash,
+	}
+	if recrawl > 0 {
+		link.NextCrawlAt = retrievedAt.Add(recrawl)
+	}
+	return link
+}
+
+// link found in the page of payload, one hop deeper
+func discoveredLink(p *payload, url string) *graph.Link {
+	return &graph.Link{
+		URL:    url,
+		Depth:  p.Depth + 1,
+		Source: graph.SourceCrawl,
+	}
+}
+
+// result of fetching the payload url
+func hostFetch(p *payload, fetchedAt time.Time) *graph.HostFetch {
+	return &graph.HostFetch{
+		Host:         payloadHost(p),
+		StatusCode:   p.StatusCode,
+		ResponseTime: p.ResponseTime,
+		FetchedAt:    fetchedAt,
+		RobotsStatus: p.RobotsStatus,
+	}
+}
+
+// HostStatsRecorder is implemented by graph that keep host statistic,
+// the crawler record every fetch into Config.HostStats.
+// graph.HostsWithContext adapt graph.HostGraph that not accept context.
+type HostStatsRecorder interface {
+	RecordFetchesContext(ctx context.Context, fetches []*graph.HostFetch) error
+}
+
 // a list methods needed for the updater to communicate with a link
-// graph component
+// graph component, the query is cancelled with the crawl.
+// graph.WithContext adapt graph that not accept context.
 type GraphUpdater interface {
-	UpsertLink(link *graph.Link) error
-	UpsertEdge(edge *graph.Edge) error
-	RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error
+	UpsertLinkContext(ctx context.Context, link *graph.Link) error
+	UpsertEdgeContext(ctx context.Context, edge *graph.Edge) error
+	RemoveStaleEdgesContext(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error
 }
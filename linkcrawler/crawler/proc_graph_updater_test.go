@@ -13,13 +13,13 @@ func Test_graph_updater(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	gu := mock_crawler.NewMockGraphUpdater(ctrl)
 
-	gu.EXPECT().UpsertLink(gomock.Any()).AnyTimes().
+	gu.EXPECT().UpsertLinkContext(gomock.Any(), gomock.Any()).AnyTimes().
 		Return(nil)
 
-	gu.EXPECT().UpsertEdge(gomock.Any()).AnyTimes().
+	gu.EXPECT().UpsertEdgeContext(gomock.Any(), gomock.Any()).AnyTimes().
 		Return(nil)
 
-	gu.EXPECT().RemoveStaleEdges(gomock.Any(), gomock.Any()).AnyTimes().
+	gu.EXPECT().RemoveStaleEdgesContext(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
 		Return(nil)
 
 	updater := newUpdater(gu)
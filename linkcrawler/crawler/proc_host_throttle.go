@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/odit-bit/pipeline"
+)
+
+var _ pipeline.Processor = (*hostThrottle)(nil)
+
+// number of tracked host before throttle start forgetting the old one
+const maxThrottledHost = 1024
+
+// hostThrottle delay request to the same host so it is not sent more often than
+// delay. it is not safe for concurrent use, it's meant to be owned by single
+// worker of key-partitioned stage where every host always goes to the same worker.
+// the wait blocks the worker, so every other host hashed to the same worker wait too.
+type hostThrottle struct {
+	delay time.Duration
+	next  pipeline.Processor
+
+	// host -> last time the request was sent
+	lastFetch map[string]time.Time
+}
+
+func newHostThrottle(delay time.Duration, next pipeline.Processor) *hostThrottle {
+	return &hostThrottle{
+		delay:     delay,
+		next:      next,
+		lastFetch: map[string]time.Time{},
+	}
+}
+
+// Process implements pipeline.Processor.
+func (ht *hostThrottle) Process(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
+	payload, ok := p.(*payload)
+	if !ok {
+		return nil, fmt.Errorf("host throttle: payload underlying type is not crawler's payload: %T", p)
+	}
+	host := payloadHost(payload)
+
+	if last, ok := ht.lastFetch[host]; ok {
+		if wait := ht.delay - time.Since(last); wait > 0 {
+			select {
+			case <-ctx.Done():
+				// nil payload is marked as processed, the link is not fetched
+				// so it must not move the checkpoint past it.
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+	ht.lastFetch[host] = time.Now()
+	ht.prune()
+
+	return ht.next.Process(ctx, p)
+}
+
+// the worker live as long as the crawler, forget host that no longer need to wait
+// so the map not grow forever.
+func (ht *hostThrottle) prune() {
+	if len(ht.lastFetch) < maxThrottledHost {
+		return
+	}
+	for host, last := range ht.lastFetch {
+		if time.Since(last) >= ht.delay {
+			delete(ht.lastFetch, host)
+		}
+	}
+}
+
+// return host of the payload url,
+// the raw url is returned if it cannot be parsed.
+func payloadHost(p *payload) string {
+	u, err := url.Parse(p.URL)
+	if err != nil || u.Host == "" {
+		return p.URL
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/odit-bit/pipeline"
+)
+
+func Test_host_throttle(t *testing.T) {
+	passThrough := pipeline.ProcessorFunc(func(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
+		return p, nil
+	})
+	delay := 50 * time.Millisecond
+	ht := newHostThrottle(delay, passThrough)
+
+	start := time.Now()
+	for _, u := range []string{"http://example.com/a", "http://other.com/a", "http://example.com/b"} {
+		if _, err := ht.Process(context.TODO(), &payload{URL: u}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if et := time.Since(start); et < delay {
+		t.Fatalf("\ngot:%v\nexpect at least:%v", et, delay)
+	}
+}
+
+func Test_host_throttle_foreign_payload(t *testing.T) {
+	passThrough := pipeline.ProcessorFunc(func(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
+		return p, nil
+	})
+	ht := newHostThrottle(time.Millisecond, passThrough)
+	if _, err := ht.Process(context.TODO(), foreignPayload{}); err == nil {
+		t.Fatal("expect error for payload that is not crawler's payload")
+	}
+}
+
+// pipeline.Payload that is not *payload
+type foreignPayload struct{}
+
+func (foreignPayload) Clone() pipeline.Payload { return foreignPayload{} }
+func (foreignPayload) MarkAsProcessed()        {}
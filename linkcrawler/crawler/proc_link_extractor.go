@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/url"
 
@@ -22,7 +23,13 @@ func newLinkExtractor(pnd PrivateNetworkDetector) *linkExtractor {
 
 // Process implements pipeline.Processor.
 func (le *linkExtractor) Process(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
-	payload, _ := p.(*payload)
+	payload, ok := p.(*payload)
+	if !ok {
+		return nil, fmt.Errorf("link extractor: payload underlying type is not crawler's payload: %T", p)
+	}
+	if payload.FetchFailed {
+		return payload, nil
+	}
 	relTo, err := url.Parse(payload.URL)
 	if err != nil {
 		return nil, err
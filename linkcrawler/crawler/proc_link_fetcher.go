@@ -2,12 +2,17 @@ package crawler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/odit-bit/invoker/linkgraph/graph"
 	"github.com/odit-bit/pipeline"
 )
 
@@ -25,10 +30,14 @@ var _ pipeline.Processor = (*linkFetcher)(nil)
 // attempts to retrieve the contents of each link by sending out HTTP GET requests.
 // The retrieved link web page contents are stored within the payload's RawContent field
 // and made available to the following stages of the pipeline.
-// for url that lead to non html , private network, or non 200 status code will be skipped with silent error
+// url that lead to non html or private network is skipped with silent error,
+// failed fetch is passed on with FetchFailed set so the updater record the result.
 type linkFetcher struct {
 	urlGetter   URLGetter
 	netDetector PrivateNetworkDetector
+
+	// nil if the robots status is not recorded
+	robots *robotsChecker
 }
 
 func newLinkFetcher(urlGetter URLGetter, netDetector PrivateNetworkDetector) *linkFetcher {
@@ -41,7 +50,10 @@ func newLinkFetcher(urlGetter URLGetter, netDetector PrivateNetworkDetector) *li
 // Process implements pipeline.Processor.
 func (lf *linkFetcher) Process(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
 	// p should crawler's payload struct
-	payload, _ := p.(*payload)
+	payload, ok := p.(*payload)
+	if !ok {
+		return nil, fmt.Errorf("link fetcher: payload underlying type is not crawler's payload: %T", p)
+	}
 
 	pURL := payload.URL
 	// Skip URLs that point to files that cannot contain html content.
@@ -58,10 +70,16 @@ func (lf *linkFetcher) Process(ctx context.Context, p pipeline.Payload) (pipelin
 		return nil, nil
 	}
 
-	//get url within timeout otherwise skipped
+	if lf.robots != nil {
+		payload.RobotsStatus = lf.robots.check(ctx, pURL)
+	}
+
+	// the status of failed fetch is still stored by the updater
 	if err := contentFromURL(ctx, lf.urlGetter, payload); err != nil {
 		// log.Printf("link fetcher error: %v url: %v\n", err, pURL)
-		return nil, nil
+		payload.FetchFailed = true
+		payload.RawContent.Reset()
+		return payload, nil
 	}
 
 	return payload, nil
@@ -81,17 +99,25 @@ func (lf *linkFetcher) isPrivate(urlString string) (bool, error) {
 func contentFromURL(ctx context.Context, getter URLGetter, payload *payload) error {
 	// url Getter
 	// held crawl link in expensive connection
+	start := time.Now()
 	res, err := getter.Get(payload.URL)
+	payload.ResponseTime = time.Since(start)
 	if err != nil {
 		return fmt.Errorf("http request: %v", err)
 	}
 
 	// http.Response should not nil, skipped not success code
-	if res == nil || res.StatusCode < 200 || res.StatusCode > 299 {
+	if res == nil {
+		return fmt.Errorf("http response is nil")
+	}
+	defer res.Body.Close()
+	payload.StatusCode = res.StatusCode
+	contentType := res.Header.Get("Content-Type")
+	payload.ContentType = contentType
+	if res.StatusCode < 200 || res.StatusCode > 299 {
 		return fmt.Errorf("http response status nok ok (%v)", res.StatusCode)
 	}
 
-	contentType := res.Header.Get("Content-Type")
 	if !strings.Contains(contentType, "html") {
 		return fmt.Errorf("http response: non html content-type:%v", contentType)
 	}
@@ -101,11 +127,66 @@ func contentFromURL(ctx context.Context, getter URLGetter, payload *payload) err
 		return fmt.Errorf("copy response body: %v", err)
 	}
 
-	err = res.Body.Close()
-	if err != nil {
-		return fmt.Errorf("close response body: %v", err)
-	}
+	sum := sha256.Sum256(payload.RawContent.Bytes())
+	payload.ContentHash = hex.EncodeToString(sum[:])
 
 	// log.Println("link fetcher content type", contentType, "url:", payload.URL)
 	return nil
 }
+
+// time until the robots.txt of the host is checked again
+const robotsCheckInterval = 24 * time.Hour
+
+// robotsChecker request the robots.txt of the host once per robotsCheckInterval,
+// it is shared by the fetchers so every worker not check the same host.
+// the status is only recorded, the robots rules are not applied.
+type robotsChecker struct {
+	urlGetter URLGetter
+
+	mu      sync.Mutex
+	checked map[string]time.Time
+}
+
+func newRobotsChecker(urlGetter URLGetter) *robotsChecker {
+	return &robotsChecker{
+		urlGetter: urlGetter,
+		checked:   make(map[string]time.Time),
+	}
+}
+
+// return the robots status of the host of rawURL when it is due,
+// graph.RobotsUnknown if it is checked recently.
+func (rc *robotsChecker) check(ctx context.Context, rawURL string) graph.RobotsStatus {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return graph.RobotsUnknown
+	}
+
+	// marked before the request so the host is checked once while it is running
+	now := time.Now()
+	rc.mu.Lock()
+	if at, ok := rc.checked[u.Host]; ok && now.Sub(at) < robotsCheckInterval {
+		rc.mu.Unlock()
+		return graph.RobotsUnknown
+	}
+	rc.checked[u.Host] = now
+	rc.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return graph.RobotsUnknown
+	}
+	res, err := rc.urlGetter.Get(u.Scheme + "://" + u.Host + "/robots.txt")
+	if err != nil || res == nil {
+		return graph.RobotsError
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return graph.RobotsFound
+	case res.StatusCode >= 400 && res.StatusCode <= 499:
+		return graph.RobotsMissing
+	default:
+		return graph.RobotsError
+	}
+}
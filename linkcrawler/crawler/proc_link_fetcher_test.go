@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	mock_crawler "github.com/odit-bit/invoker/linkcrawler/mocks"
+	"github.com/odit-bit/invoker/linkgraph/graph"
 	"go.uber.org/mock/gomock"
 )
 
@@ -45,8 +46,9 @@ func Test_linkFetcher_error_httpResponse(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if res != nil {
-		t.Error("result should nil", res)
+	// failed fetch is passed on with its status
+	if failed, ok := res.(*payload); !ok || !failed.FetchFailed || failed.StatusCode != 404 || failed.RawContent.Len() != 0 {
+		t.Errorf("\ngot:%+v\nexpect failed payload with status 404", res)
 	}
 
 	// non html header
@@ -62,8 +64,8 @@ func Test_linkFetcher_error_httpResponse(t *testing.T) {
 		t.Error(err)
 	}
 
-	if res != nil {
-		t.Error("result should nil", res)
+	if failed, ok := res.(*payload); !ok || !failed.FetchFailed || failed.ContentType != "Application/JSON" {
+		t.Errorf("\ngot:%+v\nexpect failed payload with content type", res)
 	}
 
 }
@@ -131,3 +133,38 @@ func Test_linkFetcher(t *testing.T) {
 	}
 
 }
+
+func Test_linkFetcher_robots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	urlGetter := mock_crawler.NewMockURLGetter(ctrl)
+	pnd := mock_crawler.NewMockPrivateNetworkDetector(ctrl)
+	pnd.EXPECT().IsPrivate(gomock.Any()).AnyTimes().Return(false, nil)
+
+	// robots.txt requested once for the host
+	urlGetter.EXPECT().Get(gomock.Eq("http://example.com/robots.txt")).Times(1).
+		Return(successHttpResponse(404, "text/plain", nil))
+	urlGetter.EXPECT().Get(gomock.Any()).AnyTimes().
+		Return(successHttpResponse(200, "text/html", []byte("<p>page</p>")))
+
+	lf := newLinkFetcher(urlGetter, pnd)
+	lf.robots = newRobotsChecker(urlGetter)
+
+	tests := []struct {
+		url    string
+		expect graph.RobotsStatus
+	}{
+		{url: "http://example.com/a", expect: graph.RobotsMissing},
+		{url: "http://example.com/b", expect: graph.RobotsUnknown},
+	}
+	for _, test := range tests {
+		res, err := lf.Process(context.TODO(), &payload{URL: test.url})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.(*payload).RobotsStatus; got != test.expect {
+			t.Fatalf("\nurl:%v\ngot:%v\nexpect:%v", test.url, got, test.expect)
+		}
+	}
+}
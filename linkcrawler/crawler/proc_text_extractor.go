@@ -10,6 +10,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/microcosm-cc/bluemonday"
+	"github.com/odit-bit/invoker/textIndex/index"
 	"github.com/odit-bit/pipeline"
 )
 
@@ -18,6 +19,7 @@ var (
 	// titleRegex = regexp.MustCompile(`(?i)<title.*?>(.*?)</title>`)
 
 	titleRegex         = regexp.MustCompile(`(?is)<title.*?>(.*?)</title>`)
+	htmlLangRegex      = regexp.MustCompile(`(?is)<html\b[^>]*?\blang\s*=\s*["']?([a-z_-]+)`)
 	repeatedSpaceRegex = regexp.MustCompile(`\s+`)
 )
 
@@ -40,7 +42,13 @@ func newTextExtractor() *textExtractor {
 
 // Process implements pipeline.Processor.
 func (te *textExtractor) Process(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
-	payload := p.(*payload)
+	payload, ok := p.(*payload)
+	if !ok {
+		return nil, fmt.Errorf("text extractor: payload underlying type is not crawler's payload: %T", p)
+	}
+	if payload.FetchFailed {
+		return payload, nil
+	}
 	lenP := payload.RawContent.Len()
 	if lenP == 0 {
 		return nil, fmt.Errorf("text extractor: length raw content is zero")
@@ -48,6 +56,9 @@ func (te *textExtractor) Process(ctx context.Context, p pipeline.Payload) (pipel
 	sanitizer := te.policyPool.Get().(*bluemonday.Policy)
 	defer te.policyPool.Put(sanitizer)
 
+	// sanitizer consume the raw content, so look the lang attribute first
+	payload.Language = htmlLanguage(payload.RawContent.Bytes())
+
 	title, body := sanitizeBytes(sanitizer, &payload.RawContent)
 	payload.Title, payload.TextContent = title, body
 
@@ -116,6 +127,15 @@ func sanitizeBytes(sanitizer *bluemonday.Policy, buf *bytes.Buffer) (title, body
 
 }
 
+// language of <html lang="..."> tag, empty if not declared
+func htmlLanguage(htmlBytes []byte) string {
+	matched := htmlLangRegex.FindSubmatch(htmlBytes)
+	if len(matched) != 2 {
+		return ""
+	}
+	return index.NormalizeLanguage(string(matched[1]))
+}
+
 func isValidUTF8(input []byte) bool {
 	for len(input) > 0 {
 		r, size := utf8.DecodeRune(input)
@@ -63,6 +63,10 @@ func Test_textExtractor(t *testing.T) {
 		t.Fatalf("error content \ngot:%v\nexpect:%v\n", v.TextContent, expectBodyTag)
 	}
 
+	if v.Language != "en" {
+		t.Fatalf("error language \ngot:%v\nexpect:%v\n", v.Language, "en")
+	}
+
 }
 
 var tRes, bRes []byte
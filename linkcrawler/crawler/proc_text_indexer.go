@@ -12,8 +12,9 @@ import (
 
 var _ pipeline.Processor = (*textIndexer)(nil)
 
+// index.WithContext adapt indexer that not accept context
 type Indexer interface {
-	Index(doc *index.Document) error
+	IndexContext(ctx context.Context, doc *index.Document) error
 }
 
 type textIndexer struct {
@@ -33,6 +34,11 @@ func (ti *textIndexer) Process(ctx context.Context, p pipeline.Payload) (pipelin
 		return nil, fmt.Errorf("graph updater not craweler's payload: %t ", p)
 	}
 
+	// nothing to index, the updater record the failure
+	if payload.FetchFailed {
+		return p, nil
+	}
+
 	if len(payload.TextContent) == 0 {
 		log.Println("[DEBUG][text indexer] text content is nil, it will error on postgreindex, url:", payload.URL)
 	}
@@ -44,8 +50,9 @@ func (ti *textIndexer) Process(ctx context.Context, p pipeline.Payload) (pipelin
 		Content:   string(payload.TextContent),
 		IndexedAt: time.Now(),
 		PageRank:  0,
+		Language:  payload.Language,
 	}
-	if err := ti.indexer.Index(&doc); err != nil {
+	if err := ti.indexer.IndexContext(ctx, &doc); err != nil {
 		return nil, err
 	}
 
@@ -14,8 +14,8 @@ var _ Indexer = (*mockIndexer)(nil)
 
 type mockIndexer struct{}
 
-// Index implements Indexer.
-func (mi *mockIndexer) Index(doc *index.Document) error {
+// IndexContext implements Indexer.
+func (mi *mockIndexer) IndexContext(ctx context.Context, doc *index.Document) error {
 	return nil
 }
 
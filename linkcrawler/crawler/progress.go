@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// progress track which link emitted by LinkSource has left the pipeline,
+// either dropped by a stage or consumed by the sink.
+// it relies on the source emitting links in ascending ID order, so the
+// high-water mark is the last link of the longest processed prefix.
+type progress struct {
+	mu sync.Mutex
+
+	// emitted link ID in order, head is the oldest one still in-flight
+	order []uuid.UUID
+	head  int
+
+	// number of payload (original and clones) still in pipeline per link ID
+	pending map[uuid.UUID]int
+
+	highWater uuid.UUID
+	processed int
+}
+
+func newProgress() *progress {
+	return &progress{
+		pending: map[uuid.UUID]int{},
+	}
+}
+
+// emit register the link ID as in-flight
+func (pr *progress) emit(id uuid.UUID) {
+	pr.mu.Lock()
+	pr.order = append(pr.order, id)
+	pr.pending[id]++
+	pr.mu.Unlock()
+}
+
+// clone register another payload of the same link ID
+func (pr *progress) clone(id uuid.UUID) {
+	pr.mu.Lock()
+	pr.pending[id]++
+	pr.mu.Unlock()
+}
+
+// done mark one payload of the link ID as left the pipeline
+func (pr *progress) done(id uuid.UUID) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if pr.pending[id]--; pr.pending[id] > 0 {
+		return
+	}
+
+	// advance high-water while the head is no longer in-flight
+	for pr.head < len(pr.order) && pr.pending[pr.order[pr.head]] <= 0 {
+		headID := pr.order[pr.head]
+		delete(pr.pending, headID)
+		pr.highWater = headID
+		pr.processed++
+		pr.head++
+	}
+
+	// reclaim the consumed part of the slice
+	if pr.head > 1024 && pr.head*2 > len(pr.order) {
+		pr.order = append([]uuid.UUID(nil), pr.order[pr.head:]...)
+		pr.head = 0
+	}
+}
+
+// HighWater return the last link ID that every link before it has been processed,
+// and the number of processed links. ok is false if none processed yet.
+func (pr *progress) HighWater() (id uuid.UUID, processed int, ok bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.highWater, pr.processed, pr.processed > 0
+}
@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	lpipeline "github.com/odit-bit/invoker/linkcrawler/pipeline"
+	"github.com/odit-bit/pipeline"
+)
+
+func Test_progress_high_water(t *testing.T) {
+	ids := []uuid.UUID{
+		uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000003"),
+	}
+
+	prog := newProgress()
+	for _, id := range ids {
+		prog.emit(id)
+	}
+
+	// broadcast clone the payload, both copies must be done
+	prog.clone(ids[0])
+
+	prog.done(ids[1])
+	if _, _, ok := prog.HighWater(); ok {
+		t.Fatal("high-water should not advance while the first link in-flight")
+	}
+
+	prog.done(ids[0])
+	if _, _, ok := prog.HighWater(); ok {
+		t.Fatal("high-water should not advance while clone of first link in-flight")
+	}
+
+	prog.done(ids[0])
+	hw, n, ok := prog.HighWater()
+	if !ok || hw != ids[1] || n != 2 {
+		t.Fatalf("\ngot:%v %v\nexpect:%v %v", hw, n, ids[1], 2)
+	}
+
+	prog.done(ids[2])
+	hw, n, _ = prog.HighWater()
+	if hw != ids[2] || n != 3 {
+		t.Fatalf("\ngot:%v %v\nexpect:%v %v", hw, n, ids[2], 3)
+	}
+}
+
+func Test_progress_cancel_in_throttle(t *testing.T) {
+	ids := []uuid.UUID{
+		uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+	}
+	prog := newProgress()
+
+	// fetched page is dropped, so it is marked as processed by the runner
+	drop := pipeline.ProcessorFunc(func(ctx context.Context, p pipeline.Payload) (pipeline.Payload, error) {
+		return nil, nil
+	})
+	runner := lpipeline.FIFO(&bridgedProcessor{proc: newHostThrottle(time.Hour, drop)})
+
+	in := make(chan lpipeline.Payload, len(ids))
+	errCh := make(chan error, 1)
+	for _, id := range ids {
+		prog.emit(id)
+		in <- &bridgedPayload{&payload{LinkID: id, URL: "http://example.com/" + id.String(), progress: prog}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		runner.Run(ctx, &bridgeParams{input: in, output: make(chan lpipeline.Payload), errCh: errCh})
+	}()
+
+	// the second link wait for the host delay
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-stopped
+
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, context.Canceled)
+	}
+	hw, n, ok := prog.HighWater()
+	if !ok || hw != ids[0] || n != 1 {
+		t.Fatalf("\ngot:%v %v\nexpect:%v %v", hw, n, ids[0], 1)
+	}
+}
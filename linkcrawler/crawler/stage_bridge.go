@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	lpipeline "github.com/odit-bit/invoker/linkcrawler/pipeline"
+	"github.com/odit-bit/pipeline"
+)
+
+// the crawler pipe is built on top of github.com/odit-bit/pipeline,
+// runnerStage let the StageRunner from linkcrawler/pipeline (batch, keyed etc)
+// take part as one stage of the pipe.
+
+var _ pipeline.Stage = (*runnerStage)(nil)
+
+type runnerStage struct {
+	runner lpipeline.StageRunner
+}
+
+func newRunnerStage(runner lpipeline.StageRunner) *runnerStage {
+	return &runnerStage{runner: runner}
+}
+
+// Run implements pipeline.Stage.
+func (rs *runnerStage) Run(ctx context.Context, in <-chan pipeline.Payload, errC chan<- error, out chan<- pipeline.Payload) {
+	var (
+		wg              sync.WaitGroup
+		runIn           = make(chan lpipeline.Payload)
+		runOut          = make(chan lpipeline.Payload)
+		feedCtx, cancel = context.WithCancel(ctx)
+	)
+	defer cancel()
+
+	// wrap the incoming payload
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(runIn)
+		for {
+			select {
+			case <-feedCtx.Done():
+				return
+			case p, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case <-feedCtx.Done():
+					return
+				case runIn <- &bridgedPayload{p}:
+				}
+			}
+		}
+	}()
+
+	// unwrap the outgoing payload
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for p := range runOut {
+			select {
+			case <-ctx.Done():
+				// keep draining so the runner never block
+			case out <- p.(*bridgedPayload).Payload:
+			}
+		}
+	}()
+
+	rs.runner.Run(ctx, &bridgeParams{
+		input:  runIn,
+		output: runOut,
+		errCh:  errC,
+	})
+	close(runOut)
+	// stop feeding if the runner return before input is closed
+	cancel()
+	wg.Wait()
+}
+
+var _ lpipeline.Payload = (*bridgedPayload)(nil)
+
+// bridgedPayload wrap payload from pipe so it can be handled by StageRunner
+type bridgedPayload struct {
+	pipeline.Payload
+}
+
+// Clone implements lpipeline.Payload.
+func (bp *bridgedPayload) Clone() lpipeline.Payload {
+	return &bridgedPayload{bp.Payload.Clone()}
+}
+
+var _ lpipeline.Processor = (*bridgedProcessor)(nil)
+
+// bridgedProcessor let processor written for the pipe run inside StageRunner
+type bridgedProcessor struct {
+	proc pipeline.Processor
+}
+
+// Process implements lpipeline.Processor.
+func (bp *bridgedProcessor) Process(ctx context.Context, p lpipeline.Payload) (lpipeline.Payload, error) {
+	in, ok := p.(*bridgedPayload)
+	if !ok {
+		return nil, fmt.Errorf("payload is not bridged from crawler pipe: %T", p)
+	}
+
+	out, err := bp.proc.Process(ctx, in.Payload)
+	if err != nil || out == nil {
+		return nil, err
+	}
+	return &bridgedPayload{out}, nil
+}
+
+var _ lpipeline.StageParams = (*bridgeParams)(nil)
+
+type bridgeParams struct {
+	input  <-chan lpipeline.Payload
+	output chan<- lpipeline.Payload
+	errCh  chan<- error
+}
+
+// StageIndex implements lpipeline.StageParams.
+func (bp *bridgeParams) StageIndex() int { return 0 }
+
+// Input implements lpipeline.StageParams.
+func (bp *bridgeParams) Input() <-chan lpipeline.Payload { return bp.input }
+
+// Output implements lpipeline.StageParams.
+func (bp *bridgeParams) Output() chan<- lpipeline.Payload { return bp.output }
+
+// Error implements lpipeline.StageParams.
+func (bp *bridgeParams) Error() chan<- error { return bp.errCh }
+
+// unwrap the crawler's payload from payload handed by StageRunner
+func crawlerPayload(p lpipeline.Payload) (*payload, error) {
+	bp, ok := p.(*bridgedPayload)
+	if !ok {
+		return nil, fmt.Errorf("payload is not bridged from crawler pipe: %T", p)
+	}
+	cp, ok := bp.Payload.(*payload)
+	if !ok {
+		return nil, fmt.Errorf("payload underlying type is not crawler's payload: %T", bp.Payload)
+	}
+	return cp, nil
+}
+
+// lpipeline.KeyFunc that partition payload by it's url host
+func bridgedHost(p lpipeline.Payload) string {
+	cp, err := crawlerPayload(p)
+	if err != nil {
+		return ""
+	}
+	return payloadHost(cp)
+}
@@ -0,0 +1,65 @@
+package crawlrun
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ContextStore is Store whose methods accept context,
+// store that talk to a database cancel the running query when ctx is done.
+type ContextStore interface {
+	StartRunContext(ctx context.Context, run *Run) error
+	SaveCheckpointContext(ctx context.Context, runID, checkpoint uuid.UUID, crawled int) error
+	FinishRunContext(ctx context.Context, runID uuid.UUID, crawled int) error
+	UnfinishedRunContext(ctx context.Context, fromID, toID uuid.UUID) (*Run, error)
+	RunsContext(ctx context.Context, offset, limit int) ([]*Run, error)
+}
+
+// WithContext return s as ContextStore, store that not accept context
+// is wrapped so the call fail early when ctx is done but can not be cancelled once started.
+func WithContext(s Store) ContextStore {
+	if cs, ok := s.(ContextStore); ok {
+		return cs
+	}
+	return contextAdapter{s: s}
+}
+
+type contextAdapter struct {
+	s Store
+}
+
+func (a contextAdapter) StartRunContext(ctx context.Context, run *Run) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.s.StartRun(run)
+}
+
+func (a contextAdapter) SaveCheckpointContext(ctx context.Context, runID, checkpoint uuid.UUID, crawled int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.s.SaveCheckpoint(runID, checkpoint, crawled)
+}
+
+func (a contextAdapter) FinishRunContext(ctx context.Context, runID uuid.UUID, crawled int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.s.FinishRun(runID, crawled)
+}
+
+func (a contextAdapter) UnfinishedRunContext(ctx context.Context, fromID, toID uuid.UUID) (*Run, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.s.UnfinishedRun(fromID, toID)
+}
+
+func (a contextAdapter) RunsContext(ctx context.Context, offset, limit int) ([]*Run, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.s.Runs(offset, limit)
+}
@@ -0,0 +1,30 @@
+package crawlrun
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_with_context(t *testing.T) {
+	store := WithContext(NewInMemory())
+
+	run := &Run{NumPartitions: 1}
+	if err := store.StartRunContext(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+
+	// the call fail early once ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.FinishRunContext(ctx, run.ID, 1); err != context.Canceled {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, context.Canceled)
+	}
+
+	runs, err := store.RunsContext(context.Background(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Finished() {
+		t.Fatalf("\ngot:%+v\nexpect one unfinished run", runs)
+	}
+}
@@ -0,0 +1,133 @@
+package crawlrun
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var _ Store = (*InMemory)(nil)
+
+// InMemory is Store implementation that keeps runs in memory,
+// the history is lost when process restart.
+type InMemory struct {
+	mu   sync.RWMutex
+	runs map[uuid.UUID]*Run
+}
+
+func NewInMemory() *InMemory {
+	return &InMemory{
+		runs: map[uuid.UUID]*Run{},
+	}
+}
+
+// StartRun implements Store.
+func (in *InMemory) StartRun(run *Run) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for {
+		run.ID = uuid.New()
+		if in.runs[run.ID] == nil {
+			break
+		}
+	}
+	run.StartedAt = time.Now().UTC()
+	run.UpdatedAt = run.StartedAt
+
+	rCopy := new(Run)
+	*rCopy = *run
+	in.runs[rCopy.ID] = rCopy
+	return nil
+}
+
+// SaveCheckpoint implements Store.
+func (in *InMemory) SaveCheckpoint(runID, checkpoint uuid.UUID, crawled int) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	run, ok := in.runs[runID]
+	if !ok {
+		return ErrNotFound
+	}
+	run.Checkpoint = checkpoint
+	run.Crawled = crawled
+	run.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// FinishRun implements Store.
+func (in *InMemory) FinishRun(runID uuid.UUID, crawled int) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	run, ok := in.runs[runID]
+	if !ok {
+		return ErrNotFound
+	}
+	run.Crawled = crawled
+	run.UpdatedAt = time.Now().UTC()
+	run.FinishedAt = run.UpdatedAt
+	return nil
+}
+
+// UnfinishedRun implements Store.
+func (in *InMemory) UnfinishedRun(fromID, toID uuid.UUID) (*Run, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var latest *Run
+	for _, run := range in.runs {
+		if run.Finished() {
+			continue
+		}
+		if run.FromID != fromID || run.ToID != toID {
+			if overlap(run.FromID, run.ToID, fromID, toID) {
+				run.UpdatedAt = time.Now().UTC()
+				run.FinishedAt = run.UpdatedAt
+				run.Abandoned = true
+			}
+			continue
+		}
+		if latest == nil || run.StartedAt.After(latest.StartedAt) {
+			latest = run
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+
+	rCopy := new(Run)
+	*rCopy = *latest
+	return rCopy, nil
+}
+
+// [fromA, toA) and [fromB, toB) has common link ID
+func overlap(fromA, toA, fromB, toB uuid.UUID) bool {
+	return bytes.Compare(fromA[:], toB[:]) < 0 && bytes.Compare(fromB[:], toA[:]) < 0
+}
+
+// Runs implements Store.
+func (in *InMemory) Runs(offset, limit int) ([]*Run, error) {
+	in.mu.RLock()
+	list := make([]*Run, 0, len(in.runs))
+	for _, run := range in.runs {
+		rCopy := new(Run)
+		*rCopy = *run
+		list = append(list, rCopy)
+	}
+	in.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.After(list[j].StartedAt) })
+	if offset >= len(list) {
+		return nil, nil
+	}
+	list = list[offset:]
+	if limit > 0 && limit < len(list) {
+		list = list[:limit]
+	}
+	return list, nil
+}
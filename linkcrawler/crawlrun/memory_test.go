@@ -0,0 +1,80 @@
+package crawlrun
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_run_resume(t *testing.T) {
+	store := NewInMemory()
+	from, to := uuid.Nil, uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
+
+	if _, err := store.UnfinishedRun(from, to); err != ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, ErrNotFound)
+	}
+
+	run := &Run{NumPartitions: 1, FromID: from, ToID: to}
+	if err := store.StartRun(run); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := uuid.New()
+	if err := store.SaveCheckpoint(run.ID, checkpoint, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	unfinished, err := store.UnfinishedRun(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unfinished.ID != run.ID || unfinished.Checkpoint != checkpoint || unfinished.Crawled != 10 {
+		t.Fatalf("\ngot: %+v\nexpect checkpoint: %v", unfinished, checkpoint)
+	}
+
+	if err := store.FinishRun(run.ID, 20); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UnfinishedRun(from, to); err != ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, ErrNotFound)
+	}
+
+	runs, err := store.Runs(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || !runs[0].Finished() || runs[0].Crawled != 20 {
+		t.Fatalf("\ngot: %+v", runs)
+	}
+}
+
+func Test_run_abandoned_on_rebalance(t *testing.T) {
+	store := NewInMemory()
+	half := uuid.MustParse("80000000-0000-0000-0000-000000000000")
+	third := uuid.MustParse("55555555-5555-5555-5555-555555555555")
+	max := uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
+
+	// run of the second of two partitions, interrupted
+	old := &Run{Partition: 1, NumPartitions: 2, FromID: half, ToID: max}
+	if err := store.StartRun(old); err != nil {
+		t.Fatal(err)
+	}
+
+	// first of three partitions not overlap it
+	if _, err := store.UnfinishedRun(uuid.Nil, third); err != ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, ErrNotFound)
+	}
+	runs, _ := store.Runs(0, 0)
+	if runs[0].Finished() {
+		t.Fatalf("\ngot: %+v\nexpect unfinished", runs[0])
+	}
+
+	// the last of three partitions overlap it, it is closed
+	if _, err := store.UnfinishedRun(third, max); err != ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, ErrNotFound)
+	}
+	runs, _ = store.Runs(0, 0)
+	if !runs[0].Finished() || !runs[0].Abandoned {
+		t.Fatalf("\ngot: %+v\nexpect abandoned", runs[0])
+	}
+}
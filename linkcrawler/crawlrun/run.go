@@ -0,0 +1,71 @@
+package crawlrun
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = fmt.Errorf("crawl run not found")
+
+// Run represent one crawl pass over a partition of the link graph
+type Run struct {
+	// unique identifier for run
+	ID uuid.UUID
+
+	// partition assignment when the run started
+	Partition     int
+	NumPartitions int
+
+	// [FromID, ToID) extents of the partition
+	FromID uuid.UUID
+	ToID   uuid.UUID
+
+	// high-water link ID, every link in [FromID, Checkpoint] has been processed.
+	// uuid.Nil means no checkpoint saved yet
+	Checkpoint uuid.UUID
+
+	// number of crawled link up to the checkpoint
+	Crawled int
+
+	// timestamp when the run started
+	StartedAt time.Time
+
+	// timestamp when the checkpoint last saved
+	UpdatedAt time.Time
+
+	// timestamp when the run completed, zero value for unfinished run
+	FinishedAt time.Time
+
+	// the run is closed without completing because the partition extents changed
+	// before it finished, its checkpoint not apply to the new extents.
+	Abandoned bool
+}
+
+// Finished report whether the run is completed
+func (r *Run) Finished() bool {
+	return !r.FinishedAt.IsZero()
+}
+
+// Store keeps the history of crawl runs so an interrupted pass can be resumed.
+type Store interface {
+	// StartRun insert new run, the ID and StartedAt will be assigned by store
+	StartRun(run *Run) error
+
+	// SaveCheckpoint update the high-water link ID and crawled count of run
+	SaveCheckpoint(runID, checkpoint uuid.UUID, crawled int) error
+
+	// FinishRun mark the run as completed
+	FinishRun(runID uuid.UUID, crawled int) error
+
+	// UnfinishedRun return the latest unfinished run over [fromID, toID),
+	// ErrNotFound is returned if there is none.
+	// unfinished run over other extents that overlap [fromID, toID) is finished as abandoned,
+	// the partitions are rebalanced since it started and it would never be resumed.
+	UnfinishedRun(fromID, toID uuid.UUID) (*Run, error)
+
+	// Runs return the run history ordered from the newest,
+	// limit <= 0 return all the remaining runs
+	Runs(offset, limit int) ([]*Run, error)
+}
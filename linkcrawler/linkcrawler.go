@@ -2,6 +2,7 @@ package linkcrawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/odit-bit/invoker/internal/privnet"
+	"github.com/odit-bit/invoker/internal/xuuid"
 	"github.com/odit-bit/invoker/linkcrawler/crawler"
+	"github.com/odit-bit/invoker/linkcrawler/crawlrun"
 	"github.com/odit-bit/invoker/linkcrawler/metric"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 	"github.com/odit-bit/invoker/partition"
@@ -56,24 +59,15 @@ crawling logic
 
 // ===========================================================service
 //
-//	graph representation of link
+//	graph representation of link,
+//	graph that not implement graph.ContextGraph is adapted with graph.WithContext
 type GraphAPI interface {
-	UpsertLink(link *graph.Link) error
-	// insert the new edge, the updated scenario will occure
-	// if crawler will discovered another link from edge destination it will need updated
-	UpsertEdge(edge *graph.Edge) error
-
-	// RemoveStaleEdges removes any edge that originates from the specified
-	// link ID and was updated before the specified timestamp.
-	RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error
-
-	//return link iterator to iterate link in graph
-	Links(fromID, toID uuid.UUID, retrieveBefore time.Time) (graph.LinkIterator, error)
+	graph.Graph
 }
 
+// index that not implement index.ContextIndexer is adapted with index.WithContext
 type IndexAPI interface {
-	// index will insert or update the index entry (doc)
-	Index(doc *index.Document) error
+	index.Indexer
 }
 
 // metric
@@ -98,15 +92,40 @@ type Config struct {
 	// wake the crawler to start scan the link again
 	UpdateInterval time.Duration
 
+	// when Graphdb implement graph.ChangeFeed, new link recorded in the graph
+	// wake the crawler after this delay instead of waiting for UpdateInterval.
+	// the delay gather links recorded together into one pass, zero disable it.
+	ChangeDelay time.Duration
+
 	// minimum amount of time before re-indexing link that already crawled
 	ReindexInterval time.Duration
 
 	//detect partition assginment for this service
 	PartitionDetector partition.Detector
 
+	// record crawl runs and their checkpoint so interrupted pass can be resumed.
+	// if nil every pass start from the begining of partition.
+	RunStore crawlrun.Store
+
+	// time between saved checkpoint of running pass
+	CheckpointInterval time.Duration
+
 	//number conccurent worker used for retreiving link.
 	FetchWorker int
 
+	// fetch every link of the same host by the same worker
+	FetchByHost bool
+
+	// minimum time between request to the same host when FetchByHost enabled
+	HostFetchDelay time.Duration
+
+	// number of crawled page written to graph and index in one batch,
+	// value <= 1 disable batching.
+	BatchSize int
+
+	// maximum time crawled page wait before partial batch is written
+	BatchInterval time.Duration
+
 	// count amount of crawled link for this service
 	Counter metric.CounterFunc
 
@@ -154,6 +173,11 @@ func (cfg *Config) validate() error {
 type Service struct {
 	cfg *Config
 
+	// Graphdb and RunStore with context, the query stop with the service.
+	// runs is nil without RunStore
+	graph graph.ContextGraph
+	runs  crawlrun.ContextStore
+
 	//crawler pipeline
 	crawler *crawler.Crawler
 }
@@ -197,22 +221,49 @@ func NewWithConfig(cfg *Config) (*Service, error) {
 		return nil, err
 	}
 
+	cg := graph.WithContext(cfg.Graphdb)
+
+	// assign only when implemented, typed nil would not be nil interface
+	var hostStats crawler.HostStatsRecorder
+	if hg, ok := cfg.Graphdb.(graph.HostGraph); ok {
+		hostStats = graph.HostsWithContext(hg)
+	}
+	var graphBatch graph.BatchGraph
+	if bg, ok := cfg.Graphdb.(graph.BatchGraph); ok {
+		graphBatch = bg
+	}
+
 	// pipeline
 	pipe, err := crawler.New(&crawler.Config{
 		URLGetter:    cfg.URLGetter,
 		NetDetector:  cfg.NetDetector,
-		Indexer:      cfg.Indexdb,
-		GraphUpdater: cfg.Graphdb,
+		Indexer:      index.WithContext(cfg.Indexdb),
+		GraphUpdater: cg,
 		FetchWorker:  cfg.FetchWorker,
+
+		FetchByHost:    cfg.FetchByHost,
+		HostFetchDelay: cfg.HostFetchDelay,
+
+		BatchSize:     cfg.BatchSize,
+		BatchInterval: cfg.BatchInterval,
+
+		RecrawlInterval: cfg.ReindexInterval,
+		HostStats:       hostStats,
+		GraphBatch:      graphBatch,
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Service{
+	svc := &Service{
 		cfg:     cfg,
+		graph:   cg,
 		crawler: pipe,
-	}, nil
+	}
+	if cfg.RunStore != nil {
+		svc.runs = crawlrun.WithContext(cfg.RunStore)
+	}
+	return svc, nil
 }
 
 // do background process of sanitizion, link extraction and text extraction
@@ -222,7 +273,17 @@ func (s *Service) Run(ctx context.Context) error {
 	s.cfg.Logger.Printf("reindex interval: %v\n", s.cfg.ReindexInterval.String())
 	s.cfg.Logger.Printf("worker: %v\n", s.cfg.FetchWorker)
 
+	// nil channel never receive when the change feed not used
+	var wake chan struct{}
+	if feed, ok := s.cfg.Graphdb.(graph.ChangeFeed); ok && s.cfg.ChangeDelay > 0 {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		wake = make(chan struct{}, 1)
+		go s.watchChanges(watchCtx, feed, wake)
+	}
+
 	ticker := time.NewTimer(s.cfg.UpdateInterval)
+	nextPass := time.Now().Add(s.cfg.UpdateInterval)
 	defer func() {
 		if ticker.Stop() {
 			<-ticker.C
@@ -237,16 +298,85 @@ func (s *Service) Run(ctx context.Context) error {
 				<-ticker.C
 			}
 			return nil
+		case <-wake:
+			// bring the pass forward, the timer that already fired is left to the next iteration
+			if time.Until(nextPass) > s.cfg.ChangeDelay && ticker.Stop() {
+				ticker.Reset(s.cfg.ChangeDelay)
+				nextPass = time.Now().Add(s.cfg.ChangeDelay)
+			}
 		case <-ticker.C:
 			s.cfg.Logger.Println("[INFO] crawl iteration start")
 			cur, num, err := s.cfg.PartitionDetector.PartitionInfo()
 			if err != nil {
+				if errors.Is(err, partition.ErrNoPartitionDataAvailableYet) {
+					s.cfg.Logger.Println("[WARN] deferring crawl pass: partition data not yet available")
+					ticker.Reset(s.cfg.UpdateInterval)
+					nextPass = time.Now().Add(s.cfg.UpdateInterval)
+					continue
+				}
 				return err
 			}
 			if err := s.crawlGraph(ctx, cur, num); err != nil {
 				return err
 			}
 			ticker.Reset(s.cfg.UpdateInterval)
+			nextPass = time.Now().Add(s.cfg.UpdateInterval)
+		}
+	}
+}
+
+// watchChanges send to wake when new link recorded in the graph,
+// the subscription start from the latest change and is resumed after error.
+func (s *Service) watchChanges(ctx context.Context, feed graph.ChangeFeed, wake chan<- struct{}) {
+	cursor, err := feed.LastChange(ctx)
+	for err != nil {
+		s.cfg.Logger.Printf("[ERROR] change feed: %v\n", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.UpdateInterval):
+		}
+		cursor, err = feed.LastChange(ctx)
+	}
+
+	for {
+		it := graph.Subscribe(ctx, feed, cursor)
+		for it.Next() {
+			change := it.Change()
+			cursor = change.Cursor
+			if change.Kind != graph.ChangeLinkCreated {
+				continue
+			}
+			select {
+			case wake <- struct{}{}:
+			default:
+				// already waked
+			}
+		}
+		err := it.Error()
+		it.Close()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, graph.ErrChangesPruned) {
+			// link created in the pruned range may be missed, the crawl pass see every link anyway
+			s.cfg.Logger.Println("[WARN] change feed: cursor fall behind retention, resync from the latest change")
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+			if last, err := feed.LastChange(ctx); err == nil {
+				cursor = last
+				continue
+			}
+		}
+
+		s.cfg.Logger.Printf("[ERROR] change feed: %v\n", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.cfg.ChangeDelay):
 		}
 	}
 }
@@ -263,8 +393,12 @@ func (s *Service) crawlGraph(ctx context.Context, curPartition, numPartition int
 		return err
 	}
 
+	if s.runs != nil {
+		return s.crawlRun(ctx, curPartition, numPartition, fromID, toID)
+	}
+
 	start := time.Now()
-	li, err := s.cfg.Graphdb.Links(fromID, toID, start.Add(-s.cfg.ReindexInterval))
+	li, err := s.graph.LinksContext(ctx, fromID, toID, graph.LinkFilter{RetrievedBefore: start.Add(-s.cfg.ReindexInterval)})
 	if err != nil {
 		return err
 	}
@@ -280,3 +414,68 @@ func (s *Service) crawlGraph(ctx context.Context, curPartition, numPartition int
 	s.cfg.Logger.Printf("[INFO] completed pipeline link:%v et: %v \n", n, end.Round(1*time.Millisecond))
 	return nil
 }
+
+// crawlRun is like crawlGraph but the pass is recorded in RunStore,
+// and unfinished pass over the same extents is resumed from its checkpoint.
+func (s *Service) crawlRun(ctx context.Context, curPartition, numPartition int, fromID, toID uuid.UUID) error {
+	run, err := s.runs.UnfinishedRunContext(ctx, fromID, toID)
+	switch {
+	case err == crawlrun.ErrNotFound:
+		run = &crawlrun.Run{
+			Partition:     curPartition,
+			NumPartitions: numPartition,
+			FromID:        fromID,
+			ToID:          toID,
+		}
+		if err := s.runs.StartRunContext(ctx, run); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		s.cfg.Logger.Printf("[INFO] resume crawl run:%v checkpoint:%v crawled:%v\n", run.ID, run.Checkpoint, run.Crawled)
+	}
+
+	iterFrom := run.FromID
+	if run.Checkpoint != uuid.Nil {
+		next, ok := xuuid.Next(run.Checkpoint)
+		if !ok {
+			return s.runs.FinishRunContext(ctx, run.ID, run.Crawled)
+		}
+		iterFrom = next
+	}
+
+	// keep the same cutoff as when the run started, so resumed pass select the same links
+	start := time.Now()
+	li, err := s.graph.LinksContext(ctx, iterFrom, toID, graph.LinkFilter{RetrievedBefore: run.StartedAt.Add(-s.cfg.ReindexInterval)})
+	if err != nil {
+		return err
+	}
+	defer li.Close()
+
+	// the last checkpoint is saved after the crawl is cancelled, so it must not be cancelled with it
+	processed := run.Crawled
+	checkpointCtx := context.WithoutCancel(ctx)
+	n, err := s.crawler.CrawlWithCheckpoint(ctx, li, s.cfg.CheckpointInterval, func(highWater uuid.UUID, count int) error {
+		processed = run.Crawled + count
+		return s.runs.SaveCheckpointContext(checkpointCtx, run.ID, highWater, processed)
+	})
+	end := time.Since(start)
+
+	// the pipeline stop when context is canceled, the link still waiting in it
+	// is not counted in the checkpoint, the run is not finished and will be resumed
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.runs.FinishRunContext(ctx, run.ID, processed); err != nil {
+		return err
+	}
+
+	s.cfg.Counter(float64(n))
+	s.cfg.Logger.Printf("[INFO] completed crawl run:%v link:%v et: %v \n", run.ID, n, end.Round(1*time.Millisecond))
+	return nil
+}
@@ -9,6 +9,7 @@
 package mock_crawler
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -40,44 +41,44 @@ func (m *MockGraphUpdater) EXPECT() *MockGraphUpdaterMockRecorder {
 	return m.recorder
 }
 
-// RemoveStaleEdges mocks base method.
-func (m *MockGraphUpdater) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error {
+// RemoveStaleEdgesContext mocks base method.
+func (m *MockGraphUpdater) RemoveStaleEdgesContext(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveStaleEdges", fromID, updatedBefore)
+	ret := m.ctrl.Call(m, "RemoveStaleEdgesContext", ctx, fromID, updatedBefore)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RemoveStaleEdges indicates an expected call of RemoveStaleEdges.
-func (mr *MockGraphUpdaterMockRecorder) RemoveStaleEdges(fromID, updatedBefore any) *gomock.Call {
+// RemoveStaleEdgesContext indicates an expected call of RemoveStaleEdgesContext.
+func (mr *MockGraphUpdaterMockRecorder) RemoveStaleEdgesContext(ctx, fromID, updatedBefore any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveStaleEdges", reflect.TypeOf((*MockGraphUpdater)(nil).RemoveStaleEdges), fromID, updatedBefore)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveStaleEdgesContext", reflect.TypeOf((*MockGraphUpdater)(nil).RemoveStaleEdgesContext), ctx, fromID, updatedBefore)
 }
 
-// UpsertEdge mocks base method.
-func (m *MockGraphUpdater) UpsertEdge(edge *graph.Edge) error {
+// UpsertEdgeContext mocks base method.
+func (m *MockGraphUpdater) UpsertEdgeContext(ctx context.Context, edge *graph.Edge) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpsertEdge", edge)
+	ret := m.ctrl.Call(m, "UpsertEdgeContext", ctx, edge)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpsertEdge indicates an expected call of UpsertEdge.
-func (mr *MockGraphUpdaterMockRecorder) UpsertEdge(edge any) *gomock.Call {
+// UpsertEdgeContext indicates an expected call of UpsertEdgeContext.
+func (mr *MockGraphUpdaterMockRecorder) UpsertEdgeContext(ctx, edge any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertEdge", reflect.TypeOf((*MockGraphUpdater)(nil).UpsertEdge), edge)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertEdgeContext", reflect.TypeOf((*MockGraphUpdater)(nil).UpsertEdgeContext), ctx, edge)
 }
 
-// UpsertLink mocks base method.
-func (m *MockGraphUpdater) UpsertLink(link *graph.Link) error {
+// UpsertLinkContext mocks base method.
+func (m *MockGraphUpdater) UpsertLinkContext(ctx context.Context, link *graph.Link) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpsertLink", link)
+	ret := m.ctrl.Call(m, "UpsertLinkContext", ctx, link)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpsertLink indicates an expected call of UpsertLink.
-func (mr *MockGraphUpdaterMockRecorder) UpsertLink(link any) *gomock.Call {
+// UpsertLinkContext indicates an expected call of UpsertLinkContext.
+func (mr *MockGraphUpdaterMockRecorder) UpsertLinkContext(ctx, link any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertLink", reflect.TypeOf((*MockGraphUpdater)(nil).UpsertLink), link)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertLinkContext", reflect.TypeOf((*MockGraphUpdater)(nil).UpsertLinkContext), ctx, link)
 }
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+)
+
+// BatchProcessor is implemented by types that process a group of payloads
+// at once, like writing them into a store within a single round-trip.
+//
+// the returned slice will be passed to the next stage, payloads that are not
+// part of the returned slice should be marked as processed by the processor.
+type BatchProcessor interface {
+	ProcessBatch(context.Context, []Payload) ([]Payload, error)
+}
+
+type BatchProcessorFunc func(ctx context.Context, batch []Payload) ([]Payload, error)
+
+func (bf BatchProcessorFunc) ProcessBatch(ctx context.Context, batch []Payload) ([]Payload, error) {
+	return bf(ctx, batch)
+}
+
+var _ StageRunner = (*batch)(nil)
+
+// collect incoming payloads and hand them to BatchProcessor when the batch
+// reach maxSize or the oldest payload in the batch wait longer than maxWait.
+type batch struct {
+	proc    BatchProcessor
+	maxSize int
+	maxWait time.Duration
+}
+
+// instantiate the StageRunner that group payloads into batches of at most
+// maxSize. if maxWait > 0 partial batch will be flushed after maxWait since
+// the first payload of the batch received.
+func Batch(proc BatchProcessor, maxSize int, maxWait time.Duration) StageRunner {
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+	return &batch{
+		proc:    proc,
+		maxSize: maxSize,
+		maxWait: maxWait,
+	}
+}
+
+// Run implements StageRunner.
+func (b *batch) Run(ctx context.Context, param StageParams) {
+	var (
+		buf   = make([]Payload, 0, b.maxSize)
+		timer = time.NewTimer(b.maxWait)
+
+		// nil chan never fire, so the timer only observed when batch is not empty
+		timeout <-chan time.Time
+	)
+	stopTimer(timer)
+	defer stopTimer(timer)
+
+	// flush process the collected batch and send the result to the next stage,
+	// return false if the runner should exit.
+	flush := func() bool {
+		stopTimer(timer)
+		timeout = nil
+		if len(buf) == 0 {
+			return true
+		}
+
+		payloadOut, err := b.proc.ProcessBatch(ctx, buf)
+		// processor may retain the slice, allocate new one for next batch
+		buf = make([]Payload, 0, b.maxSize)
+		if err != nil {
+			emitError(err, param.Error())
+			return false
+		}
+
+		for _, p := range payloadOut {
+			select {
+			case <-ctx.Done():
+				return false
+			case param.Output() <- p:
+				//payload go to next stage
+			}
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+			if !flush() {
+				return
+			}
+		case payloadIn, ok := <-param.Input():
+			if !ok {
+				//chan maybe closed, flush the remaining
+				flush()
+				return
+			}
+
+			buf = append(buf, payloadIn)
+			if len(buf) >= b.maxSize {
+				if !flush() {
+					return
+				}
+				continue
+			}
+
+			if len(buf) == 1 && b.maxWait > 0 {
+				timer.Reset(b.maxWait)
+				timeout = timer.C
+			}
+		}
+	}
+}
+
+// stop the timer and drain the channel if timer already fired
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func Test_batch_size(t *testing.T) {
+	input := make(chan Payload)
+	output := make(chan Payload)
+	errCh := make(chan error, 1)
+	wp := workerParams{
+		stage:  0,
+		input:  input,
+		output: output,
+		errCh:  errCh,
+	}
+
+	var sizes []int
+	proc := BatchProcessorFunc(func(ctx context.Context, batch []Payload) ([]Payload, error) {
+		sizes = append(sizes, len(batch))
+		return batch, nil
+	})
+
+	runner := Batch(proc, 3, 0)
+	done := make(chan struct{})
+	go func() {
+		runner.Run(context.Background(), &wp)
+		close(output)
+		close(done)
+	}()
+
+	go func() {
+		for i := 0; i < 7; i++ {
+			input <- &stubPayload{value: fmt.Sprint(i)}
+		}
+		close(input)
+	}()
+
+	count := 0
+	for p := range output {
+		if v := p.(*stubPayload).value; v != fmt.Sprint(count) {
+			t.Fatalf("\nactual: %v\nexpect: %v\n", v, count)
+		}
+		count++
+	}
+	<-done
+
+	if count != 7 {
+		t.Fatalf("\nactual: %v\nexpect: %v\n", count, 7)
+	}
+
+	expect := []int{3, 3, 1}
+	if fmt.Sprint(sizes) != fmt.Sprint(expect) {
+		t.Fatalf("\nactual: %v\nexpect: %v\n", sizes, expect)
+	}
+}
+
+func Test_batch_timeout(t *testing.T) {
+	input := make(chan Payload)
+	output := make(chan Payload)
+	errCh := make(chan error, 1)
+	wp := workerParams{
+		stage:  0,
+		input:  input,
+		output: output,
+		errCh:  errCh,
+	}
+
+	proc := BatchProcessorFunc(func(ctx context.Context, batch []Payload) ([]Payload, error) {
+		return batch, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runner := Batch(proc, 100, 50*time.Millisecond)
+	go runner.Run(ctx, &wp)
+
+	payload := stubPayload{value: "partial"}
+	input <- &payload
+
+	select {
+	case p := <-output:
+		if p != &payload {
+			t.Fatal("unexpected payload")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("partial batch not flushed after max wait")
+	}
+}
+
+func Test_batch_error(t *testing.T) {
+	input := make(chan Payload)
+	output := make(chan Payload)
+	errCh := make(chan error, 1)
+	wp := workerParams{
+		stage:  0,
+		input:  input,
+		output: output,
+		errCh:  errCh,
+	}
+
+	proc := BatchProcessorFunc(func(ctx context.Context, batch []Payload) ([]Payload, error) {
+		return nil, fmt.Errorf("process error")
+	})
+
+	runner := Batch(proc, 1, 0)
+	go runner.Run(context.Background(), &wp)
+
+	input <- &stubPayload{}
+
+	select {
+	case err := <-errCh:
+		if err.Error() != "process error" {
+			t.Fatalf("\nactual: %v\nexpect: %v\n", err, "process error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("chan is blocking")
+	}
+}
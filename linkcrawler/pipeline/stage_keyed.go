@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+// KeyFunc return the key of payload, payloads with the same key
+// always processed by the same worker.
+type KeyFunc func(Payload) string
+
+var _ StageRunner = (*keyed)(nil)
+
+// unlike workerPool that hand payload to whichever worker is free,
+// keyed route payload to fixed worker by the hash of it's key.
+// so payloads with same key processed in-order and
+// every worker can hold it's own state without locking.
+type keyed struct {
+	keyFn KeyFunc
+	fifos []StageRunner
+}
+
+// instantiate the StageRunner with numWorker fifo, newProc called once
+// for every worker so each of them get dedicated Processor instance.
+func KeyPartition(keyFn KeyFunc, numWorker int, newProc func(worker int) Processor) StageRunner {
+	if numWorker <= 0 {
+		numWorker = 1
+	}
+
+	fifos := make([]StageRunner, numWorker)
+	for i := range fifos {
+		fifos[i] = FIFO(newProc(i))
+	}
+	return &keyed{
+		keyFn: keyFn,
+		fifos: fifos,
+	}
+}
+
+// Run implements StageRunner.
+func (k *keyed) Run(ctx context.Context, params StageParams) {
+	var (
+		wg   sync.WaitGroup
+		inCh = make([]chan Payload, len(k.fifos))
+	)
+
+	// Start each FIFO in a go-routine. Each FIFO gets its own dedicated
+	// input channel and the shared output channel passed to Run.
+	for i := 0; i < len(k.fifos); i++ {
+		wg.Add(1)
+		inCh[i] = make(chan Payload)
+		go func(fifoIndex int) {
+			fifoParams := &workerParams{
+				stage:  params.StageIndex(),
+				input:  inCh[fifoIndex],
+				output: params.Output(),
+				errCh:  params.Error(),
+			}
+			k.fifos[fifoIndex].Run(ctx, fifoParams)
+			wg.Done()
+		}(i)
+	}
+
+done:
+	for {
+		// Read incoming payloads and pass them to FIFO that own the key
+		select {
+		case <-ctx.Done():
+			break done
+		case payload, ok := <-params.Input():
+			if !ok {
+				break done
+			}
+
+			i := workerFor(k.keyFn(payload), len(k.fifos))
+			select {
+			case <-ctx.Done():
+				break done
+			case inCh[i] <- payload:
+				// payload sent to i_th FIFO
+			}
+		}
+	}
+
+	// Close input channels and wait for FIFOs to exit
+	for _, ch := range inCh {
+		close(ch)
+	}
+	wg.Wait()
+}
+
+// map the key into worker index
+func workerFor(key string, numWorker int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(numWorker))
+}
@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_key_partition(t *testing.T) {
+	input := make(chan Payload)
+	output := make(chan Payload)
+	errCh := make(chan error, 1)
+	wp := workerParams{
+		stage:  0,
+		input:  input,
+		output: output,
+		errCh:  errCh,
+	}
+
+	// the maps below is shared by every worker only for assertion
+	var mu sync.Mutex
+	seen := map[string][]string{} // key -> values in processed order
+	owner := map[string]int{}     // key -> worker
+	newProc := func(worker int) Processor {
+		return ProcessorFunc(func(ctx context.Context, payload Payload) (Payload, error) {
+			v := payload.(*stubPayload).value
+			key := strings.Split(v, "/")[0]
+
+			mu.Lock()
+			defer mu.Unlock()
+			if w, ok := owner[key]; ok && w != worker {
+				return nil, fmt.Errorf("key %v processed by worker %v and %v", key, w, worker)
+			}
+			owner[key] = worker
+			seen[key] = append(seen[key], v)
+			return payload, nil
+		})
+	}
+	keyFn := func(p Payload) string {
+		return strings.Split(p.(*stubPayload).value, "/")[0]
+	}
+
+	runner := KeyPartition(keyFn, 4, newProc)
+	go func() {
+		runner.Run(context.Background(), &wp)
+		close(output)
+	}()
+
+	go func() {
+		for i := 0; i < 50; i++ {
+			input <- &stubPayload{value: fmt.Sprintf("host%d/%02d", i%5, i)}
+		}
+		close(input)
+	}()
+
+	count := 0
+	for range output {
+		count++
+	}
+
+	select {
+	case err := <-errCh:
+		t.Fatal(err)
+	default:
+	}
+
+	if count != 50 {
+		t.Fatalf("\nactual: %v\nexpect: %v\n", count, 50)
+	}
+
+	for key, values := range seen {
+		for i := 1; i < len(values); i++ {
+			if values[i-1] > values[i] {
+				t.Fatalf("key %v processed out of order: %v", key, values)
+			}
+		}
+	}
+}
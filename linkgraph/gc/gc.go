@@ -0,0 +1,144 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// default time between prune of the change log
+const defaultChangeInterval = time.Hour
+
+// GraphAPI defines the graph method the collector need
+type GraphAPI interface {
+	RemoveOrphanLinks(createdBefore time.Time) (int, error)
+}
+
+// ChangePruner is implemented by graph that keep change log (graph.ChangeFeed)
+type ChangePruner interface {
+	PruneChanges(ctx context.Context, before time.Time) (int, error)
+}
+
+// Config encapsulates the settings for the orphan link collector
+type Config struct {
+	// graph to prune
+	GraphAPI GraphAPI
+
+	// orphan link younger than MaxAge is kept, crawler may not reach it yet
+	MaxAge time.Duration
+
+	// time between subsequent collection passes, zero disable the orphan link removal
+	Interval time.Duration
+
+	// change older than ChangeRetention is pruned from the change log when the graph implement ChangePruner,
+	// zero keep every change.
+	ChangeRetention time.Duration
+
+	// time between subsequent prune of the change log, it run on its own ticker
+	// so the log is pruned even when orphan link removal is disabled. zero use the default
+	ChangeInterval time.Duration
+
+	Logger *log.Logger
+}
+
+func (cfg *Config) validate() error {
+	if cfg.GraphAPI == nil {
+		return fmt.Errorf("graph API has not been provided")
+	}
+	if cfg.Interval < 0 {
+		return fmt.Errorf("invalid value for interval")
+	}
+	if cfg.Interval > 0 && cfg.MaxAge <= 0 {
+		return fmt.Errorf("invalid value for max age")
+	}
+	if cfg.ChangeRetention < 0 {
+		return fmt.Errorf("invalid value for change retention")
+	}
+	if _, ok := cfg.GraphAPI.(ChangePruner); !ok {
+		// nothing to prune
+		cfg.ChangeRetention = 0
+	}
+	if cfg.Interval == 0 && cfg.ChangeRetention == 0 {
+		return fmt.Errorf("neither orphan link removal nor change prune is enabled")
+	}
+	if cfg.ChangeInterval <= 0 {
+		cfg.ChangeInterval = defaultChangeInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stdout, "[graph-gc]", log.Ldate|log.Ltime)
+	}
+	return nil
+}
+
+// Service periodically prune links that never been retrieved and no other link point to,
+// so dead or delisted url not stay in the graph forever, and the old changes of the change log.
+type Service struct {
+	cfg Config
+}
+
+func New(cfg Config) (*Service, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("graph gc: config validation failed: %w", err)
+	}
+	return &Service{cfg: cfg}, nil
+}
+
+// Name implements service.Service
+func (svc *Service) Name() string { return "graph GC" }
+
+// Run implements service.Service
+func (svc *Service) Run(ctx context.Context) error {
+	svc.cfg.Logger.Printf("orphan link max age: %v interval: %v change retention: %v interval: %v\n",
+		svc.cfg.MaxAge, svc.cfg.Interval, svc.cfg.ChangeRetention, svc.cfg.ChangeInterval)
+
+	// nil channel never receive when the task is disabled
+	var collectC, pruneC <-chan time.Time
+	if svc.cfg.Interval > 0 {
+		ticker := time.NewTicker(svc.cfg.Interval)
+		defer ticker.Stop()
+		collectC = ticker.C
+	}
+	if svc.cfg.ChangeRetention > 0 {
+		ticker := time.NewTicker(svc.cfg.ChangeInterval)
+		defer ticker.Stop()
+		pruneC = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-collectC:
+			err = svc.collect()
+		case <-pruneC:
+			err = svc.prune(ctx)
+		}
+		if err != nil {
+			// try again next pass
+			svc.cfg.Logger.Printf("[ERROR] %v\n", err)
+		}
+	}
+}
+
+// one collection pass
+func (svc *Service) collect() error {
+	n, err := svc.cfg.GraphAPI.RemoveOrphanLinks(time.Now().Add(-svc.cfg.MaxAge))
+	if err != nil {
+		return err
+	}
+	svc.cfg.Logger.Printf("[INFO] removed %v orphan links\n", n)
+	return nil
+}
+
+// remove the changes older than the retention
+func (svc *Service) prune(ctx context.Context) error {
+	n, err := svc.cfg.GraphAPI.(ChangePruner).PruneChanges(ctx, time.Now().Add(-svc.cfg.ChangeRetention))
+	if err != nil {
+		return err
+	}
+	svc.cfg.Logger.Printf("[INFO] pruned %v changes\n", n)
+	return nil
+}
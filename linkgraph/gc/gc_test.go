@@ -0,0 +1,93 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"github.com/odit-bit/invoker/linkgraph/memory"
+)
+
+func Test_collect(t *testing.T) {
+	g := memory.New()
+	retrieved := &graph.Link{URL: "www.retrieved.com", RetrievedAt: time.Now()}
+	orphan := &graph.Link{URL: "www.orphan.com"}
+	for _, link := range []*graph.Link{retrieved, orphan} {
+		if err := g.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc, err := New(Config{
+		GraphAPI: g,
+		MaxAge:   time.Millisecond,
+		Interval: time.Hour,
+		Logger:   log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := svc.collect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.LookupLink(orphan.ID); err == nil {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+	if _, err := g.LookupLink(retrieved.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_prune_changes(t *testing.T) {
+	g := memory.New()
+	if err := g.UpsertLink(&graph.Link{URL: "www.old.com", RetrievedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// orphan link removal is disabled, the change log is still pruned
+	svc, err := New(Config{
+		GraphAPI:        g,
+		ChangeRetention: 50 * time.Millisecond,
+		ChangeInterval:  10 * time.Millisecond,
+		Logger:          log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if err := g.UpsertLink(&graph.Link{URL: "www.new.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := svc.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// created and crawled change of the old link are pruned
+	if _, err := g.Changes(context.Background(), 0, 0); !errors.Is(err, graph.ErrChangesPruned) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrChangesPruned)
+	}
+	changes, err := g.Changes(context.Background(), 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(changes), 1)
+	}
+}
+
+func Test_config_nothing_enabled(t *testing.T) {
+	if _, err := New(Config{GraphAPI: memory.New()}); err == nil {
+		t.Fatal("expect error when neither task is enabled")
+	}
+}
@@ -0,0 +1,31 @@
+package graph
+
+import "context"
+
+// Batch is the graph writes of a batch of crawled pages
+type Batch struct {
+	// upserted first, the stored link is copied back into them
+	Links []*Link
+
+	// edges between the links above, created after the links got their ID
+	Edges []*BatchEdge
+
+	// out edges of these links that not upserted by this batch are removed,
+	// the cutoff is taken from the store clock
+	StaleSources []*Link
+
+	// recorded into host statistic, ignored by graph that not keep it
+	Fetches []*HostFetch
+}
+
+// BatchEdge is edge whose links may not be stored yet
+type BatchEdge struct {
+	Src *Link
+	Dst *Link
+}
+
+// BatchGraph is implemented by graph that can apply a Batch atomically,
+// none of the writes is visible if one of them fail.
+type BatchGraph interface {
+	UpdateBatchContext(ctx context.Context, batch *Batch) error
+}
@@ -0,0 +1,134 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeKind tell what happened to the graph
+type ChangeKind string
+
+const (
+	// new link inserted
+	ChangeLinkCreated ChangeKind = "link_created"
+
+	// RetrievedAt of the link moved forward
+	ChangeLinkCrawled ChangeKind = "link_crawled"
+
+	// edge inserted or removed, refreshed UpdateAt of exist edge is not a change
+	ChangeEdgeChanged ChangeKind = "edge_changed"
+)
+
+// Change is an entry of the change log
+type Change struct {
+	// position in the log, increase with every recorded change
+	Cursor uint64
+
+	Kind ChangeKind
+
+	// the link of link change, the source of edge change
+	LinkID uuid.UUID
+
+	// only set for edge change
+	EdgeID uuid.UUID
+
+	// timestamp when the change recorded
+	At time.Time
+}
+
+// ChangeFeed is implemented by graph that keep append-only log of its changes,
+// so other services can react to new links instead of polling Links.
+// changes are recorded in the same write as the data they describe.
+type ChangeFeed interface {
+	// LastChange return the cursor of the latest change, 0 if nothing recorded yet
+	LastChange(ctx context.Context) (uint64, error)
+
+	// Changes return at most limit changes recorded after the cursor,
+	// in ascending cursor order.
+	// it return ErrChangesPruned when changes after the cursor already pruned.
+	Changes(ctx context.Context, after uint64, limit int) ([]*Change, error)
+
+	// WaitChange block until there is change recorded after the cursor or ctx is done.
+	WaitChange(ctx context.Context, after uint64) error
+
+	// PruneChanges remove changes recorded before the given time and return the number of removed changes,
+	// LastChange never move backward after prune.
+	PruneChanges(ctx context.Context, before time.Time) (int, error)
+}
+
+type ChangeIterator interface {
+	Iterator
+
+	// return currently fetched Change
+	Change() *Change
+}
+
+// number of changes read at once by the subscription
+const changeBatchSize = 256
+
+// Subscribe return iterator of every change recorded after the cursor,
+// Next block until the next change is recorded and return false when ctx is done.
+// consumer keep the cursor of the last handled change to subscribe again from it.
+func Subscribe(ctx context.Context, feed ChangeFeed, after uint64) ChangeIterator {
+	return &subscription{ctx: ctx, feed: feed, after: after}
+}
+
+type subscription struct {
+	ctx   context.Context
+	feed  ChangeFeed
+	after uint64
+
+	list []*Change
+	cur  *Change
+	err  error
+}
+
+// Next implements ChangeIterator.
+func (s *subscription) Next() bool {
+	for len(s.list) == 0 {
+		if s.err != nil {
+			return false
+		}
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			return false
+		}
+
+		list, err := s.feed.Changes(s.ctx, s.after, changeBatchSize)
+		if err != nil {
+			s.err = err
+			return false
+		}
+		if len(list) > 0 {
+			s.list = list
+			break
+		}
+
+		if err := s.feed.WaitChange(s.ctx, s.after); err != nil {
+			s.err = err
+			return false
+		}
+	}
+
+	s.cur, s.list = s.list[0], s.list[1:]
+	s.after = s.cur.Cursor
+	return true
+}
+
+// Change implements ChangeIterator.
+func (s *subscription) Change() *Change {
+	return s.cur
+}
+
+// Error implements ChangeIterator.
+func (s *subscription) Error() error {
+	return s.err
+}
+
+// Close implements ChangeIterator.
+func (s *subscription) Close() error {
+	s.list = nil
+	return nil
+}
@@ -0,0 +1,188 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContextGraph is Graph whose methods accept context,
+// store that talk to a database cancel the running query when ctx is done.
+// iterator returned by it stop when ctx is done.
+type ContextGraph interface {
+	UpsertLinkContext(ctx context.Context, link *Link) error
+	UpsertLinksContext(ctx context.Context, links []*Link) error
+	LookupLinkContext(ctx context.Context, id uuid.UUID) (*Link, error)
+	LookupLinkByURLContext(ctx context.Context, url string) (*Link, error)
+	RemoveLinkContext(ctx context.Context, id uuid.UUID) error
+	RemoveOrphanLinksContext(ctx context.Context, createdBefore time.Time) (int, error)
+	LinksContext(ctx context.Context, fromID, toID uuid.UUID, filter LinkFilter) (LinkIterator, error)
+	UpsertEdgeContext(ctx context.Context, edge *Edge) error
+	UpsertEdgesContext(ctx context.Context, edges []*Edge) error
+	EdgesContext(ctx context.Context, fromID, toID uuid.UUID, updateBefore time.Time) (EdgeIterator, error)
+	InEdgesContext(ctx context.Context, dst uuid.UUID) (EdgeIterator, error)
+	OutEdgesContext(ctx context.Context, src uuid.UUID) (EdgeIterator, error)
+	DegreeContext(ctx context.Context, id uuid.UUID) (in, out int, err error)
+	RemoveStaleEdgesContext(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error
+}
+
+// WithContext return g as ContextGraph, graph that not accept context
+// is wrapped so the call fail early when ctx is done but can not be cancelled once started.
+func WithContext(g Graph) ContextGraph {
+	if cg, ok := g.(ContextGraph); ok {
+		return cg
+	}
+	return contextAdapter{g: g}
+}
+
+type contextAdapter struct {
+	g Graph
+}
+
+func (a contextAdapter) UpsertLinkContext(ctx context.Context, link *Link) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.g.UpsertLink(link)
+}
+
+func (a contextAdapter) UpsertLinksContext(ctx context.Context, links []*Link) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.g.UpsertLinks(links)
+}
+
+func (a contextAdapter) LookupLinkContext(ctx context.Context, id uuid.UUID) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.g.LookupLink(id)
+}
+
+func (a contextAdapter) LookupLinkByURLContext(ctx context.Context, url string) (*Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.g.LookupLinkByURL(url)
+}
+
+func (a contextAdapter) RemoveLinkContext(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.g.RemoveLink(id)
+}
+
+func (a contextAdapter) RemoveOrphanLinksContext(ctx context.Context, createdBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.g.RemoveOrphanLinks(createdBefore)
+}
+
+func (a contextAdapter) LinksContext(ctx context.Context, fromID, toID uuid.UUID, filter LinkFilter) (LinkIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	it, err := a.g.Links(fromID, toID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &linkContextIterator{ctx: ctx, LinkIterator: it}, nil
+}
+
+func (a contextAdapter) UpsertEdgeContext(ctx context.Context, edge *Edge) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.g.UpsertEdge(edge)
+}
+
+func (a contextAdapter) UpsertEdgesContext(ctx context.Context, edges []*Edge) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.g.UpsertEdges(edges)
+}
+
+func (a contextAdapter) EdgesContext(ctx context.Context, fromID, toID uuid.UUID, updateBefore time.Time) (EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	it, err := a.g.Edges(fromID, toID, updateBefore)
+	if err != nil {
+		return nil, err
+	}
+	return &edgeContextIterator{ctx: ctx, EdgeIterator: it}, nil
+}
+
+func (a contextAdapter) InEdgesContext(ctx context.Context, dst uuid.UUID) (EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.g.InEdges(dst)
+}
+
+func (a contextAdapter) OutEdgesContext(ctx context.Context, src uuid.UUID) (EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.g.OutEdges(src)
+}
+
+func (a contextAdapter) DegreeContext(ctx context.Context, id uuid.UUID) (int, int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+	return a.g.Degree(id)
+}
+
+func (a contextAdapter) RemoveStaleEdgesContext(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.g.RemoveStaleEdges(fromID, updatedBefore)
+}
+
+// stop the iteration when ctx is done, the error is latched
+type linkContextIterator struct {
+	LinkIterator
+	ctx context.Context
+	err error
+}
+
+func (it *linkContextIterator) Next() bool {
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	return it.LinkIterator.Next()
+}
+
+func (it *linkContextIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.LinkIterator.Error()
+}
+
+type edgeContextIterator struct {
+	EdgeIterator
+	ctx context.Context
+	err error
+}
+
+func (it *edgeContextIterator) Next() bool {
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	return it.EdgeIterator.Next()
+}
+
+func (it *edgeContextIterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.EdgeIterator.Error()
+}
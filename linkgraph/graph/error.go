@@ -4,3 +4,7 @@ import "fmt"
 
 var ErrNotFound = fmt.Errorf("not found")
 var ErrUnknownEdgeLinks = fmt.Errorf("unknown edges's link src or dst")
+
+// ErrChangesPruned returned by ChangeFeed when the cursor fall behind the retention of the change log,
+// consumer should resync from LastChange.
+var ErrChangesPruned = fmt.Errorf("changes after cursor already pruned")
@@ -1,11 +1,22 @@
 package graph
 
 import (
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Source tell how the link was discovered
+type Source string
+
+const (
+	SourceUnknown Source = ""
+	SourceUser    Source = "user"
+	SourceSitemap Source = "sitemap"
+	SourceCrawl   Source = "crawl"
+)
+
 // Link represent the set of web pages that have been processed or discovered
 // by the crawler component
 type Link struct {
@@ -17,6 +28,106 @@ type Link struct {
 
 	// timestamp when link retrieved after processed
 	RetrievedAt time.Time `db:"retrieved_at"`
+
+	// hostname of URL, filled from URL by the store if empty
+	Host string `db:"host"`
+
+	// number of hops from the link submitted by user,
+	// the smallest depth is kept when the same link discovered again
+	Depth int `db:"depth"`
+
+	// HTTP status code and content type of the last fetch
+	StatusCode  int    `db:"status_code"`
+	ContentType string `db:"content_type"`
+
+	// hash of the last fetched content
+	ContentHash string `db:"content_hash"`
+
+	// how the link first discovered, not changed by later upsert
+	Source Source `db:"source"`
+
+	// timestamp when the link first inserted, set by the store
+	FirstSeenAt time.Time `db:"first_seen_at"`
+
+	// timestamp when the link should be crawled again
+	NextCrawlAt time.Time `db:"next_crawl_at"`
+}
+
+// HostOf return hostname of rawURL, empty if it cannot be parsed
+func HostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// MergeLink update exist with the metadata of link when the same URL upserted again.
+// empty value never overwrite the stored one, the smallest depth and
+// the first discovery source are kept.
+// the stores follow this rule so the upsert can be repeated safely.
+func MergeLink(exist, link *Link) {
+	if link.RetrievedAt.After(exist.RetrievedAt) {
+		exist.RetrievedAt = link.RetrievedAt
+	}
+	if link.Host != "" {
+		exist.Host = link.Host
+	}
+	if link.Depth < exist.Depth {
+		exist.Depth = link.Depth
+	}
+	if link.StatusCode != 0 {
+		exist.StatusCode = link.StatusCode
+	}
+	if link.ContentType != "" {
+		exist.ContentType = link.ContentType
+	}
+	if link.ContentHash != "" {
+		exist.ContentHash = link.ContentHash
+	}
+	if exist.Source == SourceUnknown {
+		exist.Source = link.Source
+	}
+	if !link.NextCrawlAt.IsZero() {
+		exist.NextCrawlAt = link.NextCrawlAt
+	}
+}
+
+// LinkFilter narrow the links returned by Graph.Links,
+// zero value field is not used as filter.
+type LinkFilter struct {
+	// links retrieved before the timestamp, never retrieved link included
+	RetrievedBefore time.Time
+
+	// links that due to be crawled before the timestamp
+	NextCrawlBefore time.Time
+
+	Host        string
+	MaxDepth    int
+	StatusCode  int
+	ContentType string
+	Source      Source
+}
+
+// Match report whether link pass the filter
+func (f LinkFilter) Match(link *Link) bool {
+	switch {
+	case !f.RetrievedBefore.IsZero() && !link.RetrievedAt.Before(f.RetrievedBefore):
+		return false
+	case !f.NextCrawlBefore.IsZero() && !link.NextCrawlAt.Before(f.NextCrawlBefore):
+		return false
+	case f.Host != "" && link.Host != f.Host:
+		return false
+	case f.MaxDepth > 0 && link.Depth > f.MaxDepth:
+		return false
+	case f.StatusCode != 0 && link.StatusCode != f.StatusCode:
+		return false
+	case f.ContentType != "" && link.ContentType != f.ContentType:
+		return false
+	case f.Source != SourceUnknown && link.Source != f.Source:
+		return false
+	}
+	return true
 }
 
 // Edge represents a uni-directional connection between two links in the graph.
@@ -45,23 +156,54 @@ type Edge struct {
 // because Graph is an abstract data type , so it make sense to defined upfront,
 
 type Graph interface {
-	//
+	// UpsertLink insert the link or merge it into existing link with the same URL.
+	// the ID of new link is kept if it is set and not taken by other link.
 	UpsertLink(link *Link) error
 
+	// UpsertLinks is the bulk version of UpsertLink,
+	// all links will be inserted or updated as one unit of write.
+	UpsertLinks(links []*Link) error
+
 	//
 	LookupLink(id uuid.UUID) (*Link, error)
 
-	//return link iterator to iterate link in graph
-	Links(fromID, toID uuid.UUID, retrieveBefore time.Time) (LinkIterator, error)
+	// LookupLinkByURL return the link with the url, ErrNotFound if there is none
+	LookupLinkByURL(url string) (*Link, error)
+
+	// RemoveLink removes the link and every edge originate from or end up at it.
+	RemoveLink(id uuid.UUID) error
+
+	// RemoveOrphanLinks removes links that never been retrieved, have no inbound edge
+	// and were created before the specified timestamp. it return number of removed links.
+	RemoveOrphanLinks(createdBefore time.Time) (int, error)
+
+	//return link iterator to iterate link in graph that match the filter,
+	// links are returned in ascending ID order
+	Links(fromID, toID uuid.UUID, filter LinkFilter) (LinkIterator, error)
 
 	// insert the new edge, the updated scenario will occure
 	// if crawler will discovered another link from edge destination it will need updated
 	UpsertEdge(edge *Edge) error
 
+	// UpsertEdges is the bulk version of UpsertEdge,
+	// all edges will be inserted or updated as one unit of write.
+	UpsertEdges(edges []*Edge) error
+
 	// LookupEdge(id uuid.UUID) (*Edge, error)
 
+	// return edge iterator of edges whose source belong to the range,
+	// edges are returned in ascending source ID order
 	Edges(fromID, toID uuid.UUID, updateBefore time.Time) (EdgeIterator, error)
 
+	// InEdges return edges that end up at the link (backlinks)
+	InEdges(dst uuid.UUID) (EdgeIterator, error)
+
+	// OutEdges return edges that originate from the link (outlinks)
+	OutEdges(src uuid.UUID) (EdgeIterator, error)
+
+	// Degree return the number of edges end up at and originate from the link
+	Degree(id uuid.UUID) (in, out int, err error)
+
 	// RemoveStaleEdges removes any edge that originates from the specified
 	// link ID and was updated before the specified timestamp.
 	RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error
@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"context"
+	"time"
+)
+
+// Host aggregate the links that share the same hostname
+type Host struct {
+	Name string
+
+	// number of links of the host, and the ones that have been retrieved
+	KnownPages   int
+	CrawledPages int
+
+	// fetch statistic recorded by crawler
+	Fetches           int
+	FetchErrors       int
+	TotalResponseTime time.Duration
+	LastCrawlAt       time.Time
+
+	// status of the robots.txt of the host the last time it checked
+	RobotsStatus RobotsStatus
+
+	// number of edges from link of the host to link of other host, and vice versa
+	OutEdges int
+	InEdges  int
+}
+
+// ErrorRate return the ratio of failed fetches
+func (h *Host) ErrorRate() float64 {
+	if h.Fetches == 0 {
+		return 0
+	}
+	return float64(h.FetchErrors) / float64(h.Fetches)
+}
+
+// AvgResponseTime return the average response time of fetches
+func (h *Host) AvgResponseTime() time.Duration {
+	if h.Fetches == 0 {
+		return 0
+	}
+	return h.TotalResponseTime / time.Duration(h.Fetches)
+}
+
+// RobotsStatus tell whether the host serve robots.txt
+type RobotsStatus string
+
+const (
+	// never checked
+	RobotsUnknown RobotsStatus = ""
+
+	// robots.txt is served
+	RobotsFound RobotsStatus = "found"
+
+	// robots.txt not exist (4xx), everything is allowed
+	RobotsMissing RobotsStatus = "missing"
+
+	// robots.txt request failed or server error (5xx)
+	RobotsError RobotsStatus = "error"
+)
+
+// HostFetch is the result of single fetch to the host
+type HostFetch struct {
+	Host string
+
+	// zero if request not get any response
+	StatusCode int
+
+	ResponseTime time.Duration
+	FetchedAt    time.Time
+
+	// set when the robots.txt of the host checked with the fetch,
+	// RobotsUnknown keep the recorded status
+	RobotsStatus RobotsStatus
+}
+
+// Failed report whether the fetch count as error
+func (f *HostFetch) Failed() bool {
+	return f.StatusCode == 0 || f.StatusCode >= 400
+}
+
+// HostGraph is implemented by graph that keep host statistic.
+// links count and inter-host edges are derived from the graph, store that recount them
+// periodically may report them behind the graph.
+// the fetch statistic is added incrementally by RecordFetches.
+type HostGraph interface {
+	// RecordFetches add the fetches into statistic of their host,
+	// the robots status is replaced by the last fetch that has one
+	RecordFetches(fetches []*HostFetch) error
+
+	// LookupHost return the host, ErrNotFound if there is no link or statistic of it
+	LookupHost(name string) (*Host, error)
+
+	// Hosts return hosts that have fetch statistic, ordered by name
+	Hosts(offset, limit int) ([]*Host, error)
+}
+
+// ContextHostGraph is HostGraph whose methods accept context
+type ContextHostGraph interface {
+	RecordFetchesContext(ctx context.Context, fetches []*HostFetch) error
+	LookupHostContext(ctx context.Context, name string) (*Host, error)
+	HostsContext(ctx context.Context, offset, limit int) ([]*Host, error)
+}
+
+// HostsWithContext return h as ContextHostGraph, it is adapted like WithContext
+func HostsWithContext(h HostGraph) ContextHostGraph {
+	if ch, ok := h.(ContextHostGraph); ok {
+		return ch
+	}
+	return hostContextAdapter{h: h}
+}
+
+type hostContextAdapter struct {
+	h HostGraph
+}
+
+func (a hostContextAdapter) RecordFetchesContext(ctx context.Context, fetches []*HostFetch) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.h.RecordFetches(fetches)
+}
+
+func (a hostContextAdapter) LookupHostContext(ctx context.Context, name string) (*Host, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.h.LookupHost(name)
+}
+
+func (a hostContextAdapter) HostsContext(ctx context.Context, offset, limit int) ([]*Host, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.h.Hosts(offset, limit)
+}
@@ -0,0 +1,224 @@
+package graphtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+func changeFeed(t *testing.T, g graph.Graph) graph.ChangeFeed {
+	feed, ok := g.(graph.ChangeFeed)
+	if !ok {
+		t.Skip("graph does not record changes")
+	}
+	return feed
+}
+
+func testChanges(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		feed := changeFeed(t, g)
+		ctx := context.Background()
+
+		start, err := feed.LastChange(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		src := &graph.Link{URL: "https://example.com"}
+		dst := &graph.Link{URL: "https://example.com/about"}
+		for _, link := range []*graph.Link{src, dst} {
+			if err := g.UpsertLink(link); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// crawled, the second upsert has no newer retrieved time
+		if err := g.UpsertLink(&graph.Link{URL: src.URL, RetrievedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.UpsertLink(&graph.Link{URL: dst.URL}); err != nil {
+			t.Fatal(err)
+		}
+
+		// refreshed edge is not a change
+		edge := &graph.Edge{Src: src.ID, Dst: dst.ID}
+		for i := 0; i < 2; i++ {
+			if err := g.UpsertEdge(edge); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := g.RemoveStaleEdges(src.ID, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		type entry struct {
+			kind   graph.ChangeKind
+			linkID uuid.UUID
+			edgeID uuid.UUID
+		}
+		expect := []entry{
+			{graph.ChangeLinkCreated, src.ID, uuid.Nil},
+			{graph.ChangeLinkCreated, dst.ID, uuid.Nil},
+			{graph.ChangeLinkCrawled, src.ID, uuid.Nil},
+			{graph.ChangeEdgeChanged, src.ID, edge.ID},
+			{graph.ChangeEdgeChanged, src.ID, edge.ID},
+		}
+
+		changes, err := feed.Changes(ctx, start, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != len(expect) {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(changes), len(expect))
+		}
+		cursor := start
+		for i, change := range changes {
+			got := entry{change.Kind, change.LinkID, change.EdgeID}
+			if got != expect[i] {
+				t.Fatalf("change %d\ngot:%v\nexpect:%v", i, got, expect[i])
+			}
+			if change.Cursor <= cursor {
+				t.Fatalf("cursor not increase\ngot:%v\nafter:%v", change.Cursor, cursor)
+			}
+			cursor = change.Cursor
+		}
+
+		last, err := feed.LastChange(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last != cursor {
+			t.Fatalf("\ngot:%v\nexpect:%v", last, cursor)
+		}
+
+		// resume from the cursor with limit
+		page, err := feed.Changes(ctx, changes[1].Cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 2 || page[0].Cursor != changes[2].Cursor || page[1].Cursor != changes[3].Cursor {
+			t.Fatalf("\ngot:%v\nexpect:%v", page, changes[2:4])
+		}
+	}
+}
+
+func testSubscribe(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		feed := changeFeed(t, g)
+
+		last, err := feed.LastChange(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// nothing recorded yet
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := feed.WaitChange(ctx, last); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, context.DeadlineExceeded)
+		}
+
+		ctx, cancel = context.WithCancel(context.Background())
+		defer cancel()
+		it := graph.Subscribe(ctx, feed, last)
+		defer it.Close()
+
+		upserted := make(chan *graph.Link, 1)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			link := &graph.Link{URL: "https://example.com"}
+			if err := g.UpsertLink(link); err != nil {
+				t.Error(err)
+			}
+			upserted <- link
+		}()
+
+		if !it.Next() {
+			t.Fatalf("expect change, err: %v", it.Error())
+		}
+		link := <-upserted
+		if change := it.Change(); change.Kind != graph.ChangeLinkCreated || change.LinkID != link.ID {
+			t.Fatalf("\ngot:%v %v\nexpect:%v %v", change.Kind, change.LinkID, graph.ChangeLinkCreated, link.ID)
+		}
+
+		cancel()
+		if it.Next() {
+			t.Fatalf("expect subscription stop after cancelled")
+		}
+		if err := it.Error(); !errors.Is(err, context.Canceled) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, context.Canceled)
+		}
+	}
+}
+
+func testPruneChanges(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		feed := changeFeed(t, g)
+		ctx := context.Background()
+
+		start, err := feed.LastChange(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, url := range []string{"https://example.com", "https://example.com/about"} {
+			if err := g.UpsertLink(&graph.Link{URL: url}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		before := time.Now()
+		time.Sleep(10 * time.Millisecond)
+
+		kept := &graph.Link{URL: "https://example.com/contact"}
+		if err := g.UpsertLink(kept); err != nil {
+			t.Fatal(err)
+		}
+		last, err := feed.LastChange(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := feed.PruneChanges(ctx, before)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n < 2 {
+			t.Fatalf("\ngot:%v\nexpect:%v", n, 2)
+		}
+
+		// cursor before the pruned change must resync
+		if _, err := feed.Changes(ctx, start, 0); !errors.Is(err, graph.ErrChangesPruned) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrChangesPruned)
+		}
+		changes, err := feed.Changes(ctx, last-1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 1 || changes[0].LinkID != kept.ID {
+			t.Fatalf("\ngot:%v\nexpect:%v", changes, kept.ID)
+		}
+
+		// the cursor never move backward, even when every change is pruned
+		if _, err := feed.PruneChanges(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := feed.LastChange(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != last {
+			t.Fatalf("\ngot:%v\nexpect:%v", got, last)
+		}
+		changes, err = feed.Changes(ctx, last, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 0 {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(changes), 0)
+		}
+	}
+}
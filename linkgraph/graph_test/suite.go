@@ -0,0 +1,440 @@
+// Package graphtest is the shared test suite of graph.Graph implementations.
+package graphtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/internal/xuuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+// Run execute every test of the suite against graph returned by newGraph,
+// it is called for every test and must return an empty graph.
+func Run(t *testing.T, newGraph func(t *testing.T) graph.Graph) {
+	tests := []struct {
+		name string
+		fn   func(g graph.Graph) func(t *testing.T)
+	}{
+		{"UpsertLink", testUpsertLink},
+		{"UpsertLink keep ID", testUpsertLinkKeepID},
+		{"LookupLink", testLookupLink},
+		{"Links", testLinks},
+		{"UpsertEdge", testUpsertEdge},
+		{"Edges", testEdges},
+		{"RemoveStaleEdges", testRemoveStaleEdges},
+		{"InEdges OutEdges", testNeighbourEdges},
+		{"RemoveLink", testRemoveLink},
+
+		// skipped if the graph is not graph.ChangeFeed
+		{"Changes", testChanges},
+		{"Subscribe", testSubscribe},
+		{"PruneChanges", testPruneChanges},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(newGraph(t))(t)
+		})
+	}
+}
+
+func assertErr(err error, msg string) func(t *testing.T) {
+	return func(t *testing.T) {
+		if err != nil {
+			t.Error(err, msg)
+		}
+	}
+}
+
+// test UpsertLink method
+func testUpsertLink(InMemory graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		// Create a new link
+		original := &graph.Link{
+			URL:         "https://example.com",
+			RetrievedAt: time.Now().Add(-10 * time.Hour),
+		}
+
+		err := InMemory.UpsertLink(original)
+		if err != nil {
+			t.Error(err)
+		}
+		//=============================
+		// Update existing link with a newer timestamp and different URL
+		accessedAt := time.Now().Truncate(time.Second).UTC()
+		existing := &graph.Link{
+			ID:          original.ID,
+			URL:         "https://example.com",
+			RetrievedAt: accessedAt,
+		}
+
+		err = InMemory.UpsertLink(existing)
+		assertErr(err, "")(t)
+
+		if existing.ID != original.ID {
+			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", existing.ID, original.ID,
+				"value of ID should same")
+		}
+		stored, err := InMemory.LookupLink(existing.ID)
+		assertErr(err, "")(t)
+		if stored.RetrievedAt != accessedAt {
+			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", stored.RetrievedAt, accessedAt,
+				"last accessed timestamp was not updated")
+		}
+		//=============================
+		// Attempt to insert a new link whose URL matches an existing link with
+		// and provide an older accessedAt value
+		sameURL := &graph.Link{
+			URL:         existing.URL,
+			RetrievedAt: time.Now().Add(-10 * time.Hour).UTC(),
+		}
+		err = InMemory.UpsertLink(sameURL)
+		assertErr(err, "")(t)
+
+		if existing.ID != sameURL.ID {
+			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", existing.ID, sameURL.ID,
+				"value of ID should same")
+		}
+
+		stored, err = InMemory.LookupLink(existing.ID)
+
+		assertErr(err, "")(t)
+		if stored.RetrievedAt != accessedAt {
+			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", stored.RetrievedAt, accessedAt,
+				"last accessed timestamp was overwritten with an older value")
+		}
+
+		//=============================
+		// Create a new link and then attempt to update its URL to the same as
+		// an existing link.
+		dup := &graph.Link{
+			URL: "foo",
+		}
+		err = InMemory.UpsertLink(dup)
+		assertErr(err, "")
+		// c.Assert(dup.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to the new link"))
+		if dup.ID == uuid.Nil {
+			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", dup.ID, uuid.Nil,
+				"last accessed timestamp was overwritten with an older value")
+		}
+	}
+}
+
+// ID of new link kept if it is not taken
+func testUpsertLinkKeepID(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		id := uuid.New()
+		link := &graph.Link{ID: id, URL: "https://example.com"}
+		if err := g.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+		if link.ID != id {
+			t.Fatalf("\ngot:%v\nexpect:%v", link.ID, id)
+		}
+
+		taken := &graph.Link{ID: id, URL: "https://other.com"}
+		if err := g.UpsertLink(taken); err != nil {
+			t.Fatal(err)
+		}
+		if taken.ID == id || taken.ID == uuid.Nil {
+			t.Fatalf("\ngot:%v\nexpect:new ID", taken.ID)
+		}
+	}
+}
+
+func testLookupLink(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		link := &graph.Link{URL: "https://example.com", RetrievedAt: time.Now().Truncate(time.Second).UTC()}
+		if err := g.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+
+		byID, err := g.LookupLink(link.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byURL, err := g.LookupLinkByURL(link.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stored := range []*graph.Link{byID, byURL} {
+			if stored.ID != link.ID || stored.URL != link.URL || !stored.RetrievedAt.Equal(link.RetrievedAt) {
+				t.Fatalf("\ngot:%+v\nexpect:%+v", stored, link)
+			}
+		}
+
+		// returned link is a copy
+		byID.URL = "changed"
+		if stored, _ := g.LookupLink(link.ID); stored.URL != link.URL {
+			t.Fatalf("\ngot:%v\nexpect:%v", stored.URL, link.URL)
+		}
+
+		if _, err := g.LookupLink(uuid.New()); !errors.Is(err, graph.ErrNotFound) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+		}
+		if _, err := g.LookupLinkByURL("https://unknown.com"); !errors.Is(err, graph.ErrNotFound) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+		}
+	}
+}
+
+// insert n links, the returned links sorted by ID
+func upsertLinks(t *testing.T, g graph.Graph, n int, retrievedAt time.Time) []*graph.Link {
+	links := make([]*graph.Link, n)
+	for i := range links {
+		links[i] = &graph.Link{URL: fmt.Sprintf("https://example%d.com", i), RetrievedAt: retrievedAt}
+	}
+	if err := g.UpsertLinks(links); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID.String() < links[j].ID.String() })
+	return links
+}
+
+// collector drain iterator returned by graph method
+type collector struct {
+	t *testing.T
+}
+
+func (c collector) links(it graph.LinkIterator, err error) []*graph.Link {
+	t := c.t
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var res []*graph.Link
+	for it.Next() {
+		res = append(res, it.Link())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func (c collector) edges(it graph.EdgeIterator, err error) []*graph.Edge {
+	t := c.t
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var res []*graph.Edge
+	for it.Next() {
+		res = append(res, it.Edge())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func testLinks(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		c := collector{t}
+		retrievedAt := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+		links := upsertLinks(t, g, 5, retrievedAt)
+
+		// ascending ID order
+		all := c.links(g.Links(xuuid.MIN, xuuid.MAX, graph.LinkFilter{}))
+		if len(all) != len(links) {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(all), len(links))
+		}
+		for i, link := range all {
+			if link.ID != links[i].ID {
+				t.Fatalf("\ngot:%v\nexpect:%v", link.ID, links[i].ID)
+			}
+		}
+
+		// from is inclusive, to is exclusive
+		part := c.links(g.Links(links[1].ID, links[3].ID, graph.LinkFilter{}))
+		if len(part) != 2 || part[0].ID != links[1].ID || part[1].ID != links[2].ID {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(part), 2)
+		}
+
+		// retrieved after the filter
+		filtered := c.links(g.Links(xuuid.MIN, xuuid.MAX, graph.LinkFilter{RetrievedBefore: retrievedAt}))
+		if len(filtered) != 0 {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(filtered), 0)
+		}
+	}
+}
+
+func testUpsertEdge(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		links := upsertLinks(t, g, 2, time.Time{})
+
+		edge := &graph.Edge{Src: links[0].ID, Dst: links[1].ID}
+		if err := g.UpsertEdge(edge); err != nil {
+			t.Fatal(err)
+		}
+		if edge.ID == uuid.Nil || edge.UpdateAt.IsZero() {
+			t.Fatalf("\ngot:%+v\nexpect:ID and update time assigned", edge)
+		}
+
+		// same src and dst update the edge
+		again := &graph.Edge{Src: links[0].ID, Dst: links[1].ID}
+		if err := g.UpsertEdge(again); err != nil {
+			t.Fatal(err)
+		}
+		if again.ID != edge.ID {
+			t.Fatalf("\ngot:%v\nexpect:%v", again.ID, edge.ID)
+		}
+		if again.UpdateAt.Before(edge.UpdateAt) {
+			t.Fatalf("\ngot:%v\nexpect:not before %v", again.UpdateAt, edge.UpdateAt)
+		}
+
+		unknown := &graph.Edge{Src: links[0].ID, Dst: uuid.New()}
+		if err := g.UpsertEdge(unknown); !errors.Is(err, graph.ErrUnknownEdgeLinks) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrUnknownEdgeLinks)
+		}
+	}
+}
+
+// link i point to every link after it
+func upsertEdges(t *testing.T, g graph.Graph, links []*graph.Link) []*graph.Edge {
+	var edges []*graph.Edge
+	for i := range links {
+		for j := i + 1; j < len(links); j++ {
+			edges = append(edges, &graph.Edge{Src: links[i].ID, Dst: links[j].ID})
+		}
+	}
+	if err := g.UpsertEdges(edges); err != nil {
+		t.Fatal(err)
+	}
+	return edges
+}
+
+func testEdges(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		c := collector{t}
+		links := upsertLinks(t, g, 4, time.Time{})
+		edges := upsertEdges(t, g, links)
+
+		all := c.edges(g.Edges(xuuid.MIN, xuuid.MAX, time.Now().Add(time.Minute)))
+		if len(all) != len(edges) {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(all), len(edges))
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i].Src.String() < all[i-1].Src.String() {
+				t.Fatalf("edges not in ascending source order: %v after %v", all[i].Src, all[i-1].Src)
+			}
+		}
+
+		// range select by source
+		part := c.edges(g.Edges(links[1].ID, links[2].ID, time.Now().Add(time.Minute)))
+		if len(part) != 2 {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(part), 2)
+		}
+		for _, edge := range part {
+			if edge.Src != links[1].ID {
+				t.Fatalf("\ngot:%v\nexpect:%v", edge.Src, links[1].ID)
+			}
+		}
+
+		// updated after the filter
+		old := c.edges(g.Edges(xuuid.MIN, xuuid.MAX, time.Now().Add(-time.Minute)))
+		if len(old) != 0 {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(old), 0)
+		}
+	}
+}
+
+func testRemoveStaleEdges(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		c := collector{t}
+		links := upsertLinks(t, g, 3, time.Time{})
+		upsertEdges(t, g, links)
+
+		// refresh one edge of links[0] after the cut
+		time.Sleep(10 * time.Millisecond)
+		cut := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		if err := g.UpsertEdge(&graph.Edge{Src: links[0].ID, Dst: links[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := g.RemoveStaleEdges(links[0].ID, cut); err != nil {
+			t.Fatal(err)
+		}
+		out := c.edges(g.OutEdges(links[0].ID))
+		if len(out) != 1 || out[0].Dst != links[1].ID {
+			t.Fatalf("\ngot:%v\nexpect:%v", len(out), 1)
+		}
+
+		// edges of other source untouched
+		if _, n, _ := g.Degree(links[1].ID); n != 1 {
+			t.Fatalf("\ngot:%v\nexpect:%v", n, 1)
+		}
+		if n, _, _ := g.Degree(links[2].ID); n != 1 {
+			t.Fatalf("\ngot:%v\nexpect:%v", n, 1)
+		}
+	}
+}
+
+func testNeighbourEdges(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		c := collector{t}
+		links := upsertLinks(t, g, 3, time.Time{})
+		upsertEdges(t, g, links)
+
+		for i, link := range links {
+			in, out, err := g.Degree(link.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if in != i || out != len(links)-1-i {
+				t.Fatalf("\ngot:%v/%v\nexpect:%v/%v", in, out, i, len(links)-1-i)
+			}
+
+			for _, edge := range c.edges(g.InEdges(link.ID)) {
+				if edge.Dst != link.ID {
+					t.Fatalf("\ngot:%v\nexpect:%v", edge.Dst, link.ID)
+				}
+			}
+			for _, edge := range c.edges(g.OutEdges(link.ID)) {
+				if edge.Src != link.ID {
+					t.Fatalf("\ngot:%v\nexpect:%v", edge.Src, link.ID)
+				}
+			}
+		}
+	}
+}
+
+func testRemoveLink(g graph.Graph) func(t *testing.T) {
+	return func(t *testing.T) {
+		links := upsertLinks(t, g, 3, time.Now())
+		upsertEdges(t, g, links)
+
+		orphan := &graph.Link{URL: "https://orphan.com"}
+		if err := g.UpsertLink(orphan); err != nil {
+			t.Fatal(err)
+		}
+
+		if n, err := g.RemoveOrphanLinks(time.Now().Add(time.Minute)); err != nil || n != 1 {
+			t.Fatalf("\ngot:%v %v\nexpect:%v", n, err, 1)
+		}
+		if _, err := g.LookupLink(orphan.ID); !errors.Is(err, graph.ErrNotFound) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+		}
+
+		// removal cascade to edges of both direction
+		if err := g.RemoveLink(links[1].ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, out, _ := g.Degree(links[0].ID); out != 1 {
+			t.Fatalf("\ngot:%v\nexpect:%v", out, 1)
+		}
+		if in, _, _ := g.Degree(links[2].ID); in != 1 {
+			t.Fatalf("\ngot:%v\nexpect:%v", in, 1)
+		}
+		if err := g.RemoveLink(links[1].ID); !errors.Is(err, graph.ErrNotFound) {
+			t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+		}
+	}
+}
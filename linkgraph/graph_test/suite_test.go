@@ -2,9 +2,7 @@ package graphtest
 
 import (
 	"testing"
-	"time"
 
-	"github.com/google/uuid"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 	"github.com/odit-bit/invoker/linkgraph/memory"
 )
@@ -71,93 +69,8 @@ import (
 // 	c.Assert(dup.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to the new link"))
 // }
 
-// Manual Testing
-
-func assertErr(err error, msg string) func(t *testing.T) {
-	return func(t *testing.T) {
-		if err != nil {
-			t.Error(err, msg)
-		}
-	}
-}
-
-// test UpsertLink method
-func testUpsertLink(InMemory graph.Graph) func(t *testing.T) {
-	return func(t *testing.T) {
-		// Create a new link
-		original := &graph.Link{
-			URL:         "https://example.com",
-			RetrievedAt: time.Now().Add(-10 * time.Hour),
-		}
-
-		err := InMemory.UpsertLink(original)
-		if err != nil {
-			t.Error(err)
-		}
-		//=============================
-		// Update existing link with a newer timestamp and different URL
-		accessedAt := time.Now().Truncate(time.Second).UTC()
-		existing := &graph.Link{
-			ID:          original.ID,
-			URL:         "https://example.com",
-			RetrievedAt: accessedAt,
-		}
-
-		err = InMemory.UpsertLink(existing)
-		assertErr(err, "")(t)
-
-		if existing.ID != original.ID {
-			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", existing.ID, original.ID,
-				"value of ID should same")
-		}
-		stored, err := InMemory.LookupLink(existing.ID)
-		assertErr(err, "")(t)
-		if stored.RetrievedAt != accessedAt {
-			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", stored.RetrievedAt, accessedAt,
-				"last accessed timestamp was not updated")
-		}
-		//=============================
-		// Attempt to insert a new link whose URL matches an existing link with
-		// and provide an older accessedAt value
-		sameURL := &graph.Link{
-			URL:         existing.URL,
-			RetrievedAt: time.Now().Add(-10 * time.Hour).UTC(),
-		}
-		err = InMemory.UpsertLink(sameURL)
-		assertErr(err, "")(t)
-
-		if existing.ID != sameURL.ID {
-			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", existing.ID, sameURL.ID,
-				"value of ID should same")
-		}
-
-		stored, err = InMemory.LookupLink(existing.ID)
-
-		assertErr(err, "")(t)
-		if stored.RetrievedAt != accessedAt {
-			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", stored.RetrievedAt, accessedAt,
-				"last accessed timestamp was overwritten with an older value")
-		}
-
-		//=============================
-		// Create a new link and then attempt to update its URL to the same as
-		// an existing link.
-		dup := &graph.Link{
-			URL: "foo",
-		}
-		err = InMemory.UpsertLink(dup)
-		assertErr(err, "")
-		// c.Assert(dup.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to the new link"))
-		if dup.ID == uuid.Nil {
-			t.Errorf("\ngot:\t %v, \nexpected:\t %v \nerror: %v", dup.ID, uuid.Nil,
-				"last accessed timestamp was overwritten with an older value")
-		}
-	}
-}
-
-func Test_UpsertLink(t *testing.T) {
-	inMem := memory.New()
-	t.Run("UpsertLink", testUpsertLink(inMem))
+func Test_memory(t *testing.T) {
+	Run(t, func(t *testing.T) graph.Graph { return memory.New() })
 }
 
 // // TestLinkIteratorTimeFilter verifies that the time-based filtering of the
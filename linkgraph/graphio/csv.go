@@ -0,0 +1,88 @@
+package graphio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+var csvHeader = []string{"src", "dst", "src_url", "dst_url", "updated_at"}
+
+type csvEncoder struct {
+	w        *csv.Writer
+	resolver *urlResolver
+}
+
+func newCSVEncoder(w io.Writer, resolver *urlResolver) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), resolver: resolver}
+}
+
+func (e *csvEncoder) begin() error {
+	return e.write(csvHeader)
+}
+
+func (e *csvEncoder) end() error { return nil }
+
+// edge list has no link record
+func (e *csvEncoder) link(*graph.Link) error { return nil }
+
+func (e *csvEncoder) edge(edge *graph.Edge) error {
+	srcURL, err := e.resolver.url(edge.Src)
+	if err != nil {
+		return err
+	}
+	dstURL, err := e.resolver.url(edge.Dst)
+	if err != nil {
+		return err
+	}
+	return e.write([]string{edge.Src.String(), edge.Dst.String(), srcURL, dstURL, formatTime(edge.UpdateAt)})
+}
+
+// csv.Writer buffer on it's own, flush so the bufio.Writer of exporter
+// has every row before progress is reported
+func (e *csvEncoder) write(row []string) error {
+	if err := e.w.Write(row); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type csvDecoder struct {
+	r *csv.Reader
+}
+
+func newCSVDecoder(r io.Reader) *csvDecoder {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return &csvDecoder{r: cr}
+}
+
+func (d *csvDecoder) next() (*record, error) {
+	for {
+		row, err := d.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		if row[0] == csvHeader[0] {
+			continue
+		}
+		if len(row) < 4 {
+			line, _ := d.r.FieldPos(0)
+			return nil, fmt.Errorf("line %d: expect at least 4 columns, got %d", line, len(row))
+		}
+
+		// id column may be empty when the list made by other tool
+		src, _ := uuid.Parse(row[0])
+		dst, _ := uuid.Parse(row[1])
+		return &record{
+			edge:   &graph.Edge{Src: src, Dst: dst},
+			srcURL: row[2],
+			dstURL: row[3],
+		}, nil
+	}
+}
@@ -0,0 +1,246 @@
+package graphio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/internal/xuuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+// number of records written between progress report
+const progressEvery = 1000
+
+// ExportOptions encapsulates the settings of an export
+type ExportOptions struct {
+	Format Format
+
+	// position returned by interrupted export, the output must be appended
+	// to the previous one. zero value start from the beginning.
+	From Position
+
+	// called with the current position every few records and when the export end,
+	// the written output has been flushed before it is called.
+	// records written after the position may be written again on resume,
+	// import dedupe them.
+	Progress func(Position) error
+}
+
+// writes records in one format
+type encoder interface {
+	// write the header, only called when export start from the beginning
+	begin() error
+	link(link *graph.Link) error
+	edge(edge *graph.Edge) error
+	// write the footer
+	end() error
+}
+
+func newEncoder(format Format, w io.Writer, g ExportGraph) (encoder, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLEncoder(w), nil
+	case FormatCSV:
+		return newCSVEncoder(w, newURLResolver(g)), nil
+	case FormatGraphML:
+		return newGraphMLEncoder(w), nil
+	}
+	return nil, fmt.Errorf("unknown graph format %q", format)
+}
+
+// Export write every link and edge of g into w, it return the last position
+// which is SectionDone if the export is complete.
+func Export(g ExportGraph, w io.Writer, opts ExportOptions) (Position, error) {
+	bw := bufio.NewWriter(w)
+	enc, err := newEncoder(opts.Format, bw, g)
+	if err != nil {
+		return opts.From, err
+	}
+
+	ex := exporter{
+		g:        g,
+		bw:       bw,
+		enc:      enc,
+		pos:      opts.From,
+		progress: opts.Progress,
+		startAt:  time.Now(),
+	}
+	err = ex.run(opts.Format)
+	return ex.pos, err
+}
+
+type exporter struct {
+	g        ExportGraph
+	bw       *bufio.Writer
+	enc      encoder
+	pos      Position
+	progress func(Position) error
+
+	// edges updated after the export start are not exported
+	startAt time.Time
+	written int
+}
+
+func (ex *exporter) run(format Format) error {
+	if ex.pos.Section == "" {
+		if err := ex.enc.begin(); err != nil {
+			return err
+		}
+		ex.pos = Position{Section: SectionEdges}
+		if format.hasLinks() {
+			ex.pos.Section = SectionLinks
+		}
+	}
+
+	if ex.pos.Section == SectionLinks {
+		if err := ex.links(); err != nil {
+			return err
+		}
+		ex.pos = Position{Section: SectionEdges}
+	}
+
+	if ex.pos.Section == SectionEdges {
+		if err := ex.edges(); err != nil {
+			return err
+		}
+		if err := ex.enc.end(); err != nil {
+			return err
+		}
+		ex.pos = Position{Section: SectionDone}
+	}
+
+	return ex.report()
+}
+
+// first id that not exported yet in current section, ok is false if nothing left
+func (ex *exporter) from() (uuid.UUID, bool) {
+	if ex.pos.After == uuid.Nil {
+		return xuuid.MIN, true
+	}
+	return xuuid.Next(ex.pos.After)
+}
+
+func (ex *exporter) links() error {
+	from, ok := ex.from()
+	if !ok {
+		return nil
+	}
+
+	it, err := ex.g.Links(from, xuuid.MAX, graph.LinkFilter{})
+	if err != nil {
+		return fmt.Errorf("export links: %v", err)
+	}
+	defer it.Close()
+
+	for it.Next() {
+		link := it.Link()
+		if link == nil {
+			break
+		}
+		if err := ex.enc.link(link); err != nil {
+			return err
+		}
+		ex.pos.After = link.ID
+		if err := ex.tick(); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("export links: %v", err)
+	}
+	return nil
+}
+
+func (ex *exporter) edges() error {
+	from, ok := ex.from()
+	if !ok {
+		return nil
+	}
+
+	it, err := ex.g.Edges(from, xuuid.MAX, ex.startAt)
+	if err != nil {
+		return fmt.Errorf("export edges: %v", err)
+	}
+	defer it.Close()
+
+	// edges arrive grouped by source, a source is complete when the next one start
+	var curSrc uuid.UUID
+	for it.Next() {
+		edge := it.Edge()
+		if edge == nil {
+			break
+		}
+		if edge.Src != curSrc && curSrc != uuid.Nil {
+			ex.pos.After = curSrc
+			if err := ex.tick(); err != nil {
+				return err
+			}
+		}
+		curSrc = edge.Src
+
+		if err := ex.enc.edge(edge); err != nil {
+			if errors.Is(err, graph.ErrNotFound) {
+				// end of the edge removed in the meantime
+				continue
+			}
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("export edges: %v", err)
+	}
+	return nil
+}
+
+// count written record and report every progressEvery records
+func (ex *exporter) tick() error {
+	ex.written++
+	if ex.written%progressEvery != 0 {
+		return nil
+	}
+	return ex.report()
+}
+
+func (ex *exporter) report() error {
+	if err := ex.bw.Flush(); err != nil {
+		return err
+	}
+	if ex.progress == nil {
+		return nil
+	}
+	return ex.progress(ex.pos)
+}
+
+// number of url kept by urlResolver
+const maxResolvedURL = 4096
+
+// urlResolver lookup url of link id, it keep recently resolved url
+// so source of many edges not looked up again.
+type urlResolver struct {
+	g     ExportGraph
+	cache map[uuid.UUID]string
+}
+
+func newURLResolver(g ExportGraph) *urlResolver {
+	return &urlResolver{g: g, cache: map[uuid.UUID]string{}}
+}
+
+func (r *urlResolver) url(id uuid.UUID) (string, error) {
+	if url, ok := r.cache[id]; ok {
+		return url, nil
+	}
+
+	link, err := r.g.LookupLink(id)
+	if err != nil {
+		return "", err
+	}
+	if len(r.cache) >= maxResolvedURL {
+		r.cache = map[uuid.UUID]string{}
+	}
+	r.cache[id] = link.URL
+	return link.URL, nil
+}
@@ -0,0 +1,95 @@
+// Package graphio export and import the link graph in JSONL, CSV edge list and GraphML.
+// both direction stream through the graph iterators and can be resumed.
+package graphio
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+// Format of exported graph
+type Format string
+
+const (
+	// one JSON object per line, every link followed by every edge
+	FormatJSONL Format = "jsonl"
+
+	// edge list with url of both end, links without edge are not exported
+	FormatCSV Format = "csv"
+
+	// GraphML document, link as node and edge as edge
+	FormatGraphML Format = "graphml"
+)
+
+// ParseFormat return format by it's name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatJSONL, FormatCSV, FormatGraphML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown graph format %q", name)
+}
+
+// whether the format contain link records
+func (f Format) hasLinks() bool {
+	return f != FormatCSV
+}
+
+// ExportGraph defines the graph methods needed to export it
+type ExportGraph interface {
+	Links(fromID, toID uuid.UUID, filter graph.LinkFilter) (graph.LinkIterator, error)
+	Edges(fromID, toID uuid.UUID, updateBefore time.Time) (graph.EdgeIterator, error)
+	LookupLink(id uuid.UUID) (*graph.Link, error)
+}
+
+// ImportGraph defines the graph methods needed to import into it
+type ImportGraph interface {
+	UpsertLink(link *graph.Link) error
+	UpsertEdge(edge *graph.Edge) error
+	LookupLink(id uuid.UUID) (*graph.Link, error)
+	LookupLinkByURL(url string) (*graph.Link, error)
+}
+
+// Section of the export
+type Section string
+
+const (
+	SectionLinks Section = "links"
+	SectionEdges Section = "edges"
+	SectionDone  Section = "done"
+)
+
+// Position of an export, zero value is the beginning.
+// every link (in links section) or every edge of source (in edges section)
+// up to After has been written.
+type Position struct {
+	Section Section   `json:"section"`
+	After   uuid.UUID `json:"after"`
+}
+
+// record is the decoded form of single line or element,
+// only one of link and edge is set.
+type record struct {
+	link *graph.Link
+	edge *graph.Edge
+
+	// url of edge ends, only set by format without link records
+	srcURL, dstURL string
+}
+
+const timeLayout = time.RFC3339Nano
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// empty string is zero time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(timeLayout, s)
+}
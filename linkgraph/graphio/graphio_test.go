@@ -0,0 +1,195 @@
+package graphio
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/internal/xuuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"github.com/odit-bit/invoker/linkgraph/memory"
+)
+
+// graph of 4 links, 3 edges and 1 link without edge
+func testGraph(t *testing.T) *memory.InMemory {
+	g := memory.New()
+	links := make([]*graph.Link, 4)
+	for i := range links {
+		links[i] = &graph.Link{
+			URL:         fmt.Sprintf("https://example%d.com/page", i),
+			RetrievedAt: time.Now().Add(-time.Hour).Truncate(time.Second),
+			Depth:       i,
+			Source:      graph.SourceCrawl,
+		}
+		if err := g.UpsertLink(links[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {2, 1}} {
+		if err := g.UpsertEdge(&graph.Edge{Src: links[pair[0]].ID, Dst: links[pair[1]].ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+// edges of g as "src url -> dst url"
+func edgeURLs(t *testing.T, g *memory.InMemory) []string {
+	it, err := g.Edges(xuuid.MIN, xuuid.MAX, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for it.Next() {
+		edge := it.Edge()
+		src, _ := g.LookupLink(edge.Src)
+		dst, _ := g.LookupLink(edge.Dst)
+		res = append(res, src.URL+" -> "+dst.URL)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func linkCount(t *testing.T, g *memory.InMemory) int {
+	it, err := g.Links(xuuid.MIN, xuuid.MAX, graph.LinkFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for it.Next() {
+		it.Link()
+		count++
+	}
+	return count
+}
+
+func Test_round_trip(t *testing.T) {
+	src := testGraph(t)
+	expectEdges := edgeURLs(t, src)
+
+	tests := []struct {
+		format     Format
+		expectLink int
+	}{
+		{FormatJSONL, 4},
+		{FormatCSV, 3},
+		{FormatGraphML, 4},
+	}
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			pos, err := Export(src, &buf, ExportOptions{Format: test.format})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pos.Section != SectionDone {
+				t.Fatalf("\ngot:%v\nexpect:%v", pos.Section, SectionDone)
+			}
+
+			dst := memory.New()
+			// import twice, the second one must not duplicate anything
+			for i := 0; i < 2; i++ {
+				if _, err := Import(dst, bytes.NewReader(buf.Bytes()), ImportOptions{Format: test.format}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if n := linkCount(t, dst); n != test.expectLink {
+				t.Fatalf("\ngot:%v\nexpect:%v", n, test.expectLink)
+			}
+			if got := edgeURLs(t, dst); fmt.Sprint(got) != fmt.Sprint(expectEdges) {
+				t.Fatalf("\ngot:%v\nexpect:%v", got, expectEdges)
+			}
+
+			// ID and metadata preserved
+			it, _ := dst.Links(xuuid.MIN, xuuid.MAX, graph.LinkFilter{})
+			for it.Next() {
+				link := it.Link()
+				orig, err := src.LookupLink(link.ID)
+				if err != nil {
+					t.Fatalf("link %v: %v", link.URL, err)
+				}
+				if test.format != FormatCSV && (orig.Depth != link.Depth || !orig.RetrievedAt.Equal(link.RetrievedAt)) {
+					t.Fatalf("\ngot:%+v\nexpect:%+v", link, orig)
+				}
+			}
+		})
+	}
+}
+
+func Test_import_remap_id(t *testing.T) {
+	src := testGraph(t)
+	var buf bytes.Buffer
+	if _, err := Export(src, &buf, ExportOptions{Format: FormatJSONL}); err != nil {
+		t.Fatal(err)
+	}
+
+	// url already exist with other ID, edges must follow the stored link
+	dst := memory.New()
+	exist := &graph.Link{URL: "https://example1.com/page"}
+	if err := dst.UpsertLink(exist); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Import(dst, &buf, ImportOptions{Format: FormatJSONL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Links != 4 || res.Edges != 3 || res.DroppedEdges != 0 {
+		t.Fatalf("\ngot:%+v", res)
+	}
+	if in, _, _ := dst.Degree(exist.ID); in != 2 {
+		t.Fatalf("\ngot:%v\nexpect:%v", in, 2)
+	}
+}
+
+func Test_resume(t *testing.T) {
+	src := testGraph(t)
+
+	var full bytes.Buffer
+	if _, err := Export(src, &full, ExportOptions{Format: FormatJSONL}); err != nil {
+		t.Fatal(err)
+	}
+
+	// export resumed after the second link write the rest only
+	it, _ := src.Links(xuuid.MIN, xuuid.MAX, graph.LinkFilter{})
+	var second uuid.UUID
+	for i := 0; i < 2 && it.Next(); i++ {
+		second = it.Link().ID
+	}
+
+	var rest bytes.Buffer
+	pos, err := Export(src, &rest, ExportOptions{
+		Format: FormatJSONL,
+		From:   Position{Section: SectionLinks, After: second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.Section != SectionDone {
+		t.Fatalf("\ngot:%v\nexpect:%v", pos.Section, SectionDone)
+	}
+	// 2 links and 3 edges left
+	if n := bytes.Count(rest.Bytes(), []byte("\n")); n != 5 {
+		t.Fatalf("\ngot:%v\nexpect:%v", n, 5)
+	}
+
+	// import skipping records written by interrupted import
+	dst := memory.New()
+	lines := bytes.SplitAfter(full.Bytes(), []byte("\n"))
+	if _, err := Import(dst, bytes.NewReader(bytes.Join(lines[:2], nil)), ImportOptions{Format: FormatJSONL}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Import(dst, bytes.NewReader(full.Bytes()), ImportOptions{Format: FormatJSONL, Skip: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Records != 7 || res.Links != 2 || res.Edges != 3 {
+		t.Fatalf("\ngot:%+v", res)
+	}
+	if got, expect := edgeURLs(t, dst), edgeURLs(t, src); fmt.Sprint(got) != fmt.Sprint(expect) {
+		t.Fatalf("\ngot:%v\nexpect:%v", got, expect)
+	}
+}
@@ -0,0 +1,197 @@
+package graphio
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+const graphMLHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="url" for="node" attr.name="url" attr.type="string"/>
+  <key id="retrieved_at" for="node" attr.name="retrieved_at" attr.type="string"/>
+  <key id="host" for="node" attr.name="host" attr.type="string"/>
+  <key id="depth" for="node" attr.name="depth" attr.type="int"/>
+  <key id="status_code" for="node" attr.name="status_code" attr.type="int"/>
+  <key id="source" for="node" attr.name="source" attr.type="string"/>
+  <key id="updated_at" for="edge" attr.name="updated_at" attr.type="string"/>
+  <graph id="links" edgedefault="directed">
+`
+
+const graphMLFooter = `  </graph>
+</graphml>
+`
+
+type graphMLEncoder struct {
+	w io.Writer
+}
+
+func newGraphMLEncoder(w io.Writer) *graphMLEncoder {
+	return &graphMLEncoder{w: w}
+}
+
+func (e *graphMLEncoder) begin() error {
+	_, err := io.WriteString(e.w, graphMLHeader)
+	return err
+}
+
+func (e *graphMLEncoder) end() error {
+	_, err := io.WriteString(e.w, graphMLFooter)
+	return err
+}
+
+func (e *graphMLEncoder) link(link *graph.Link) error {
+	node := xmlElement{
+		ID: link.ID.String(),
+		Data: []xmlData{
+			{Key: "url", Value: link.URL},
+			{Key: "host", Value: link.Host},
+			{Key: "depth", Value: strconv.Itoa(link.Depth)},
+		},
+	}
+	if !link.RetrievedAt.IsZero() {
+		node.Data = append(node.Data, xmlData{Key: "retrieved_at", Value: formatTime(link.RetrievedAt)})
+	}
+	if link.StatusCode != 0 {
+		node.Data = append(node.Data, xmlData{Key: "status_code", Value: strconv.Itoa(link.StatusCode)})
+	}
+	if link.Source != graph.SourceUnknown {
+		node.Data = append(node.Data, xmlData{Key: "source", Value: string(link.Source)})
+	}
+	return e.write("node", &node)
+}
+
+func (e *graphMLEncoder) edge(edge *graph.Edge) error {
+	return e.write("edge", &xmlElement{
+		ID:     edge.ID.String(),
+		Source: edge.Src.String(),
+		Target: edge.Dst.String(),
+		Data:   []xmlData{{Key: "updated_at", Value: formatTime(edge.UpdateAt)}},
+	})
+}
+
+func (e *graphMLEncoder) write(name string, el *xmlElement) error {
+	b, err := xml.Marshal(struct {
+		XMLName xml.Name
+		*xmlElement
+	}{xml.Name{Local: name}, el})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(e.w, "    %s\n", b)
+	return err
+}
+
+// node or edge element
+type xmlElement struct {
+	ID     string    `xml:"id,attr"`
+	Source string    `xml:"source,attr,omitempty"`
+	Target string    `xml:"target,attr,omitempty"`
+	Data   []xmlData `xml:"data"`
+}
+
+type xmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlKey struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"attr.name,attr"`
+}
+
+type graphMLDecoder struct {
+	d *xml.Decoder
+
+	// key id -> attribute name, document made by other tool may use other key id
+	keys map[string]string
+}
+
+func newGraphMLDecoder(r io.Reader) *graphMLDecoder {
+	return &graphMLDecoder{d: xml.NewDecoder(r), keys: map[string]string{}}
+}
+
+func (d *graphMLDecoder) next() (*record, error) {
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "key":
+			var key xmlKey
+			if err := d.d.DecodeElement(&key, &se); err != nil {
+				return nil, err
+			}
+			d.keys[key.ID] = key.Name
+
+		case "node":
+			var node xmlElement
+			if err := d.d.DecodeElement(&node, &se); err != nil {
+				return nil, err
+			}
+			return d.link(&node)
+
+		case "edge":
+			var edge xmlElement
+			if err := d.d.DecodeElement(&edge, &se); err != nil {
+				return nil, err
+			}
+			src, err := uuid.Parse(edge.Source)
+			if err != nil {
+				return nil, fmt.Errorf("edge %v source: %v", edge.ID, err)
+			}
+			dst, err := uuid.Parse(edge.Target)
+			if err != nil {
+				return nil, fmt.Errorf("edge %v target: %v", edge.ID, err)
+			}
+			return &record{edge: &graph.Edge{Src: src, Dst: dst}}, nil
+		}
+	}
+}
+
+func (d *graphMLDecoder) link(node *xmlElement) (*record, error) {
+	id, err := uuid.Parse(node.ID)
+	if err != nil {
+		return nil, fmt.Errorf("node %v: %v", node.ID, err)
+	}
+
+	link := graph.Link{ID: id}
+	for _, data := range node.Data {
+		name, ok := d.keys[data.Key]
+		if !ok {
+			name = data.Key
+		}
+
+		switch name {
+		case "url":
+			link.URL = data.Value
+		case "host":
+			link.Host = data.Value
+		case "source":
+			link.Source = graph.Source(data.Value)
+		case "depth":
+			link.Depth, err = strconv.Atoi(data.Value)
+		case "status_code":
+			link.StatusCode, err = strconv.Atoi(data.Value)
+		case "retrieved_at":
+			link.RetrievedAt, err = parseTime(data.Value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("node %v %v: %v", node.ID, name, err)
+		}
+	}
+	if link.URL == "" {
+		return nil, fmt.Errorf("node %v has no url", node.ID)
+	}
+	return &record{link: &link}, nil
+}
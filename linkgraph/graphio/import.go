@@ -0,0 +1,177 @@
+package graphio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+// ImportOptions encapsulates the settings of an import
+type ImportOptions struct {
+	Format Format
+
+	// number of records already imported by interrupted import,
+	// they are read again but not written.
+	Skip int64
+
+	// called with number of processed records every few records and when the import end.
+	Progress func(processed int64) error
+}
+
+// ImportResult summarize an import
+type ImportResult struct {
+	// number of records read including the skipped one
+	Records int64
+
+	Links int64
+	Edges int64
+
+	// edges whose link is not in the input nor in the graph
+	DroppedEdges int64
+}
+
+type decoder interface {
+	// return io.EOF when there is no record left
+	next() (*record, error)
+}
+
+func newDecoder(format Format, r io.Reader) (decoder, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLDecoder(r), nil
+	case FormatCSV:
+		return newCSVDecoder(r), nil
+	case FormatGraphML:
+		return newGraphMLDecoder(r), nil
+	}
+	return nil, fmt.Errorf("unknown graph format %q", format)
+}
+
+// Import upsert every link and edge read from r into g.
+// link keep it's ID unless the url already exist or the ID is taken by other link,
+// edge of the remapped link follow the new ID.
+func Import(g ImportGraph, r io.Reader, opts ImportOptions) (ImportResult, error) {
+	dec, err := newDecoder(opts.Format, r)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	im := importer{
+		g:     g,
+		idMap: map[uuid.UUID]uuid.UUID{},
+	}
+	for {
+		rec, err := dec.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return im.res, fmt.Errorf("import record %d: %v", im.res.Records+1, err)
+		}
+
+		if im.res.Records < opts.Skip {
+			err = im.skip(rec)
+		} else {
+			err = im.write(rec)
+		}
+		if err != nil {
+			return im.res, fmt.Errorf("import record %d: %v", im.res.Records+1, err)
+		}
+		im.res.Records++
+
+		if opts.Progress != nil && im.res.Records%progressEvery == 0 {
+			if err := opts.Progress(im.res.Records); err != nil {
+				return im.res, err
+			}
+		}
+	}
+
+	if opts.Progress != nil {
+		if err := opts.Progress(im.res.Records); err != nil {
+			return im.res, err
+		}
+	}
+	return im.res, nil
+}
+
+type importer struct {
+	g   ImportGraph
+	res ImportResult
+
+	// input link ID -> stored link ID, only for link that not keep it's ID
+	idMap map[uuid.UUID]uuid.UUID
+}
+
+func (im *importer) write(rec *record) error {
+	if rec.link != nil {
+		return im.link(rec.link)
+	}
+
+	edge := &graph.Edge{Src: rec.edge.Src, Dst: rec.edge.Dst}
+	if rec.srcURL != "" || rec.dstURL != "" {
+		// edge list carry it's links
+		src := &graph.Link{ID: edge.Src, URL: rec.srcURL}
+		if err := im.link(src); err != nil {
+			return err
+		}
+		dst := &graph.Link{ID: edge.Dst, URL: rec.dstURL}
+		if err := im.link(dst); err != nil {
+			return err
+		}
+		edge.Src, edge.Dst = src.ID, dst.ID
+	} else {
+		edge.Src, edge.Dst = im.mapID(edge.Src), im.mapID(edge.Dst)
+	}
+
+	if err := im.g.UpsertEdge(edge); err != nil {
+		if errors.Is(err, graph.ErrUnknownEdgeLinks) {
+			im.res.DroppedEdges++
+			return nil
+		}
+		return err
+	}
+	im.res.Edges++
+	return nil
+}
+
+// upsert the link, dedupe by url is done by the graph
+func (im *importer) link(link *graph.Link) error {
+	inputID := link.ID
+	if err := im.g.UpsertLink(link); err != nil {
+		return err
+	}
+	if inputID != uuid.Nil && link.ID != inputID {
+		im.idMap[inputID] = link.ID
+	}
+	im.res.Links++
+	return nil
+}
+
+// rebuild the id mapping of record written by previous import
+func (im *importer) skip(rec *record) error {
+	if rec.link == nil {
+		return nil
+	}
+
+	stored, err := im.g.LookupLinkByURL(rec.link.URL)
+	if err != nil {
+		if errors.Is(err, graph.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if stored.ID != rec.link.ID {
+		im.idMap[rec.link.ID] = stored.ID
+	}
+	return nil
+}
+
+func (im *importer) mapID(id uuid.UUID) uuid.UUID {
+	if stored, ok := im.idMap[id]; ok {
+		return stored
+	}
+	return id
+}
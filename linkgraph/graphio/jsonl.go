@@ -0,0 +1,166 @@
+package graphio
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+const (
+	jsonTypeLink = "link"
+	jsonTypeEdge = "edge"
+)
+
+type jsonLink struct {
+	Type        string    `json:"type"`
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	RetrievedAt string    `json:"retrieved_at,omitempty"`
+	Host        string    `json:"host,omitempty"`
+	Depth       int       `json:"depth,omitempty"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	ContentHash string    `json:"content_hash,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	FirstSeenAt string    `json:"first_seen_at,omitempty"`
+	NextCrawlAt string    `json:"next_crawl_at,omitempty"`
+}
+
+type jsonEdge struct {
+	Type      string    `json:"type"`
+	ID        uuid.UUID `json:"id"`
+	Src       uuid.UUID `json:"src"`
+	Dst       uuid.UUID `json:"dst"`
+	UpdatedAt string    `json:"updated_at,omitempty"`
+}
+
+type jsonlEncoder struct {
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	return &jsonlEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *jsonlEncoder) begin() error { return nil }
+func (e *jsonlEncoder) end() error   { return nil }
+
+func (e *jsonlEncoder) link(link *graph.Link) error {
+	rec := jsonLink{
+		Type:        jsonTypeLink,
+		ID:          link.ID,
+		URL:         link.URL,
+		Host:        link.Host,
+		Depth:       link.Depth,
+		StatusCode:  link.StatusCode,
+		ContentType: link.ContentType,
+		ContentHash: link.ContentHash,
+		Source:      string(link.Source),
+	}
+	if !link.RetrievedAt.IsZero() {
+		rec.RetrievedAt = formatTime(link.RetrievedAt)
+	}
+	if !link.FirstSeenAt.IsZero() {
+		rec.FirstSeenAt = formatTime(link.FirstSeenAt)
+	}
+	if !link.NextCrawlAt.IsZero() {
+		rec.NextCrawlAt = formatTime(link.NextCrawlAt)
+	}
+	return e.enc.Encode(&rec)
+}
+
+func (e *jsonlEncoder) edge(edge *graph.Edge) error {
+	rec := jsonEdge{
+		Type: jsonTypeEdge,
+		ID:   edge.ID,
+		Src:  edge.Src,
+		Dst:  edge.Dst,
+	}
+	if !edge.UpdateAt.IsZero() {
+		rec.UpdatedAt = formatTime(edge.UpdateAt)
+	}
+	return e.enc.Encode(&rec)
+}
+
+// maximum length of single line
+const maxJSONLine = 1 << 20
+
+type jsonlDecoder struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxJSONLine)
+	return &jsonlDecoder{sc: sc}
+}
+
+func (d *jsonlDecoder) next() (*record, error) {
+	for d.sc.Scan() {
+		d.line++
+		line := d.sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var head struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(line, &head); err != nil {
+			return nil, fmt.Errorf("line %d: %v", d.line, err)
+		}
+
+		switch head.Type {
+		case jsonTypeLink:
+			return d.link(line)
+		case jsonTypeEdge:
+			return d.edge(line)
+		default:
+			return nil, fmt.Errorf("line %d: unknown record type %q", d.line, head.Type)
+		}
+	}
+	if err := d.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (d *jsonlDecoder) link(line []byte) (*record, error) {
+	var rec jsonLink
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("line %d: %v", d.line, err)
+	}
+
+	link := graph.Link{
+		ID:          rec.ID,
+		URL:         rec.URL,
+		Host:        rec.Host,
+		Depth:       rec.Depth,
+		StatusCode:  rec.StatusCode,
+		ContentType: rec.ContentType,
+		ContentHash: rec.ContentHash,
+		Source:      graph.Source(rec.Source),
+	}
+	var err error
+	if link.RetrievedAt, err = parseTime(rec.RetrievedAt); err != nil {
+		return nil, fmt.Errorf("line %d: %v", d.line, err)
+	}
+	if link.NextCrawlAt, err = parseTime(rec.NextCrawlAt); err != nil {
+		return nil, fmt.Errorf("line %d: %v", d.line, err)
+	}
+	// first seen is set by the store of destination graph
+	return &record{link: &link}, nil
+}
+
+func (d *jsonlDecoder) edge(line []byte) (*record, error) {
+	var rec jsonEdge
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("line %d: %v", d.line, err)
+	}
+	return &record{edge: &graph.Edge{ID: rec.ID, Src: rec.Src, Dst: rec.Dst}}, nil
+}
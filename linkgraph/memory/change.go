@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+var _ graph.ChangeFeed = (*InMemory)(nil)
+
+// append change into the log and wake the waiting subscriber,
+// caller must hold the write lock
+func (in *InMemory) record(kind graph.ChangeKind, linkID, edgeID uuid.UUID) {
+	in.changes = append(in.changes, &graph.Change{
+		Cursor: in.lastCursor() + 1,
+		Kind:   kind,
+		LinkID: linkID,
+		EdgeID: edgeID,
+		At:     time.Now(),
+	})
+	close(in.changed)
+	in.changed = make(chan struct{})
+}
+
+// caller must hold the lock
+func (in *InMemory) lastCursor() uint64 {
+	if len(in.changes) == 0 {
+		return in.pruned
+	}
+	return in.changes[len(in.changes)-1].Cursor
+}
+
+// LastChange implements graph.ChangeFeed.
+func (in *InMemory) LastChange(_ context.Context) (uint64, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.lastCursor(), nil
+}
+
+// Changes implements graph.ChangeFeed.
+func (in *InMemory) Changes(_ context.Context, after uint64, limit int) ([]*graph.Change, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	if after < in.pruned {
+		return nil, graph.ErrChangesPruned
+	}
+
+	i := sort.Search(len(in.changes), func(i int) bool { return in.changes[i].Cursor > after })
+	end := len(in.changes)
+	if limit > 0 && i+limit < end {
+		end = i + limit
+	}
+
+	list := make([]*graph.Change, 0, end-i)
+	for _, change := range in.changes[i:end] {
+		ccopy := *change
+		list = append(list, &ccopy)
+	}
+	return list, nil
+}
+
+// PruneChanges implements graph.ChangeFeed.
+func (in *InMemory) PruneChanges(_ context.Context, before time.Time) (int, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	n := sort.Search(len(in.changes), func(i int) bool { return !in.changes[i].At.Before(before) })
+	if n == 0 {
+		return 0, nil
+	}
+	in.pruned = in.changes[n-1].Cursor
+
+	// copied so the pruned entries can be collected
+	in.changes = append([]*graph.Change(nil), in.changes[n:]...)
+	return n, nil
+}
+
+// WaitChange implements graph.ChangeFeed.
+func (in *InMemory) WaitChange(ctx context.Context, after uint64) error {
+	in.mu.RLock()
+	last, changed := in.lastCursor(), in.changed
+	in.mu.RUnlock()
+	if last > after {
+		return nil
+	}
+
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+var _ graph.HostGraph = (*InMemory)(nil)
+
+// RecordFetches implements graph.HostGraph.
+func (in *InMemory) RecordFetches(fetches []*graph.HostFetch) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for _, fetch := range fetches {
+		host := in.hostStat(fetch.Host)
+		host.Fetches++
+		if fetch.Failed() {
+			host.FetchErrors++
+		}
+		host.TotalResponseTime += fetch.ResponseTime
+		if fetch.FetchedAt.After(host.LastCrawlAt) {
+			host.LastCrawlAt = fetch.FetchedAt
+		}
+		if fetch.RobotsStatus != graph.RobotsUnknown {
+			host.RobotsStatus = fetch.RobotsStatus
+		}
+	}
+	return nil
+}
+
+// LookupHost implements graph.HostGraph.
+func (in *InMemory) LookupHost(name string) (*graph.Host, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	host := in.host(name)
+	if _, ok := in.hosts[name]; !ok && host.KnownPages == 0 {
+		return nil, fmt.Errorf("lookup host: %w", graph.ErrNotFound)
+	}
+	return host, nil
+}
+
+// Hosts implements graph.HostGraph.
+func (in *InMemory) Hosts(offset, limit int) ([]*graph.Host, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	names := make([]string, 0, len(in.hosts))
+	for name := range in.hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if offset >= len(names) {
+		return nil, nil
+	}
+	names = names[offset:]
+	if limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+
+	hosts := make([]*graph.Host, len(names))
+	for i, name := range names {
+		hosts[i] = in.host(name)
+	}
+	return hosts, nil
+}
+
+// return stored statistic of the host, created if not exist.
+// caller must hold the write lock
+func (in *InMemory) hostStat(name string) *graph.Host {
+	host, ok := in.hosts[name]
+	if !ok {
+		host = &graph.Host{Name: name}
+		in.hosts[name] = host
+	}
+	return host
+}
+
+// copy of host statistic with the derived fields.
+// caller must hold the lock
+func (in *InMemory) host(name string) *graph.Host {
+	host := graph.Host{Name: name}
+	if stat, ok := in.hosts[name]; ok {
+		host = *stat
+	}
+
+	for _, link := range in.links {
+		if link.Host != name {
+			continue
+		}
+		host.KnownPages++
+		if !link.RetrievedAt.IsZero() {
+			host.CrawledPages++
+		}
+	}
+
+	for _, edge := range in.edges {
+		srcHost, dstHost := in.links[edge.Src].Host, in.links[edge.Dst].Host
+		switch {
+		case srcHost == dstHost:
+		case srcHost == name:
+			host.OutEdges++
+		case dstHost == name:
+			host.InEdges++
+		}
+	}
+	return &host
+}
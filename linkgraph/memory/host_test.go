@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+func Test_host(t *testing.T) {
+	cache := New()
+
+	page := &graph.Link{URL: "https://a.com/page", RetrievedAt: time.Now()}
+	other := &graph.Link{URL: "https://a.com/other"}
+	external := &graph.Link{URL: "https://b.com"}
+	for _, link := range []*graph.Link{page, other, external} {
+		if err := cache.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, edge := range []*graph.Edge{
+		{Src: page.ID, Dst: other.ID},
+		{Src: page.ID, Dst: external.ID},
+	} {
+		if err := cache.UpsertEdge(edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := cache.RecordFetches([]*graph.HostFetch{
+		{Host: "a.com", StatusCode: 200, ResponseTime: 100 * time.Millisecond, FetchedAt: time.Now()},
+		{Host: "a.com", StatusCode: 500, ResponseTime: 300 * time.Millisecond, FetchedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, err := cache.LookupHost("a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.KnownPages != 2 || host.CrawledPages != 1 || host.OutEdges != 1 || host.InEdges != 0 {
+		t.Fatalf("\ngot:%+v", host)
+	}
+	if host.ErrorRate() != 0.5 || host.AvgResponseTime() != 200*time.Millisecond {
+		t.Fatalf("\ngot:%v %v\nexpect:%v %v", host.ErrorRate(), host.AvgResponseTime(), 0.5, 200*time.Millisecond)
+	}
+
+	// known host without statistic
+	host, err = cache.LookupHost("b.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.KnownPages != 1 || host.InEdges != 1 || host.Fetches != 0 {
+		t.Fatalf("\ngot:%+v", host)
+	}
+
+	if _, err := cache.LookupHost("c.com"); !errors.Is(err, graph.ErrNotFound) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+
+	hosts, err := cache.Hosts(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 1 || hosts[0].Name != "a.com" {
+		t.Fatalf("\ngot:%+v", hosts)
+	}
+}
@@ -29,8 +29,11 @@ func (it *LinkIterator) Error() error {
 
 // Next implements graph.Iterator.
 func (it *LinkIterator) Next() bool {
-	return it.idx < len(it.list)
-
+	if it.idx >= len(it.list) {
+		return false
+	}
+	it.idx++
+	return true
 }
 
 // Link implements graph.LinkIterator.
@@ -38,9 +41,7 @@ func (li *LinkIterator) Link() *graph.Link {
 	li.s.mu.RLock()
 
 	val := new(graph.Link)
-	l := li.list[li.idx]
-	*val = *l
-	li.idx++
+	*val = *li.list[li.idx-1]
 
 	li.s.mu.RUnlock()
 	return val
@@ -69,9 +70,7 @@ func (it *edgeIterator) Edge() *graph.Edge {
 	it.mem.mu.RLock()
 
 	val := new(graph.Edge)
-	l := it.list[it.idx]
-	*val = *l
-	it.idx++
+	*val = *it.list[it.idx-1]
 
 	it.mem.mu.RUnlock()
 	return val
@@ -84,5 +83,9 @@ func (*edgeIterator) Error() error {
 
 // Next implements graph.EdgeIterator.
 func (it *edgeIterator) Next() bool {
-	return it.idx < len(it.list)
+	if it.idx >= len(it.list) {
+		return false
+	}
+	it.idx++
+	return true
 }
@@ -2,6 +2,7 @@ package memory
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,7 +12,7 @@ import (
 
 //represent Implementation in-memory store graph
 
-// var _ graph.Graph = (*InMemory)(nil)
+var _ graph.Graph = (*InMemory)(nil)
 
 type InMemory struct {
 	mu sync.RWMutex
@@ -31,6 +32,21 @@ type InMemory struct {
 		edge that originate to same link (edge.Src)
 	*/
 	linkEdgeMap map[uuid.UUID]edgeList
+
+	// same as linkEdgeMap but keyed by edge.Dst,
+	// so the backlinks of link can be found without scanning every edge
+	linkInEdgeMap map[uuid.UUID]edgeList
+
+	// fetch statistic keyed by hostname,
+	// the other host fields derived from links and edges on lookup.
+	hosts map[string]*graph.Host
+
+	// append-only change log, changed is closed and replaced on every recorded change
+	changes []*graph.Change
+	changed chan struct{}
+
+	// cursor of the last pruned change
+	pruned uint64
 }
 
 // containt only the list of edge's ID that originate from the same link
@@ -47,6 +63,16 @@ type InMemory struct {
 */
 type edgeList []uuid.UUID // edge ID
 
+// return the list without the edge ID
+func (list edgeList) without(id uuid.UUID) edgeList {
+	for i, edgeID := range list {
+		if edgeID == id {
+			return append(list[:i:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
 func New() *InMemory {
 	in := &InMemory{
 		// mu:           sync.RWMutex{},
@@ -54,6 +80,10 @@ func New() *InMemory {
 		edges:        map[uuid.UUID]*graph.Edge{},
 		linkUrlIndex: map[string]*graph.Link{},
 		linkEdgeMap:  map[uuid.UUID]edgeList{},
+
+		linkInEdgeMap: map[uuid.UUID]edgeList{},
+		hosts:         map[string]*graph.Host{},
+		changed:       make(chan struct{}),
 	}
 
 	return in
@@ -69,6 +99,8 @@ func (in *InMemory) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time)
 		edge := in.edges[edgeID]
 		if edge.UpdateAt.Before(updatedBefore) {
 			delete(in.edges, edgeID)
+			in.linkInEdgeMap[edge.Dst] = in.linkInEdgeMap[edge.Dst].without(edgeID)
+			in.record(graph.ChangeEdgeChanged, edge.Src, edgeID)
 			continue
 		}
 
@@ -83,28 +115,28 @@ func (in *InMemory) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time)
 func (in *InMemory) UpsertLink(link *graph.Link) error {
 	in.mu.Lock()
 	defer in.mu.Unlock()
+	if link.Host == "" {
+		link.Host = graph.HostOf(link.URL)
+	}
+
 	// check if link is exist
-	// is link exist update the link.ID into exist link
+	// is link exist merge it into exist link
 	if exist := in.linkUrlIndex[link.URL]; exist != nil {
-		link.ID = exist.ID
-		originRetrieve := exist.RetrievedAt
-		*exist = *link
-
-		if originRetrieve.After(exist.RetrievedAt) {
-			exist.RetrievedAt = originRetrieve
+		if link.RetrievedAt.After(exist.RetrievedAt) {
+			in.record(graph.ChangeLinkCrawled, exist.ID, uuid.Nil)
 		}
+		graph.MergeLink(exist, link)
+		*link = *exist
 		return nil
 	}
 
 	// insert.
-	// avoid collision uuid
-	for {
+	// keep given id if not taken, otherwise avoid collision uuid
+	for link.ID == uuid.Nil || in.links[link.ID] != nil {
 		link.ID = uuid.New()
-		if in.links[link.ID] == nil {
-			break
-		}
 	}
 
+	link.FirstSeenAt = time.Now()
 	lcopy := new(graph.Link)
 	*lcopy = *link
 	//insert url as index
@@ -112,10 +144,25 @@ func (in *InMemory) UpsertLink(link *graph.Link) error {
 	//insert to stores
 	in.links[lcopy.ID] = lcopy
 
+	in.record(graph.ChangeLinkCreated, lcopy.ID, uuid.Nil)
+	if !lcopy.RetrievedAt.IsZero() {
+		in.record(graph.ChangeLinkCrawled, lcopy.ID, uuid.Nil)
+	}
+
 	// log.Println("DEBUG inMEMORY LINKGRAPH links length:", len(in.links), lcopy.URL)
 	return nil
 }
 
+// UpsertLinks implements graph.Graph.
+func (in *InMemory) UpsertLinks(links []*graph.Link) error {
+	for _, link := range links {
+		if err := in.UpsertLink(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // lookup link by it's ID
 func (in *InMemory) LookupLink(id uuid.UUID) (*graph.Link, error) {
 	in.mu.RLock()
@@ -123,7 +170,7 @@ func (in *InMemory) LookupLink(id uuid.UUID) (*graph.Link, error) {
 
 	l, ok := in.links[id]
 	if !ok {
-		return nil, fmt.Errorf("find link: not found ")
+		return nil, fmt.Errorf("find link: %w", graph.ErrNotFound)
 	}
 
 	lcopy := new(graph.Link)
@@ -131,18 +178,95 @@ func (in *InMemory) LookupLink(id uuid.UUID) (*graph.Link, error) {
 	return lcopy, nil
 }
 
+// LookupLinkByURL implements graph.Graph.
+func (in *InMemory) LookupLinkByURL(url string) (*graph.Link, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	l, ok := in.linkUrlIndex[url]
+	if !ok {
+		return nil, fmt.Errorf("find link: %w", graph.ErrNotFound)
+	}
+
+	lcopy := new(graph.Link)
+	*lcopy = *l
+	return lcopy, nil
+}
+
+// RemoveLink implements graph.Graph.
+func (in *InMemory) RemoveLink(id uuid.UUID) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if _, ok := in.links[id]; !ok {
+		return fmt.Errorf("remove link: %w", graph.ErrNotFound)
+	}
+	in.removeLink(id)
+	return nil
+}
+
+// caller must hold the write lock
+func (in *InMemory) removeLink(id uuid.UUID) {
+	// edges originate from the link, drop them from destination backlinks
+	for _, edgeID := range in.linkEdgeMap[id] {
+		edge := in.edges[edgeID]
+		in.linkInEdgeMap[edge.Dst] = in.linkInEdgeMap[edge.Dst].without(edgeID)
+		delete(in.edges, edgeID)
+		in.record(graph.ChangeEdgeChanged, edge.Src, edgeID)
+	}
+
+	// edges end up at the link, drop them from source edge list
+	for _, edgeID := range in.linkInEdgeMap[id] {
+		edge, ok := in.edges[edgeID]
+		if !ok {
+			// self-link, already removed above
+			continue
+		}
+		in.linkEdgeMap[edge.Src] = in.linkEdgeMap[edge.Src].without(edgeID)
+		delete(in.edges, edgeID)
+		in.record(graph.ChangeEdgeChanged, edge.Src, edgeID)
+	}
+
+	delete(in.linkEdgeMap, id)
+	delete(in.linkInEdgeMap, id)
+	delete(in.linkUrlIndex, in.links[id].URL)
+	delete(in.links, id)
+}
+
+// RemoveOrphanLinks implements graph.Graph.
+func (in *InMemory) RemoveOrphanLinks(createdBefore time.Time) (int, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	removed := 0
+	for id, link := range in.links {
+		if !link.RetrievedAt.IsZero() || len(in.linkInEdgeMap[id]) > 0 {
+			continue
+		}
+		if !link.FirstSeenAt.Before(createdBefore) {
+			continue
+		}
+		in.removeLink(id)
+		removed++
+	}
+	return removed, nil
+}
+
 // return the set of links (iterator) whose id belong to
-func (in *InMemory) Links(fromID, toID uuid.UUID, retrieveBefore time.Time) (graph.LinkIterator, error) {
+func (in *InMemory) Links(fromID, toID uuid.UUID, filter graph.LinkFilter) (graph.LinkIterator, error) {
 	from, to := fromID.String(), toID.String()
 
 	in.mu.RLock()
 	var list []*graph.Link
 	for linkID, link := range in.links {
-		if id := linkID.String(); id >= from && id < to && link.RetrievedAt.Before(retrieveBefore) {
+		if id := linkID.String(); id >= from && id < to && filter.Match(link) {
 			list = append(list, link)
 		}
 	}
 	in.mu.RUnlock()
+
+	// iterate in ascending ID order like the other stores
+	sort.Slice(list, func(i, j int) bool { return list[i].ID.String() < list[j].ID.String() })
 	return &LinkIterator{
 		s:    in,
 		list: list,
@@ -161,7 +285,7 @@ func (in *InMemory) UpsertEdge(input *graph.Edge) error {
 	_, dstExist := in.links[input.Dst]
 
 	if !srcExist || !dstExist {
-		return fmt.Errorf("upsert edge: unknown edge link")
+		return fmt.Errorf("upsert edge: %w", graph.ErrUnknownEdgeLinks)
 	}
 
 	// update the edge
@@ -188,26 +312,86 @@ func (in *InMemory) UpsertEdge(input *graph.Edge) error {
 	in.edges[eCopy.ID] = eCopy
 
 	in.linkEdgeMap[input.Src] = append(in.linkEdgeMap[input.Src], eCopy.ID)
+	in.linkInEdgeMap[input.Dst] = append(in.linkInEdgeMap[input.Dst], eCopy.ID)
+	in.record(graph.ChangeEdgeChanged, eCopy.Src, eCopy.ID)
 
 	// log.Println("DEBUG inMEMORY LINKGRAPH edges length:", len(in.edges), eCopy.Src)
 	return nil
 }
 
+// UpsertEdges implements graph.Graph.
+func (in *InMemory) UpsertEdges(edges []*graph.Edge) error {
+	for _, edge := range edges {
+		if err := in.UpsertEdge(edge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Edges implements graph.Graph.
 func (in *InMemory) Edges(fromID uuid.UUID, toID uuid.UUID, update time.Time) (graph.EdgeIterator, error) {
 	from, to := fromID.String(), toID.String()
 
 	in.mu.RLock()
 	var list []*graph.Edge
-	for edgeID, edge := range in.edges {
-		if id := edgeID.String(); id >= from && id < to && edge.UpdateAt.Before(update) {
+	for _, edge := range in.edges {
+		if src := edge.Src.String(); src >= from && src < to && edge.UpdateAt.Before(update) {
 			list = append(list, edge)
 		}
 	}
 	in.mu.RUnlock()
+
+	// ascending source ID order like the other stores
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Src != list[j].Src {
+			return list[i].Src.String() < list[j].Src.String()
+		}
+		return list[i].ID.String() < list[j].ID.String()
+	})
 	return &edgeIterator{
 		mem:  in,
 		list: list,
 		idx:  0,
 	}, nil
 }
+
+// InEdges implements graph.Graph.
+func (in *InMemory) InEdges(dst uuid.UUID) (graph.EdgeIterator, error) {
+	in.mu.RLock()
+	list := in.edgesOf(in.linkInEdgeMap[dst])
+	in.mu.RUnlock()
+	return &edgeIterator{
+		mem:  in,
+		list: list,
+		idx:  0,
+	}, nil
+}
+
+// OutEdges implements graph.Graph.
+func (in *InMemory) OutEdges(src uuid.UUID) (graph.EdgeIterator, error) {
+	in.mu.RLock()
+	list := in.edgesOf(in.linkEdgeMap[src])
+	in.mu.RUnlock()
+	return &edgeIterator{
+		mem:  in,
+		list: list,
+		idx:  0,
+	}, nil
+}
+
+// Degree implements graph.Graph.
+func (in *InMemory) Degree(id uuid.UUID) (int, int, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return len(in.linkInEdgeMap[id]), len(in.linkEdgeMap[id]), nil
+}
+
+// caller must hold the lock
+func (in *InMemory) edgesOf(ids edgeList) []*graph.Edge {
+	list := make([]*graph.Edge, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, in.edges[id])
+	}
+	return list
+}
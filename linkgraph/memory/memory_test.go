@@ -1,10 +1,14 @@
 package memory
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/odit-bit/invoker/internal/xuuid"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 )
 
@@ -70,8 +74,13 @@ func Test_iterator(t *testing.T) {
 	cache.UpsertLink(l1)
 	cache.UpsertLink(l2)
 
+	// range include l1 only if it has the lower ID
+	if l2.ID.String() < l1.ID.String() {
+		l1, l2 = l2, l1
+	}
+
 	// list
-	list, err := cache.Links(l1.ID, l2.ID, time.Now())
+	list, err := cache.Links(l1.ID, l2.ID, graph.LinkFilter{RetrievedBefore: time.Now()})
 	if err != nil {
 		t.Error(err)
 	}
@@ -93,3 +102,190 @@ func Test_iterator(t *testing.T) {
 	}
 
 }
+
+func Test_neighbour_edges(t *testing.T) {
+	cache := New()
+	links := make([]*graph.Link, 3)
+	for i := range links {
+		links[i] = &graph.Link{URL: fmt.Sprintf("www.example%d.com", i)}
+		if err := cache.UpsertLink(links[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 0 -> 1, 0 -> 2, 2 -> 1
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {2, 1}} {
+		if err := cache.UpsertEdge(&graph.Edge{Src: links[pair[0]].ID, Dst: links[pair[1]].ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in, out, _ := cache.Degree(links[1].ID)
+	if in != 2 || out != 0 {
+		t.Fatalf("\ngot:%v/%v\nexpect:%v/%v", in, out, 2, 0)
+	}
+	in, out, _ = cache.Degree(links[0].ID)
+	if in != 0 || out != 2 {
+		t.Fatalf("\ngot:%v/%v\nexpect:%v/%v", in, out, 0, 2)
+	}
+
+	it, _ := cache.InEdges(links[1].ID)
+	count := 0
+	for it.Next() {
+		if edge := it.Edge(); edge.Dst != links[1].ID {
+			t.Fatalf("\ngot:%v\nexpect:%v", edge.Dst, links[1].ID)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 2)
+	}
+
+	// stale edge removed from backlinks too
+	if err := cache.RemoveStaleEdges(links[2].ID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	in, _, _ = cache.Degree(links[1].ID)
+	if in != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", in, 1)
+	}
+}
+
+func Test_remove_link(t *testing.T) {
+	cache := New()
+
+	// src is retrieved, dst is only discovered, orphan is neither
+	src := &graph.Link{URL: "www.src.com", RetrievedAt: time.Now()}
+	dst := &graph.Link{URL: "www.dst.com"}
+	orphan := &graph.Link{URL: "www.orphan.com"}
+	for _, link := range []*graph.Link{src, dst, orphan} {
+		if err := cache.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.UpsertEdge(&graph.Edge{Src: src.ID, Dst: dst.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := cache.LookupLinkByURL(dst.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != dst.ID {
+		t.Fatalf("\ngot:%v\nexpect:%v", found.ID, dst.ID)
+	}
+
+	// not old enough
+	if n, _ := cache.RemoveOrphanLinks(time.Now().Add(-time.Minute)); n != 0 {
+		t.Fatalf("\ngot:%v\nexpect:%v", n, 0)
+	}
+	if n, _ := cache.RemoveOrphanLinks(time.Now().Add(time.Minute)); n != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", n, 1)
+	}
+	if _, err := cache.LookupLinkByURL(orphan.URL); !errors.Is(err, graph.ErrNotFound) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+
+	// removing src cascade to it's edge
+	if err := cache.RemoveLink(src.ID); err != nil {
+		t.Fatal(err)
+	}
+	if in, _, _ := cache.Degree(dst.ID); in != 0 {
+		t.Fatalf("\ngot:%v\nexpect:%v", in, 0)
+	}
+	if err := cache.RemoveLink(src.ID); !errors.Is(err, graph.ErrNotFound) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+}
+
+func Test_link_metadata(t *testing.T) {
+	cache := New()
+
+	// discovered by crawl, then submitted by user and fetched
+	link := &graph.Link{URL: "https://www.example.com/page", Depth: 2, Source: graph.SourceCrawl}
+	if err := cache.UpsertLink(link); err != nil {
+		t.Fatal(err)
+	}
+	if link.Host != "www.example.com" || link.FirstSeenAt.IsZero() {
+		t.Fatalf("\ngot:%v %v\nexpect:%v non-zero first seen", link.Host, link.FirstSeenAt, "www.example.com")
+	}
+
+	update := &graph.Link{URL: link.URL, Depth: 0, Source: graph.SourceUser}
+	if err := cache.UpsertLink(update); err != nil {
+		t.Fatal(err)
+	}
+	fetched := &graph.Link{URL: link.URL, Depth: 1, StatusCode: 200, ContentType: "text/html", RetrievedAt: time.Now()}
+	if err := cache.UpsertLink(fetched); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := cache.LookupLink(link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Depth != 0 || stored.Source != graph.SourceCrawl || stored.StatusCode != 200 || stored.ContentType != "text/html" {
+		t.Fatalf("\ngot:%+v", stored)
+	}
+	if !stored.FirstSeenAt.Equal(link.FirstSeenAt) {
+		t.Fatalf("\ngot:%v\nexpect:%v", stored.FirstSeenAt, link.FirstSeenAt)
+	}
+
+	other := &graph.Link{URL: "https://other.com", Depth: 3, StatusCode: 404}
+	if err := cache.UpsertLink(other); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		filter graph.LinkFilter
+		expect int
+	}{
+		{graph.LinkFilter{}, 2},
+		{graph.LinkFilter{Host: "www.example.com"}, 1},
+		{graph.LinkFilter{StatusCode: 404}, 1},
+		{graph.LinkFilter{MaxDepth: 2}, 1},
+		{graph.LinkFilter{Source: graph.SourceCrawl}, 1},
+		{graph.LinkFilter{RetrievedBefore: time.Now().Add(-time.Minute)}, 1},
+	}
+	for _, test := range tests {
+		it, _ := cache.Links(xuuid.MIN, xuuid.MAX, test.filter)
+		count := 0
+		for it.Next() {
+			it.Link()
+			count++
+		}
+		if count != test.expect {
+			t.Fatalf("\nfilter:%+v\ngot:%v\nexpect:%v", test.filter, count, test.expect)
+		}
+	}
+}
+
+func Test_with_context(t *testing.T) {
+	cache := New()
+	for _, u := range []string{"www.example1.com", "www.example2.com"} {
+		if err := cache.UpsertLink(&graph.Link{URL: u}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cg := graph.WithContext(cache)
+
+	it, err := cg.LinksContext(ctx, xuuid.MIN, xuuid.MAX, graph.LinkFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !it.Next() {
+		t.Fatalf("expect first link before cancelled")
+	}
+	cancel()
+	if it.Next() {
+		t.Fatalf("expect iterator stop after cancelled")
+	}
+	if err := it.Error(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, context.Canceled)
+	}
+
+	if err := cg.UpsertLinkContext(ctx, &graph.Link{URL: "www.example3.com"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, context.Canceled)
+	}
+}
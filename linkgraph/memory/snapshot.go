@@ -0,0 +1,200 @@
+package memory
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+// snapshot is the persisted form of the graph,
+// the indexes are rebuilt when it is loaded.
+type snapshot struct {
+	Links []*graph.Link
+	Edges []*graph.Edge
+	Hosts []*graph.Host
+
+	// change log, so subscriber can resume from its cursor after restart
+	Changes []*graph.Change
+	Pruned  uint64
+}
+
+// WriteSnapshot write every link, edge, host statistic and the change log into w
+func (in *InMemory) WriteSnapshot(w io.Writer) error {
+	in.mu.RLock()
+	snap := snapshot{
+		Links: make([]*graph.Link, 0, len(in.links)),
+		Edges: make([]*graph.Edge, 0, len(in.edges)),
+		Hosts: make([]*graph.Host, 0, len(in.hosts)),
+	}
+	for _, link := range in.links {
+		lcopy := *link
+		snap.Links = append(snap.Links, &lcopy)
+	}
+	for _, edge := range in.edges {
+		ecopy := *edge
+		snap.Edges = append(snap.Edges, &ecopy)
+	}
+	for _, host := range in.hosts {
+		hcopy := *host
+		snap.Hosts = append(snap.Hosts, &hcopy)
+	}
+	// change entries are never modified
+	snap.Changes = append(snap.Changes, in.changes...)
+	snap.Pruned = in.pruned
+	in.mu.RUnlock()
+
+	if err := gob.NewEncoder(w).Encode(&snap); err != nil {
+		return fmt.Errorf("write snapshot: %v", err)
+	}
+	return nil
+}
+
+// ReadSnapshot replace the content of the graph with snapshot read from r
+func (in *InMemory) ReadSnapshot(r io.Reader) error {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("read snapshot: %v", err)
+	}
+
+	// built aside and swapped in only when every edge is valid,
+	// a bad snapshot leave the graph untouched
+	links := make(map[uuid.UUID]*graph.Link, len(snap.Links))
+	edges := make(map[uuid.UUID]*graph.Edge, len(snap.Edges))
+	linkUrlIndex := make(map[string]*graph.Link, len(snap.Links))
+	linkEdgeMap := map[uuid.UUID]edgeList{}
+	linkInEdgeMap := map[uuid.UUID]edgeList{}
+	hosts := make(map[string]*graph.Host, len(snap.Hosts))
+
+	for _, link := range snap.Links {
+		links[link.ID] = link
+		linkUrlIndex[link.URL] = link
+	}
+	for _, edge := range snap.Edges {
+		if links[edge.Src] == nil || links[edge.Dst] == nil {
+			return fmt.Errorf("read snapshot: edge %v: %w", edge.ID, graph.ErrUnknownEdgeLinks)
+		}
+		edges[edge.ID] = edge
+		linkEdgeMap[edge.Src] = append(linkEdgeMap[edge.Src], edge.ID)
+		linkInEdgeMap[edge.Dst] = append(linkInEdgeMap[edge.Dst], edge.ID)
+	}
+	for _, host := range snap.Hosts {
+		hosts[host.Name] = host
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	in.links = links
+	in.edges = edges
+	in.linkUrlIndex = linkUrlIndex
+	in.linkEdgeMap = linkEdgeMap
+	in.linkInEdgeMap = linkInEdgeMap
+	in.hosts = hosts
+
+	// changes recorded before must not be missed by the waiting subscriber
+	in.changes = snap.Changes
+	in.pruned = snap.Pruned
+	close(in.changed)
+	in.changed = make(chan struct{})
+	return nil
+}
+
+// SaveFile write snapshot into the file at path,
+// it is written into temporary file first so crash never leave partial snapshot.
+func (in *InMemory) SaveFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("save snapshot: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := in.WriteSnapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("save snapshot: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("save snapshot: %v", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("save snapshot: %v", err)
+	}
+	return nil
+}
+
+// Open create graph loaded from snapshot file at path,
+// the graph is empty if the file does not exist yet.
+func Open(path string) (*InMemory, error) {
+	in := New()
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return in, nil
+		}
+		return nil, fmt.Errorf("open snapshot: %v", err)
+	}
+	defer f.Close()
+
+	if err := in.ReadSnapshot(f); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// SnapshotService periodically save the graph into snapshot file,
+// and once more when it is stopped.
+type SnapshotService struct {
+	graph    *InMemory
+	path     string
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewSnapshotService(graph *InMemory, path string, interval time.Duration) (*SnapshotService, error) {
+	if path == "" {
+		return nil, fmt.Errorf("snapshot path has not been provided")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid value for snapshot interval")
+	}
+	return &SnapshotService{
+		graph:    graph,
+		path:     path,
+		interval: interval,
+		logger:   log.New(os.Stdout, "[graph-snapshot]", log.Ldate|log.Ltime),
+	}, nil
+}
+
+// Name implements service.Service
+func (svc *SnapshotService) Name() string { return "graph snapshot" }
+
+// Run implements service.Service
+func (svc *SnapshotService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(svc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return svc.graph.SaveFile(svc.path)
+		case <-ticker.C:
+			if err := svc.graph.SaveFile(svc.path); err != nil {
+				// try again next tick
+				svc.logger.Printf("[ERROR] %v\n", err)
+			}
+		}
+	}
+}
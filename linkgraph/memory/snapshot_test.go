@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+func Test_snapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.snapshot")
+
+	// missing file is empty graph
+	empty, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.links) != 0 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(empty.links), 0)
+	}
+
+	cache := New()
+	src := &graph.Link{URL: "https://www.example.com", RetrievedAt: time.Now()}
+	dst := &graph.Link{URL: "https://other.com/page", Depth: 1}
+	for _, link := range []*graph.Link{src, dst} {
+		if err := cache.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.UpsertEdge(&graph.Edge{Src: src.ID, Dst: dst.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.RecordFetches([]*graph.HostFetch{{Host: src.Host, StatusCode: 200, FetchedAt: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := loaded.LookupLinkByURL(dst.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ID != dst.ID || stored.Depth != 1 {
+		t.Fatalf("\ngot:%+v\nexpect:%+v", stored, dst)
+	}
+	if in, out, _ := loaded.Degree(src.ID); in != 0 || out != 1 {
+		t.Fatalf("\ngot:%v/%v\nexpect:%v/%v", in, out, 0, 1)
+	}
+	if in, _, _ := loaded.Degree(dst.ID); in != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", in, 1)
+	}
+	host, err := loaded.LookupHost(src.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Fetches != 1 || host.OutEdges != 1 {
+		t.Fatalf("\ngot:%+v", host)
+	}
+
+	// the change log continue from the saved cursor
+	last, _ := cache.LastChange(context.Background())
+	if loadedLast, _ := loaded.LastChange(context.Background()); loadedLast != last {
+		t.Fatalf("\ngot:%v\nexpect:%v", loadedLast, last)
+	}
+	if err := loaded.UpsertLink(&graph.Link{URL: "https://new.com"}); err != nil {
+		t.Fatal(err)
+	}
+	changes, _ := loaded.Changes(context.Background(), last, 0)
+	if len(changes) != 1 || changes[0].Cursor != last+1 {
+		t.Fatalf("\ngot:%v\nexpect cursor:%v", changes, last+1)
+	}
+}
+
+func Test_snapshot_bad_edge(t *testing.T) {
+	cache := New()
+	link := &graph.Link{URL: "https://www.example.com"}
+	if err := cache.UpsertLink(link); err != nil {
+		t.Fatal(err)
+	}
+
+	// the edge point to link that is not in the snapshot
+	var buf bytes.Buffer
+	snap := snapshot{
+		Links: []*graph.Link{{ID: uuid.New(), URL: "https://other.com"}},
+		Edges: []*graph.Edge{{ID: uuid.New(), Src: uuid.New(), Dst: uuid.New()}},
+	}
+	if err := gob.NewEncoder(&buf).Encode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.ReadSnapshot(&buf); !errors.Is(err, graph.ErrUnknownEdgeLinks) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrUnknownEdgeLinks)
+	}
+
+	// the graph keep its content
+	if stored, err := cache.LookupLinkByURL(link.URL); err != nil || stored.ID != link.ID {
+		t.Fatalf("\ngot:%v %v\nexpect:%v", stored, err, link)
+	}
+	if _, err := cache.LookupLinkByURL("https://other.com"); err == nil {
+		t.Fatalf("\nexpect link of bad snapshot not loaded")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,20 +14,30 @@ import (
 	"github.com/odit-bit/invoker/pagerank/bspgraph"
 	"github.com/odit-bit/invoker/pagerank/calculator"
 	"github.com/odit-bit/invoker/partition"
+	"github.com/odit-bit/invoker/textIndex/index"
 	"go.uber.org/multierr"
 )
 
-// GraphAPI defines as set of API methods for fetching the links and edges from
-// the link graph.
+// GraphAPI is the link graph whose links and edges are ranked.
+// graph that not implement graph.ContextGraph is adapted with graph.WithContext,
+// so stopped service cancel the running query.
 type GraphAPI interface {
-	Links(fromID, toID uuid.UUID, retrievedBefore time.Time) (graph.LinkIterator, error)
-	Edges(fromID, toID uuid.UUID, updatedBefore time.Time) (graph.EdgeIterator, error)
+	graph.Graph
 }
 
-// IndexAPI defines a set of API methods for updating PageRank scores for
-// indexed documents.
+// IndexAPI is the index whose documents get the PageRank scores,
+// adapted with index.WithContext like GraphAPI.
 type IndexAPI interface {
-	UpdateScore(linkID uuid.UUID, score float64) error
+	index.Indexer
+}
+
+// LeaderElector decide which instance of the cluster run the PageRank pass.
+type LeaderElector interface {
+	// IsLeader report whether this instance is the current leader
+	IsLeader() bool
+
+	// Leader return the name of current leader, empty if there is none
+	Leader() (string, error)
 }
 
 // Config encapsulates the settings for configuring the PageRank calculator
@@ -41,6 +52,10 @@ type Config struct {
 	// An API for detecting the partition assignments for this service.
 	PartitionDetector partition.Detector
 
+	// An API for electing the instance that compute the scores. If not
+	// specified, the instance that own partition 0 is the leader.
+	LeaderElector LeaderElector
+
 	// The number of workers to spin up for computing PageRank scores. If
 	// not specified, a default value of 1 will be used instead.
 	ComputeWorkers int
@@ -80,8 +95,14 @@ func (cfg *Config) validate() error {
 // Service implements the PageRank calculator component.
 type Service struct {
 	cfg        Config
+	graphDB    graph.ContextGraph
+	indexDB    index.ContextIndexer
 	calculator *calculator.Calculator
 
+	mu       sync.RWMutex
+	lastPass time.Time
+	lastSize int
+
 	logger *log.Logger
 }
 
@@ -118,6 +139,8 @@ func NewWithConfig(cfg Config) (*Service, error) {
 	logger := log.New(os.Stdout, "[pagerank]", log.Ldate|log.Ltime)
 	return &Service{
 		cfg:        cfg,
+		graphDB:    graph.WithContext(cfg.GraphAPI),
+		indexDB:    index.WithContext(cfg.IndexAPI),
 		calculator: calculator,
 		logger:     logger,
 	}, nil
@@ -150,18 +173,22 @@ func (svc *Service) Run(ctx context.Context) error {
 			return nil
 		case <-timer.C:
 			svc.logger.Println("[INFO] pagerank iteration start")
-			curPartition, _, err := svc.cfg.PartitionDetector.PartitionInfo()
+			leader, err := svc.isLeader()
 			if err != nil {
 				if errors.Is(err, partition.ErrNoPartitionDataAvailableYet) {
 					svc.logger.Println("[WARN] deferring PageRank update pass: partition data not yet available")
+					timer.Reset(svc.cfg.UpdateInterval)
 					continue
 				}
 				return err
 			}
 
-			if curPartition != 0 {
+			// leadership may change between passes,
+			// so standby keep waiting instead of quit.
+			if !leader {
 				svc.logger.Println("[INFO] service can only run on the leader of the application cluster")
-				return nil
+				timer.Reset(svc.cfg.UpdateInterval)
+				continue
 			}
 
 			if err := svc.updateGraphScores(ctx); err != nil {
@@ -172,6 +199,50 @@ func (svc *Service) Run(ctx context.Context) error {
 	}
 }
 
+func (svc *Service) isLeader() (bool, error) {
+	if svc.cfg.LeaderElector != nil {
+		return svc.cfg.LeaderElector.IsLeader(), nil
+	}
+	curPartition, _, err := svc.cfg.PartitionDetector.PartitionInfo()
+	if err != nil {
+		return false, err
+	}
+	return curPartition == 0, nil
+}
+
+// Status describe leadership and the last pass of the service
+type Status struct {
+	Leader         string    `json:"leader"`
+	IsLeader       bool      `json:"is_leader"`
+	LastPassAt     time.Time `json:"last_pass_at"`
+	LastPassVertex int       `json:"last_pass_vertices"`
+}
+
+// Status return the current leader and the last pass computed by this instance,
+// Leader is empty if there is no elector or nobody hold the leadership.
+func (svc *Service) Status() (*Status, error) {
+	var status Status
+	if svc.cfg.LeaderElector != nil {
+		leader, err := svc.cfg.LeaderElector.Leader()
+		if err != nil {
+			return nil, err
+		}
+		status.Leader = leader
+	}
+
+	isLeader, err := svc.isLeader()
+	if err != nil && !errors.Is(err, partition.ErrNoPartitionDataAvailableYet) {
+		return nil, err
+	}
+	status.IsLeader = isLeader
+
+	svc.mu.RLock()
+	status.LastPassAt = svc.lastPass
+	status.LastPassVertex = svc.lastSize
+	svc.mu.RUnlock()
+	return &status, nil
+}
+
 func (svc *Service) updateGraphScores(ctx context.Context) error {
 	svc.logger.Println("[INFO] starting PageRank update pass",
 		"vertice_count", len(svc.calculator.Graph().Vertices()))
@@ -183,10 +254,10 @@ func (svc *Service) updateGraphScores(ctx context.Context) error {
 	if err := svc.calculator.Graph().Reset(); err != nil {
 		svc.logger.Println("reset", err)
 		return err
-	} else if err := svc.loadLinks(uuid.Nil, maxUUID, startAt); err != nil {
+	} else if err := svc.loadLinks(ctx, uuid.Nil, maxUUID, startAt); err != nil {
 		svc.logger.Println("loadlink", err)
 		return err
-	} else if err := svc.loadEdges(uuid.Nil, maxUUID, startAt); err != nil {
+	} else if err := svc.loadEdges(ctx, uuid.Nil, maxUUID, startAt); err != nil {
 		svc.logger.Println("loadedges", err)
 		return err
 	}
@@ -199,8 +270,22 @@ func (svc *Service) updateGraphScores(ctx context.Context) error {
 	}
 	// scoreCalculationTime := time.Since(tick)
 
+	// the lease may expire while computing, the scores of the old leader
+	// would overwrite the ones written by the new leader
+	leader, err := svc.isLeader()
+	if err != nil && !errors.Is(err, partition.ErrNoPartitionDataAvailableYet) {
+		return err
+	}
+	if !leader {
+		svc.logger.Println("[WARN] leadership lost during PageRank update pass, scores are not written")
+		return nil
+	}
+
 	// tick = time.Now()
-	if err := svc.calculator.Scores(svc.persistScore); err != nil {
+	persistScore := func(vertexID string, score float64) error {
+		return svc.persistScore(ctx, vertexID, score)
+	}
+	if err := svc.calculator.Scores(persistScore); err != nil {
 		svc.logger.Println("[ERROR] persist score", err)
 		return err
 	}
@@ -221,20 +306,25 @@ func (svc *Service) updateGraphScores(ctx context.Context) error {
 	// )
 	svc.logger.Printf("[INFO] completed PageRank update pass vertices:%v time:%v", proccessedLink, total_pass_time)
 
+	svc.mu.Lock()
+	svc.lastPass = startAt
+	svc.lastSize = proccessedLink
+	svc.mu.Unlock()
+
 	return nil
 }
 
-func (svc *Service) persistScore(vertexID string, score float64) error {
+func (svc *Service) persistScore(ctx context.Context, vertexID string, score float64) error {
 	linkID, err := uuid.Parse(vertexID)
 	if err != nil {
 		return err
 	}
 
-	return svc.cfg.IndexAPI.UpdateScore(linkID, score)
+	return svc.indexDB.UpdateScoreContext(ctx, linkID, score)
 }
 
-func (svc *Service) loadLinks(fromID, toID uuid.UUID, filter time.Time) error {
-	linkIt, err := svc.cfg.GraphAPI.Links(fromID, toID, filter)
+func (svc *Service) loadLinks(ctx context.Context, fromID, toID uuid.UUID, filter time.Time) error {
+	linkIt, err := svc.graphDB.LinksContext(ctx, fromID, toID, graph.LinkFilter{RetrievedBefore: filter})
 	if err != nil {
 		return err
 	}
@@ -253,8 +343,8 @@ func (svc *Service) loadLinks(fromID, toID uuid.UUID, filter time.Time) error {
 	return linkIt.Close()
 }
 
-func (svc *Service) loadEdges(fromID, toID uuid.UUID, filter time.Time) error {
-	edgeIt, err := svc.cfg.GraphAPI.Edges(fromID, toID, filter)
+func (svc *Service) loadEdges(ctx context.Context, fromID, toID uuid.UUID, filter time.Time) error {
+	edgeIt, err := svc.graphDB.EdgesContext(ctx, fromID, toID, filter)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,196 @@
+// Package bleveindex implements index.Indexer on a persistent scorch index,
+// every document field is stored in the index so nothing kept in memory.
+package bleveindex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// it is like page-size
+const batchSize = 10
+
+var _ index.Indexer = (*bleveIndex)(nil)
+
+// stored form of index.Document, the ID of bleve document is the link ID
+type bleveDoc struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	IndexedAt time.Time `json:"indexed_at"`
+	PageRank  float64   `json:"pagerank"`
+	Language  string    `json:"language"`
+
+	// host of the url and its parent domains, so site filter is a term query
+	Site []string `json:"site"`
+
+	// host of the url, the result is collapsed by it
+	Host string `json:"host"`
+
+	// title and content again without stemming, its term dictionary is the vocabulary
+	Words string `json:"words"`
+
+	// best weighted terms by tf-idf at index time, searched for the related documents
+	Terms []string `json:"terms"`
+}
+
+type bleveIndex struct {
+	// serialize read-modify-write of document,
+	// so concurrent index and score update not lose the score
+	mu sync.Mutex
+
+	idx bleve.Index
+}
+
+// version of newMapping, increase it whenever the mapping change.
+// the mapping of exist index never change, field added later fall back to dynamic mapping,
+// so index created with older mapping is refused by Open and must be rebuilt with Reindex.
+const mappingVersion = 1
+
+// internal key of the mapping version the index created with,
+// index created before the version is stored has none (version 0)
+var mappingVersionKey = []byte("mapping_version")
+
+// ErrOutdatedMapping returned by Open when the index is not created with the current mapping
+var ErrOutdatedMapping = errors.New("index mapping is outdated")
+
+// Open the index at path, it is created if not exist
+func Open(path string) (*bleveIndex, error) {
+	idx, err := bleve.Open(path)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+		idx, err = create(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open bleve index: %v", err)
+	}
+
+	version, err := readMappingVersion(idx)
+	if err != nil {
+		idx.Close()
+		return nil, fmt.Errorf("open bleve index: %v", err)
+	}
+	if version != mappingVersion {
+		idx.Close()
+		return nil, fmt.Errorf("open bleve index: %w, got version %v expect %v, rebuild it with Reindex", ErrOutdatedMapping, version, mappingVersion)
+	}
+	return &bleveIndex{idx: idx}, nil
+}
+
+// create new index with the current mapping
+func create(path string) (bleve.Index, error) {
+	idx, err := bleve.NewUsing(path, newMapping(), scorch.Name, scorch.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := idx.SetInternal(mappingVersionKey, []byte(strconv.Itoa(mappingVersion))); err != nil {
+		idx.Close()
+		return nil, err
+	}
+	return idx, nil
+}
+
+func readMappingVersion(idx bleve.Index) (int, error) {
+	val, err := idx.GetInternal(mappingVersionKey)
+	if err != nil {
+		return 0, fmt.Errorf("read mapping version: %v", err)
+	}
+	if val == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(val))
+	if err != nil {
+		return 0, fmt.Errorf("read mapping version: %v", err)
+	}
+	return version, nil
+}
+
+// title and content analyzed like the english text search of postgreindex,
+// mappingVersion must be increased when it is changed.
+// the other fields only stored or used for sorting.
+// words is only indexed, document indexed before it is added has no vocabulary until reindexed.
+// terms is only stored, they are searched in title and content.
+func newMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = en.AnalyzerName
+
+	url := bleve.NewKeywordFieldMapping()
+	url.IncludeInAll = false
+
+	indexedAt := bleve.NewDateTimeFieldMapping()
+	indexedAt.IncludeInAll = false
+
+	pagerank := bleve.NewNumericFieldMapping()
+	pagerank.IncludeInAll = false
+
+	language := bleve.NewKeywordFieldMapping()
+	language.IncludeInAll = false
+
+	site := bleve.NewKeywordFieldMapping()
+	site.IncludeInAll = false
+	site.Store = false
+
+	host := bleve.NewKeywordFieldMapping()
+	host.IncludeInAll = false
+	host.Store = false
+
+	words := bleve.NewTextFieldMapping()
+	words.Analyzer = simple.Name
+	words.IncludeInAll = false
+	words.IncludeTermVectors = false
+	words.Store = false
+	words.DocValues = false
+
+	terms := bleve.NewKeywordFieldMapping()
+	terms.IncludeInAll = false
+	terms.Index = false
+	terms.DocValues = false
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("url", url)
+	doc.AddFieldMappingsAt("title", text)
+	doc.AddFieldMappingsAt("content", text)
+	doc.AddFieldMappingsAt("indexed_at", indexedAt)
+	doc.AddFieldMappingsAt("pagerank", pagerank)
+	doc.AddFieldMappingsAt("language", language)
+	doc.AddFieldMappingsAt("site", site)
+	doc.AddFieldMappingsAt("host", host)
+	doc.AddFieldMappingsAt("words", words)
+	doc.AddFieldMappingsAt("terms", terms)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	m.DefaultAnalyzer = en.AnalyzerName
+	return m
+}
+
+// Close the index
+func (bi *bleveIndex) Close() error {
+	return bi.idx.Close()
+}
+
+// Backup copy consistent snapshot of the index into dir while it is in use,
+// the copy can be opened with Open.
+func (bi *bleveIndex) Backup(dir string) error {
+	copyable, ok := bi.idx.(bleve.IndexCopyable)
+	if !ok {
+		return fmt.Errorf("backup index: index not support copy")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("backup index: %v", err)
+	}
+	if err := copyable.CopyTo(bleve.FileSystemDirectory(dir)); err != nil {
+		return fmt.Errorf("backup index: %v", err)
+	}
+	return nil
+}
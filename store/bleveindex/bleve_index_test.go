@@ -0,0 +1,590 @@
+package bleveindex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/scorch"
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+func Test_bleve_index(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index")
+	bi, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := make([]*index.Document, 15)
+	for i := range docs {
+		docs[i] = &index.Document{
+			LinkID:  uuid.New(),
+			URL:     fmt.Sprintf("https://example.com/%d", i),
+			Title:   fmt.Sprintf("page %d", i),
+			Content: "gopher running",
+		}
+	}
+	if err := bi.IndexBatch(docs); err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range docs {
+		if err := bi.UpdateScore(doc.LinkID, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reindex keep the score
+	docs[3].Content = "gopher run again"
+	if err := bi.Index(docs[3]); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := bi.Lookup(docs[3].LinkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PageRank != 3 || stored.Content != docs[3].Content || stored.URL != docs[3].URL || stored.IndexedAt.IsZero() {
+		t.Fatalf("\ngot:%+v", stored)
+	}
+	if _, err := bi.Lookup(uuid.New()); err == nil {
+		t.Fatal("expect not found error")
+	}
+
+	// reopen and search page by page, highest score first
+	if err := bi.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if bi, err = Open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	tests := []struct {
+		query  index.Query
+		expect []int
+	}{
+		{index.Query{Expression: "runs"}, []int{14, 13, 12, 11, 10, 9, 8, 7, 6, 5}},
+		{index.Query{Expression: "runs", Offset: 10}, []int{4, 3, 2, 1, 0}},
+		{index.Query{Expression: "page 7", Type: index.QueryTypePhrase}, []int{7}},
+		{index.Query{Expression: " ", Offset: 13}, []int{1, 0}},
+	}
+	for _, test := range tests {
+		it, err := bi.Search(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for it.Next() {
+			doc := it.Document()
+			got = append(got, int(doc.PageRank))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Fatalf("\nquery:%+v\ngot:%v\nexpect:%v", test.query, got, test.expect)
+		}
+	}
+
+	// backup is a working index
+	backup := filepath.Join(t.TempDir(), "backup")
+	if err := bi.Backup(backup); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := Open(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	it, err := restored.Search(index.Query{Expression: "gopher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it.TotalCount() != uint64(len(docs)) {
+		t.Fatalf("\ngot:%v\nexpect:%v", it.TotalCount(), len(docs))
+	}
+}
+
+func Test_bleve_index_cursor(t *testing.T) {
+	bi, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	// same score and relevance on many documents, the id break the tie
+	docs := make([]*index.Document, 25)
+	for i := range docs {
+		docs[i] = &index.Document{LinkID: uuid.New(), Content: "gopher"}
+	}
+	if err := bi.IndexBatch(docs); err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range docs {
+		if err := bi.UpdateScore(doc.LinkID, float64(i%3)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[uuid.UUID]bool{}
+	query := index.Query{Expression: "gopher"}
+	for pages := 0; ; pages++ {
+		if pages > len(docs) {
+			t.Fatal("cursor does not move forward")
+		}
+		it, err := bi.Search(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if it.Cursor() != "" {
+			t.Fatalf("\ngot:%v\nexpect empty cursor", it.Cursor())
+		}
+		n := 0
+		for ; it.Next(); n++ {
+			doc := it.Document()
+			if seen[doc.LinkID] {
+				t.Fatalf("document %v returned twice", doc.LinkID)
+			}
+			seen[doc.LinkID] = true
+			query.Cursor = it.Cursor()
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if len(seen) != len(docs) {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(seen), len(docs))
+	}
+
+	if _, err := bi.Search(index.Query{Cursor: "not a cursor"}); !errors.Is(err, index.ErrInvalidCursor) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, index.ErrInvalidCursor)
+	}
+}
+
+func Test_bleve_index_filters(t *testing.T) {
+	bi, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	docs := []*index.Document{
+		{LinkID: uuid.New(), URL: "https://pkg.go.dev/net/http", Title: "http package", Content: "gopher server", Language: "en"},
+		{LinkID: uuid.New(), URL: "https://go.dev/blog", Title: "go blog", Content: "gopher news", Language: "en"},
+		{LinkID: uuid.New(), URL: "https://golang.org/doc?q=*", Title: "dokumentasi", Content: "gopher server", Language: "id"},
+	}
+	if err := bi.IndexBatch(docs); err != nil {
+		t.Fatal(err)
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	tests := []struct {
+		query  index.Query
+		expect uint64
+	}{
+		{index.Query{Expression: "gopher"}, 3},
+		{index.Query{Expression: "gopher", Filters: index.Filters{Site: "go.dev"}}, 2},
+		{index.Query{Filters: index.Filters{Site: "GO.dev"}}, 2},
+		{index.Query{Filters: index.Filters{Site: "o.dev"}}, 0},
+		{index.Query{Filters: index.Filters{InURL: "/doc"}}, 1},
+		{index.Query{Filters: index.Filters{InURL: "?q=*"}}, 1},
+		{index.Query{Expression: "gopher", Filters: index.Filters{InTitle: "blog"}}, 1},
+		{index.Query{Expression: "gopher", Filters: index.Filters{Language: "id"}}, 1},
+		{index.Query{Expression: "gopher", Filters: index.Filters{IndexedAfter: hourAgo}}, 3},
+		{index.Query{Expression: "gopher", Filters: index.Filters{IndexedBefore: hourAgo}}, 0},
+		{index.Query{Expression: "gopher", Exclude: []string{"server"}}, 1},
+		{index.Query{Exclude: []string{"gopher news"}}, 2},
+		{index.Query{Phrases: []string{"gopher news"}}, 1},
+		{index.Query{Type: index.QueryTypePrefix, Expression: "serv*"}, 2},
+		{index.Query{Type: index.QueryTypePrefix, Expression: "h?tp"}, 1},
+		{index.Query{Type: index.QueryTypeFuzzy, Expression: "gophr"}, 3},
+		{index.Query{Type: index.QueryTypeFuzzy, Expression: "gpr", Fuzziness: 1}, 0},
+		{index.Query{Type: index.QueryTypeBoolean, Boolean: &index.Clause{Op: index.ClauseAnd, Clauses: []*index.Clause{
+			{Op: index.ClauseOr, Clauses: []*index.Clause{{Text: "news"}, {Text: "http"}}},
+			{Op: index.ClauseNot, Clauses: []*index.Clause{{Text: "server"}}},
+		}}}, 1},
+	}
+	for _, test := range tests {
+		it, err := bi.Search(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if it.TotalCount() != test.expect {
+			t.Fatalf("\nquery:%+v\ngot:%v\nexpect:%v", test.query, it.TotalCount(), test.expect)
+		}
+	}
+
+	doc, err := bi.Lookup(docs[2].LinkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Language != "id" {
+		t.Fatalf("\ngot:%v\nexpect:%v", doc.Language, "id")
+	}
+
+	// vocabulary is not stemmed
+	vocabulary := map[string]int{}
+	err = bi.Vocabulary(context.Background(), func(word string, docCount int) error {
+		vocabulary[word] = docCount
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vocabulary["gopher"] != 3 || vocabulary["news"] != 1 || vocabulary["dokumentasi"] != 1 {
+		t.Fatalf("\ngot:%v", vocabulary)
+	}
+
+	titles := map[string]float64{}
+	err = bi.Titles(context.Background(), func(title string, pageRank float64) error {
+		titles[title] = pageRank
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 3 || titles["go blog"] != 0 {
+		t.Fatalf("\ngot:%v", titles)
+	}
+	if err := bi.UpdateScore(docs[1].LinkID, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	err = bi.Titles(context.Background(), func(title string, pageRank float64) error {
+		titles[title] = pageRank
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if titles["go blog"] != 0.5 {
+		t.Fatalf("\ngot:%v\nexpect:%v", titles["go blog"], 0.5)
+	}
+}
+
+func Test_bleve_index_ranking(t *testing.T) {
+	bi, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	popular := &index.Document{LinkID: uuid.New(), URL: "https://example.com/a/b/c", Title: "news", Content: "daily news about everything, once mention gopher"}
+	relevant := &index.Document{LinkID: uuid.New(), URL: "https://go.dev/gopher", Title: "the gopher", Content: "gopher gopher gopher"}
+	if err := bi.IndexBatch([]*index.Document{popular, relevant}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bi.UpdateScore(popular.LinkID, 0.001); err != nil {
+		t.Fatal(err)
+	}
+	if err := bi.UpdateScore(relevant.LinkID, 0.0005); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ranking *index.Ranking
+		expect  []uuid.UUID
+	}{
+		{nil, []uuid.UUID{relevant.LinkID, popular.LinkID}},
+		{&index.Ranking{PageRank: 1}, []uuid.UUID{popular.LinkID, relevant.LinkID}},
+	}
+	for _, test := range tests {
+		it, err := bi.Search(index.Query{Expression: "gopher", Ranking: test.ranking, Explain: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []uuid.UUID
+		for it.Next() {
+			got = append(got, it.Document().LinkID)
+
+			e := it.(index.ExplainIterator).Explain()
+			if e == nil || e.Score <= 0 || e.Relevance <= 0 {
+				t.Fatalf("\ngot:%+v\nexpect explanation", e)
+			}
+			if it.Document().LinkID == relevant.LinkID && (e.TitleMatch != 1 || e.URLDepth != 0.5) {
+				t.Fatalf("\ngot:%+v\nexpect title match and url depth 1", e)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Fatalf("\nranking:%+v\ngot:%v\nexpect:%v", test.ranking, got, test.expect)
+		}
+	}
+}
+
+func Test_bleve_index_highlight(t *testing.T) {
+	bi, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	doc := &index.Document{
+		LinkID:  uuid.New(),
+		Title:   "gopher",
+		Content: strings.Repeat("nothing to see here. ", 20) + "the gopher was running fast." + strings.Repeat(" still nothing.", 20),
+	}
+	if err := bi.Index(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expression string
+		expect     []string
+	}{
+		// stemmed match is highlighted
+		{"runs", []string{"running"}},
+		{"gopher run", []string{"gopher", "running"}},
+		// nothing matched in content, the beginning is returned
+		{" ", nil},
+	}
+	for _, test := range tests {
+		it, err := bi.Search(index.Query{Expression: test.expression, HighlightLength: 90})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !it.Next() {
+			t.Fatalf("expect document for %q", test.expression)
+		}
+		if it.Document().Content != "" {
+			t.Fatalf("\ngot:%v\nexpect empty content", it.Document().Content)
+		}
+		hl := it.Highlight()
+		if hl == nil || len(hl.Fragments) == 0 {
+			t.Fatalf("\ngot:%+v\nexpect fragments", hl)
+		}
+		var got []string
+		for _, f := range hl.Fragments {
+			if len(f.Text) > 90 {
+				t.Fatalf("\ngot:%v\nexpect shorter fragment", f.Text)
+			}
+			for _, m := range f.Matches {
+				got = append(got, f.Text[m.Start:m.End])
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Fatalf("\nexpression:%q\ngot:%v\nexpect:%v", test.expression, got, test.expect)
+		}
+		if test.expect == nil && !strings.HasPrefix(doc.Content, hl.Fragments[0].Text) {
+			t.Fatalf("\ngot:%v\nexpect beginning of content", hl.Fragments[0].Text)
+		}
+	}
+}
+
+func Test_bleve_index_collapse(t *testing.T) {
+	bi, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	// five documents of four hosts, ten hosts with single document and one without host
+	var docs []*index.Document
+	for i := 0; i < 20; i++ {
+		docs = append(docs, &index.Document{LinkID: uuid.New(), URL: fmt.Sprintf("https://h%d.com/%d", i%4, i), Content: "gopher"})
+	}
+	for i := 0; i < 10; i++ {
+		docs = append(docs, &index.Document{LinkID: uuid.New(), URL: fmt.Sprintf("https://u%d.com", i), Content: "gopher"})
+	}
+	docs = append(docs, &index.Document{LinkID: uuid.New(), Content: "gopher"})
+	if err := bi.IndexBatch(docs); err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range docs {
+		if err := bi.UpdateScore(doc.LinkID, float64(i)/1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// by cursor and by offset give the same pages
+	var byCursor, byOffset []uuid.UUID
+	hosts := map[string]int{}
+	query := index.Query{Expression: "gopher", CollapseByHost: 2}
+	for pages := 0; ; pages++ {
+		if pages > len(docs) {
+			t.Fatal("cursor does not move forward")
+		}
+		it, err := bi.Search(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if it.TotalCount() != uint64(len(docs)) {
+			t.Fatalf("\ngot:%v\nexpect:%v", it.TotalCount(), len(docs))
+		}
+		n := 0
+		for ; it.Next(); n++ {
+			doc := it.Document()
+			byCursor = append(byCursor, doc.LinkID)
+			host := index.Host(doc.URL)
+			hosts[host]++
+
+			expect := 1
+			if strings.HasPrefix(host, "h") {
+				expect = 5
+			}
+			if count := it.(index.CollapseIterator).HostCount(); count != expect {
+				t.Fatalf("\nhost:%v\ngot:%v\nexpect:%v", host, count, expect)
+			}
+			query.Cursor = it.Cursor()
+		}
+		if n == 0 {
+			break
+		}
+	}
+	for offset := 0; ; offset += batchSize {
+		it, err := bi.Search(index.Query{Expression: "gopher", CollapseByHost: 2, Offset: uint64(offset)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for ; it.Next(); n++ {
+			byOffset = append(byOffset, it.Document().LinkID)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if len(byCursor) != 4*2+10+1 || fmt.Sprint(byCursor) != fmt.Sprint(byOffset) {
+		t.Fatalf("\ncursor:%v\noffset:%v", byCursor, byOffset)
+	}
+	for host, n := range hosts {
+		if n > 2 {
+			t.Fatalf("\nhost:%v\ngot:%v\nexpect at most 2", host, n)
+		}
+	}
+	// the best documents of the host are kept
+	if hosts["h3.com"] != 2 || byCursor[0] != docs[len(docs)-1].LinkID {
+		t.Fatalf("\ngot:%v", hosts)
+	}
+
+	// the cursor carry the host counts, without them the page is rescanned the same
+	it, err := bi.Search(index.Query{Expression: "gopher", CollapseByHost: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for it.Next() {
+	}
+	cursor, err := index.ParseCursor(it.Cursor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cursor.HostCounts) == 0 {
+		t.Fatal("expect host counts in the cursor of collapsed result")
+	}
+	cursor.HostCounts = nil
+	it, err = bi.Search(index.Query{Expression: "gopher", CollapseByHost: 2, Cursor: cursor.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rescanned []uuid.UUID
+	for it.Next() {
+		rescanned = append(rescanned, it.Document().LinkID)
+	}
+	if expect := byCursor[batchSize : batchSize+len(rescanned)]; len(rescanned) == 0 || fmt.Sprint(rescanned) != fmt.Sprint(expect) {
+		t.Fatalf("\ngot:%v\nexpect:%v", rescanned, expect)
+	}
+}
+
+func Test_bleve_index_related(t *testing.T) {
+	bi, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	gopher := &index.Document{LinkID: uuid.New(), Title: "gopher", Content: "the gopher digs tunnels, gophers live in burrows"}
+	similar := &index.Document{LinkID: uuid.New(), Title: "burrow", Content: "a gopher burrow has many tunnels"}
+	other := &index.Document{LinkID: uuid.New(), Title: "cooking", Content: "the recipe of pasta with tomato sauce"}
+	if err := bi.IndexBatch([]*index.Document{gopher, similar, other}); err != nil {
+		t.Fatal(err)
+	}
+	// terms survive the score update
+	if err := bi.UpdateScore(gopher.LinkID, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := bi.lookup(gopher.LinkID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terms := stored[gopher.LinkID].terms; len(terms) == 0 || terms[0] != "gopher" {
+		t.Fatalf("\ngot:%v\nexpect:%v", terms, "gopher first")
+	}
+
+	related, err := bi.Related(gopher.LinkID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(related) != 1 || related[0].LinkID != similar.LinkID {
+		t.Fatalf("\ngot:%v\nexpect:%v", related, []*index.Document{similar})
+	}
+
+	if _, err := bi.Related(uuid.New(), 10); !errors.Is(err, index.ErrNotFound) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, index.ErrNotFound)
+	}
+}
+
+func Test_bleve_index_reindex(t *testing.T) {
+	// index created before the mapping is versioned, every field is mapped dynamically
+	src := filepath.Join(t.TempDir(), "index")
+	old, err := bleve.NewUsing(src, bleve.NewIndexMapping(), scorch.Name, scorch.Name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &index.Document{
+		LinkID:    uuid.New(),
+		URL:       "https://pkg.go.dev/net/http",
+		Title:     "http package",
+		Content:   "gopher server",
+		IndexedAt: time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		PageRank:  0.5,
+	}
+	if err := old.Index(doc.LinkID.String(), bleveDoc{
+		URL:       doc.URL,
+		Title:     doc.Title,
+		Content:   doc.Content,
+		IndexedAt: doc.IndexedAt,
+		PageRank:  doc.PageRank,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(src); !errors.Is(err, ErrOutdatedMapping) {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, ErrOutdatedMapping)
+	}
+
+	dst := filepath.Join(t.TempDir(), "index")
+	if err := Reindex(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reindex(src, dst); err == nil {
+		t.Fatal("expect error when the destination exist")
+	}
+
+	bi, err := Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bi.Close()
+
+	stored, err := bi.Lookup(doc.LinkID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PageRank != doc.PageRank || !stored.IndexedAt.Equal(doc.IndexedAt) || stored.Content != doc.Content {
+		t.Fatalf("\ngot:%+v\nexpect:%+v", stored, doc)
+	}
+
+	// site is derived again from the url
+	it, err := bi.Search(index.Query{Filters: index.Filters{Site: "go.dev"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it.TotalCount() != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", it.TotalCount(), 1)
+	}
+}
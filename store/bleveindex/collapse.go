@@ -0,0 +1,147 @@
+package bleveindex
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// hits fetched per request while scanning collapsed result
+const collapseScanSize = 100
+
+// collapseScan keep the host of every scanned hit,
+// so the cursor of the page hit carry the documents of every host up to it.
+type collapseScan struct {
+	// counts before the scanned hits, from the cursor or the rescanned prefix
+	base map[uint64]int
+
+	// index.HostKey of the scanned hits in result order
+	scanned []uint64
+
+	// position in scanned of the page hits by id
+	pos map[string]int
+
+	// counts are capped, the host beyond it is collapsed anyway
+	limit int
+}
+
+// counts of index.Cursor.HostCounts at the page hit
+func (cs *collapseScan) countsAt(id string) map[uint64]int {
+	counts := make(map[uint64]int, len(cs.base)+len(cs.scanned))
+	for key, count := range cs.base {
+		counts[key] = min(count, cs.limit)
+	}
+	for _, key := range cs.scanned[:cs.pos[id]+1] {
+		if counts[key] < cs.limit {
+			counts[key]++
+		}
+	}
+	return counts
+}
+
+// searchCollapsed return the page of req without the documents beyond the best
+// q.CollapseByHost of their host, and the count of documents of every host in the page.
+// the rank of document in its host depend on every document before it, the cursor carry
+// the counts of the hosts before it so the scan resume from there. cursor without the counts
+// (too many hosts) is rescanned from the best document.
+func (bi *bleveIndex) searchCollapsed(req *bleve.SearchRequest, q index.Query, after *index.Cursor) (*bleve.SearchResult, map[string]int, *collapseScan, error) {
+	scan := *req
+	scan.From, scan.Size, scan.SearchAfter = 0, collapseScanSize, nil
+
+	cs := &collapseScan{base: make(map[uint64]int), pos: make(map[string]int), limit: q.CollapseByHost}
+	seen := make(map[uint64]int)
+	resume := after != nil && after.HostCounts != nil
+	if resume {
+		scan.SearchAfter = req.SearchAfter
+		for key, count := range after.HostCounts {
+			seen[key], cs.base[key] = count, count
+		}
+	}
+
+	skip := int(q.Offset)
+	if after != nil {
+		skip = 0
+	}
+
+	page := &bleve.SearchResult{}
+	for len(page.Hits) < req.Size {
+		res, err := bi.idx.Search(&scan)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		// like the other queries, the total is counted before collapsing
+		page.Total = res.Total
+
+		for _, hit := range res.Hits {
+			key := index.HostKey(hitHost(hit))
+			seen[key]++
+			if after != nil && !resume && !isAfter(hit, after) {
+				cs.base[key]++
+				continue
+			}
+			cs.scanned = append(cs.scanned, key)
+			if seen[key] > q.CollapseByHost {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			cs.pos[hit.ID] = len(cs.scanned) - 1
+			if page.Hits = append(page.Hits, hit); len(page.Hits) == req.Size {
+				break
+			}
+		}
+		if len(res.Hits) < scan.Size {
+			break
+		}
+		scan.SearchAfter = append([]string(nil), res.Hits[len(res.Hits)-1].Sort...)
+	}
+
+	hostCounts := make(map[string]int)
+	for _, hit := range page.Hits {
+		host := hitHost(hit)
+		if _, ok := hostCounts[host]; ok {
+			continue
+		}
+		count, err := bi.countHost(scan.Query, hit, host)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		hostCounts[host] = count
+	}
+	return page, hostCounts, cs, nil
+}
+
+// matched documents of the host of hit
+func (bi *bleveIndex) countHost(matched query.Query, hit *search.DocumentMatch, host string) (int, error) {
+	if host == hit.ID {
+		return 1, nil
+	}
+	hostQuery := bleve.NewTermQuery(host)
+	hostQuery.SetField("host")
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(matched, hostQuery), 0, 0, false)
+	res, err := bi.idx.Search(req)
+	if err != nil {
+		return 0, fmt.Errorf("count documents of %v: %v", host, err)
+	}
+	return int(res.Total), nil
+}
+
+// collapse key of the hit, document without host is a group of its own
+func hitHost(hit *search.DocumentMatch) string {
+	rawURL, _ := hit.Fields["url"].(string)
+	if host := index.Host(rawURL); host != "" {
+		return host
+	}
+	return hit.ID
+}
+
+// the hit is ordered after the cursor, the sort is rank score then id both descending
+func isAfter(hit *search.DocumentMatch, after *index.Cursor) bool {
+	score, id := encodeScore(after.Score), after.LinkID.String()
+	return hit.Sort[0] < score || (hit.Sort[0] == score && hit.ID < id)
+}
@@ -0,0 +1,64 @@
+package bleveindex
+
+import (
+	"sort"
+
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/plain"
+	simplefragmenter "github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// highlighter cut the content around the term locations of the hit with the pieces of
+// bleve simple highlighter, the locations are of the analyzed terms so stemmed match is found.
+// unlike the simple highlighter the fragments are kept in content order.
+type highlighter struct {
+	fragmenter highlight.Fragmenter
+	formatter  highlight.FragmentFormatter
+}
+
+func newHighlighter(length int) *highlighter {
+	return &highlighter{
+		fragmenter: simplefragmenter.NewFragmenter(max(length/index.MaxFragments, 1)),
+		formatter:  plain.NewFragmentFormatter(index.MatchStart, index.MatchEnd),
+	}
+}
+
+// best fragments of the content field, the hit must be searched with locations
+func (h *highlighter) highlight(hit *search.DocumentMatch, content string) *index.Highlight {
+	tlm := hit.Locations["content"]
+	locations := highlight.OrderTermLocations(tlm)
+	scorer := simple.NewFragmentScorer(tlm)
+
+	candidates := h.fragmenter.Fragment([]byte(content), locations)
+	for _, f := range candidates {
+		scorer.Score(f)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	var best []*highlight.Fragment
+	for _, f := range candidates {
+		if len(best) == index.MaxFragments {
+			break
+		}
+		overlap := false
+		for _, b := range best {
+			overlap = overlap || f.Overlaps(b)
+		}
+		if !overlap {
+			best = append(best, f)
+		}
+	}
+	sort.Slice(best, func(i, j int) bool { return best[i].Start < best[j].Start })
+
+	locations.MergeOverlapping()
+	hl := &index.Highlight{}
+	for _, f := range best {
+		if f.End > f.Start {
+			hl.Fragments = append(hl.Fragments, index.ParseFragment(h.formatter.Format(f, locations)))
+		}
+	}
+	return hl
+}
@@ -0,0 +1,116 @@
+package bleveindex
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// Index implements index.Indexer.
+// the pagerank score of existing document is preserved
+func (bi *bleveIndex) Index(doc *index.Document) error {
+	return bi.IndexBatch([]*index.Document{doc})
+}
+
+// IndexBatch implements index.Indexer.
+func (bi *bleveIndex) IndexBatch(docs []*index.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if doc.LinkID == uuid.Nil {
+			return fmt.Errorf("indexer insert documents: uuid cannot be nil")
+		}
+		ids = append(ids, doc.LinkID.String())
+	}
+
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+
+	exist, err := bi.lookup(ids...)
+	if err != nil {
+		return fmt.Errorf("indexer insert documents: %v", err)
+	}
+
+	now := time.Now().UTC()
+	batch := bi.idx.NewBatch()
+	for _, doc := range docs {
+		doc.IndexedAt = now
+		if origin, ok := exist[doc.LinkID]; ok {
+			doc.PageRank = origin.PageRank
+		}
+		terms, err := bi.topTerms(doc)
+		if err != nil {
+			return fmt.Errorf("indexer insert documents: %v", err)
+		}
+		if err := batch.Index(doc.LinkID.String(), toBleveDoc(doc, terms)); err != nil {
+			return fmt.Errorf("indexer insert documents: %v", err)
+		}
+	}
+
+	if err := bi.idx.Batch(batch); err != nil {
+		return fmt.Errorf("indexer insert documents: %v, batch size: %v", err, len(docs))
+	}
+	return nil
+}
+
+// UpdateScore implements index.Indexer.
+// unknown document is ignored
+func (bi *bleveIndex) UpdateScore(linkID uuid.UUID, score float64) error {
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+
+	exist, err := bi.lookup(linkID.String())
+	if err != nil {
+		return fmt.Errorf("update pagerank document: %v", err)
+	}
+	doc, ok := exist[linkID]
+	if !ok {
+		return nil
+	}
+
+	doc.PageRank = score
+	if err := bi.idx.Index(linkID.String(), toBleveDoc(doc.Document, doc.terms)); err != nil {
+		return fmt.Errorf("update pagerank document: %v", err)
+	}
+	return nil
+}
+
+func toBleveDoc(doc *index.Document, terms []string) bleveDoc {
+	return bleveDoc{
+		URL:       doc.URL,
+		Title:     doc.Title,
+		Content:   doc.Content,
+		IndexedAt: doc.IndexedAt,
+		PageRank:  doc.PageRank,
+		Language:  doc.Language,
+		Site:      siteDomains(doc.URL),
+		Host:      index.Host(doc.URL),
+		Words:     doc.Title + " " + doc.Content,
+		Terms:     terms,
+	}
+}
+
+// host of the url followed by its parent domains,
+// "https://pkg.go.dev/net" give [pkg.go.dev go.dev dev]
+func siteDomains(rawURL string) []string {
+	host := index.Host(rawURL)
+	if host == "" {
+		return nil
+	}
+
+	domains := []string{host}
+	for {
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || parent == "" {
+			return domains
+		}
+		domains = append(domains, parent)
+		host = parent
+	}
+}
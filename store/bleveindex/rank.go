@@ -0,0 +1,158 @@
+package bleveindex
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ search.SearchSort = (*rankSort)(nil)
+
+// rankSort order hits by the score of index.Ranking, the raw relevance is the bleve score.
+// the other factors are read from the doc values of the fields,
+// so the score is computed while collecting without loading stored fields.
+type rankSort struct {
+	ranking index.Ranking
+
+	// reference time of freshness
+	now time.Time
+
+	// analyzed terms of the expression, title match when all of them in the title
+	terms    [][]byte
+	analyzer analysis.Analyzer
+
+	desc bool
+
+	// factors of the document being visited, reset by Value
+	pagerank   float64
+	indexedAt  time.Time
+	urlDepth   int
+	titleTerms []bool
+}
+
+func newRankSort(ranking index.Ranking, now time.Time, analyzer analysis.Analyzer, expression string) *rankSort {
+	rs := &rankSort{ranking: ranking, now: now, analyzer: analyzer, desc: true}
+	for _, token := range analyzer.Analyze([]byte(expression)) {
+		rs.terms = append(rs.terms, token.Term)
+	}
+	rs.titleTerms = make([]bool, len(rs.terms))
+	return rs
+}
+
+// UpdateVisitor implements search.SearchSort.
+func (rs *rankSort) UpdateVisitor(field string, term []byte) {
+	switch field {
+	case "pagerank":
+		if v, ok := prefixCodedInt64(term); ok {
+			rs.pagerank = numeric.Int64ToFloat64(v)
+		}
+	case "indexed_at":
+		if v, ok := prefixCodedInt64(term); ok {
+			rs.indexedAt = time.Unix(0, v)
+		}
+	case "url":
+		rs.urlDepth = index.URLDepth(string(term))
+	case "title":
+		for i, t := range rs.terms {
+			if bytes.Equal(t, term) {
+				rs.titleTerms[i] = true
+			}
+		}
+	}
+}
+
+// Value implements search.SearchSort.
+func (rs *rankSort) Value(d *search.DocumentMatch) string {
+	titleMatch := len(rs.terms) > 0
+	for i := range rs.titleTerms {
+		titleMatch = titleMatch && rs.titleTerms[i]
+		rs.titleTerms[i] = false
+	}
+
+	e := rs.ranking.Explain(index.RankFactors{
+		Relevance:  d.Score,
+		PageRank:   rs.pagerank,
+		IndexedAt:  rs.indexedAt,
+		TitleMatch: titleMatch,
+		URLDepth:   rs.urlDepth,
+	}, rs.now)
+	rs.pagerank, rs.indexedAt, rs.urlDepth = 0, time.Time{}, 0
+	return encodeScore(e.Score)
+}
+
+// explain the score of document that returned in the result,
+// factors are taken from the stored fields so the sort state is not touched.
+func (rs *rankSort) explain(hit *search.DocumentMatch, doc *index.Document) *index.Explanation {
+	titleMatch := len(rs.terms) > 0
+	titleTokens := rs.analyzer.Analyze([]byte(doc.Title))
+	for _, t := range rs.terms {
+		found := false
+		for _, token := range titleTokens {
+			if bytes.Equal(t, token.Term) {
+				found = true
+				break
+			}
+		}
+		titleMatch = titleMatch && found
+	}
+
+	e := rs.ranking.Explain(index.RankFactors{
+		Relevance:  hit.Score,
+		PageRank:   doc.PageRank,
+		IndexedAt:  doc.IndexedAt,
+		TitleMatch: titleMatch,
+		URLDepth:   index.URLDepth(doc.URL),
+	}, rs.now)
+	// report the score the result is ordered by
+	e.Score = decodeScore(hit.Sort[0])
+	return e
+}
+
+// Descending implements search.SearchSort.
+func (rs *rankSort) Descending() bool { return rs.desc }
+
+// RequiresDocID implements search.SearchSort.
+func (rs *rankSort) RequiresDocID() bool { return false }
+
+// RequiresScoring implements search.SearchSort.
+// the sort value is compared instead of the raw score, scoring still happen for every hit.
+func (rs *rankSort) RequiresScoring() bool { return false }
+
+// RequiresFields implements search.SearchSort.
+func (rs *rankSort) RequiresFields() []string {
+	return []string{"pagerank", "indexed_at", "url", "title"}
+}
+
+// Reverse implements search.SearchSort.
+func (rs *rankSort) Reverse() { rs.desc = !rs.desc }
+
+// Copy implements search.SearchSort.
+func (rs *rankSort) Copy() search.SearchSort {
+	rv := *rs
+	rv.titleTerms = make([]bool, len(rs.terms))
+	return &rv
+}
+
+// numeric doc value is indexed in many precision, only the full one is the value
+func prefixCodedInt64(term []byte) (int64, bool) {
+	pc := numeric.PrefixCoded(term)
+	if shift, err := pc.Shift(); err != nil || shift != 0 {
+		return 0, false
+	}
+	v, err := pc.Int64()
+	return v, err == nil
+}
+
+// score as string that sort in the same order
+func encodeScore(score float64) string {
+	return string(numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(score), 0))
+}
+
+func decodeScore(value string) float64 {
+	v, _ := prefixCodedInt64([]byte(value))
+	return numeric.Int64ToFloat64(v)
+}
@@ -0,0 +1,77 @@
+package bleveindex
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// number of documents copied in one batch by Reindex
+const reindexBatchSize = 100
+
+// Reindex copy every document of the index at src into new index at dst created with the current mapping,
+// the index at src is not modified and can be created with any older mapping.
+// the fields that are not stored (site, host and words) are derived again from the stored url, title and content,
+// so the pagerank score and indexed time of the documents are kept.
+//
+// the index must not be in use, rebuild it then move the new index in place of the old one:
+//
+//	Reindex("index.bleve", "index.bleve.new")
+//	mv index.bleve index.bleve.old && mv index.bleve.new index.bleve
+func Reindex(src, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("reindex: %v already exist", dst)
+	}
+
+	old, err := bleve.Open(src)
+	if err != nil {
+		return fmt.Errorf("reindex: %v", err)
+	}
+	defer old.Close()
+
+	bi, err := Open(dst)
+	if err != nil {
+		return fmt.Errorf("reindex: %v", err)
+	}
+	defer bi.Close()
+
+	// the terms of document indexed before they are stored are weighted against the old index
+	source := &bleveIndex{idx: old}
+
+	var after []string
+	for {
+		req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), reindexBatchSize, 0, false)
+		req.Fields = []string{"*"}
+		req.SortBy([]string{"_id"})
+		req.SearchAfter = after
+		res, err := old.Search(req)
+		if err != nil {
+			return fmt.Errorf("reindex: %v", err)
+		}
+		if len(res.Hits) == 0 {
+			return nil
+		}
+
+		batch := bi.idx.NewBatch()
+		for _, hit := range res.Hits {
+			doc, err := hitDocument(hit)
+			if err != nil {
+				return fmt.Errorf("reindex: %v", err)
+			}
+			terms := storedTerms(hit.Fields["terms"])
+			if len(terms) == 0 {
+				if terms, err = source.topTerms(doc); err != nil {
+					return fmt.Errorf("reindex: %v", err)
+				}
+			}
+			if err := batch.Index(hit.ID, toBleveDoc(doc, terms)); err != nil {
+				return fmt.Errorf("reindex: %v", err)
+			}
+		}
+		if err := bi.idx.Batch(batch); err != nil {
+			return fmt.Errorf("reindex: %v", err)
+		}
+		after = []string{res.Hits[len(res.Hits)-1].ID}
+	}
+}
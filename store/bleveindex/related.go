@@ -0,0 +1,162 @@
+package bleveindex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ index.RelatedIndexer = (*bleveIndex)(nil)
+
+// Related implements index.RelatedIndexer.
+// the stored top terms of the document are searched in title and content,
+// the term with higher weight is boosted more.
+func (bi *bleveIndex) Related(linkID uuid.UUID, n int) ([]*index.Document, error) {
+	return bi.RelatedContext(context.Background(), linkID, n)
+}
+
+// RelatedContext implements index.RelatedIndexer.
+func (bi *bleveIndex) RelatedContext(ctx context.Context, linkID uuid.UUID, n int) ([]*index.Document, error) {
+	docs, err := bi.lookup(linkID.String())
+	if err != nil {
+		return nil, fmt.Errorf("indexer related documents: %v", err)
+	}
+	source, ok := docs[linkID]
+	if !ok {
+		return nil, fmt.Errorf("indexer related documents: %w", index.ErrNotFound)
+	}
+
+	// document indexed before the terms is stored has none until reindexed
+	terms := source.terms
+	if len(terms) == 0 {
+		if terms, err = bi.topTerms(source.Document); err != nil {
+			return nil, fmt.Errorf("indexer related documents: %v", err)
+		}
+	}
+	if len(terms) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	match := bleve.NewDisjunctionQuery()
+	for i, term := range terms {
+		boost := float64(len(terms)-i) / float64(len(terms))
+		for _, field := range []string{"title", "content"} {
+			tq := bleve.NewTermQuery(term)
+			tq.SetField(field)
+			tq.SetBoost(boost)
+			match.AddQuery(tq)
+		}
+	}
+	q := bleve.NewBooleanQuery()
+	q.AddMust(match)
+	q.AddMustNot(bleve.NewDocIDQuery([]string{linkID.String()}))
+
+	req := bleve.NewSearchRequestOptions(q, n, 0, false)
+	req.Fields = []string{"*"}
+	res, err := bi.idx.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("indexer related documents: %v", err)
+	}
+
+	related := make([]*index.Document, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		doc, err := hitDocument(hit)
+		if err != nil {
+			return nil, fmt.Errorf("indexer related documents: %v", err)
+		}
+		related = append(related, doc)
+	}
+	return related, nil
+}
+
+// best index.RelatedTerms terms of the document by tf-idf, the terms are analyzed like the content
+// so they can be searched as is. the document frequency is of the content field at the time.
+func (bi *bleveIndex) topTerms(doc *index.Document) ([]string, error) {
+	analyzer := bi.idx.Mapping().AnalyzerNamed(en.AnalyzerName)
+	tf := make(map[string]int)
+	for _, token := range analyzer.Analyze([]byte(doc.Title + " " + doc.Content)) {
+		if len(token.Term) > 1 {
+			tf[string(token.Term)]++
+		}
+	}
+	if len(tf) == 0 {
+		return nil, nil
+	}
+
+	adv, err := bi.idx.Advanced()
+	if err != nil {
+		return nil, err
+	}
+	r, err := adv.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	// the document is counted, it may not be in the index yet
+	docCount, err := r.DocCount()
+	if err != nil {
+		return nil, err
+	}
+	docCount++
+
+	weights := make(map[string]float64, len(tf))
+	for term, count := range tf {
+		tfr, err := r.TermFieldReader(context.Background(), []byte(term), "content", false, false, false)
+		if err != nil {
+			return nil, err
+		}
+		df := tfr.Count()
+		_ = tfr.Close()
+		weights[term] = termWeight(count, df, docCount)
+	}
+	return topTerms(weights, index.RelatedTerms), nil
+}
+
+// tf-idf weight of the term appear tf times in the document and in df of docs documents,
+// the idf is smoothed so it is positive even for term in every document.
+// postgreindex weight the lexemes the same way.
+func termWeight(tf int, df, docs uint64) float64 {
+	return float64(tf) * math.Log(1+float64(docs)/float64(1+df))
+}
+
+// at most n terms of the highest weight, the tie is ordered by term
+func topTerms(weights map[string]float64, n int) []string {
+	terms := make([]string, 0, len(weights))
+	for term := range weights {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if wi, wj := weights[terms[i]], weights[terms[j]]; wi != wj {
+			return wi > wj
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// decode the stored terms field, single term is stored as string
+func storedTerms(field interface{}) []string {
+	switch v := field.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		terms := make([]string, 0, len(v))
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				terms = append(terms, s)
+			}
+		}
+		return terms
+	}
+	return nil
+}
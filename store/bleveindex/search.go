@@ -0,0 +1,348 @@
+package bleveindex
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// Lookup implements index.Indexer.
+func (bi *bleveIndex) Lookup(linkID uuid.UUID) (*index.Document, error) {
+	docs, err := bi.lookup(linkID.String())
+	if err != nil {
+		return nil, fmt.Errorf("indexer lookup document: %v", err)
+	}
+	doc, ok := docs[linkID]
+	if !ok {
+		return nil, fmt.Errorf("indexer lookup document: %v not found", linkID)
+	}
+	return doc.Document, nil
+}
+
+// document with the stored fields that are not part of index.Document
+type storedDoc struct {
+	*index.Document
+	terms []string
+}
+
+// return stored documents of the ids that exist
+func (bi *bleveIndex) lookup(ids ...string) (map[uuid.UUID]*storedDoc, error) {
+	req := bleve.NewSearchRequestOptions(bleve.NewDocIDQuery(ids), len(ids), 0, false)
+	req.Fields = []string{"*"}
+	res, err := bi.idx.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(map[uuid.UUID]*storedDoc, len(res.Hits))
+	for _, hit := range res.Hits {
+		doc, err := hitDocument(hit)
+		if err != nil {
+			return nil, err
+		}
+		docs[doc.LinkID] = &storedDoc{Document: doc, terms: storedTerms(hit.Fields["terms"])}
+	}
+	return docs, nil
+}
+
+// Search implements index.Indexer.
+// like postgreindex, it return one page of documents start from the offset or after the cursor,
+// ordered by the ranking score then id. blank expression match every document.
+func (bi *bleveIndex) Search(q index.Query) (index.Iterator, error) {
+	after := index.Cursor{At: time.Now().UTC()}
+	if q.Cursor != "" {
+		var err error
+		if after, err = index.ParseCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	analyzer := bi.idx.Mapping().AnalyzerNamed(en.AnalyzerName)
+	expression := q.Expression
+	if tree := q.Tree(); tree != nil {
+		expression = strings.Join(tree.Terms(), " ")
+	}
+	rank := newRankSort(index.RankingOf(q), after.At, analyzer, expression)
+
+	size := batchSize
+	if q.Limit > 0 {
+		size = q.Limit
+	}
+	req := bleve.NewSearchRequestOptions(buildQuery(q), size, int(q.Offset), false)
+	req.Sort = search.SortOrder{rank, &search.SortDocID{Desc: true}}
+	req.Fields = []string{"*"}
+	req.IncludeLocations = q.HighlightLength > 0
+	if q.Cursor != "" {
+		req.From = 0
+		req.SearchAfter = []string{encodeScore(after.Score), after.LinkID.String()}
+	}
+
+	var (
+		res        *bleve.SearchResult
+		hostCounts map[string]int
+		collapse   *collapseScan
+		err        error
+	)
+	if q.CollapseByHost > 0 {
+		var cursor *index.Cursor
+		if q.Cursor != "" {
+			cursor = &after
+		}
+		res, hostCounts, collapse, err = bi.searchCollapsed(req, q, cursor)
+	} else {
+		res, err = bi.idx.Search(req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("index search documents: %v", err)
+	}
+
+	it := &iterator{result: res, cursor: index.Cursor{At: after.At}, hostCounts: hostCounts, collapse: collapse}
+	if q.Explain {
+		it.rank = rank
+	}
+	if q.HighlightLength > 0 {
+		it.highlighter = newHighlighter(q.HighlightLength)
+	}
+	return it, nil
+}
+
+// native query of the clause tree, clause without children match every document
+func clauseQuery(c *index.Clause) query.Query {
+	children := make([]query.Query, 0, len(c.Clauses))
+	for _, child := range c.Clauses {
+		children = append(children, clauseQuery(child))
+	}
+
+	switch c.Op {
+	case index.ClauseNot:
+		bq := bleve.NewBooleanQuery()
+		bq.AddMustNot(children...)
+		return bq
+	case index.ClauseAnd, index.ClauseOr:
+		switch {
+		case len(children) == 0:
+			return bleve.NewMatchAllQuery()
+		case c.Op == index.ClauseOr:
+			return bleve.NewDisjunctionQuery(children...)
+		default:
+			return bleve.NewConjunctionQuery(children...)
+		}
+	}
+
+	// term query is not analyzed, index terms are lowercase
+	text := strings.ToLower(strings.TrimSpace(c.Text))
+	switch c.Type {
+	case index.QueryTypePhrase:
+		return bleve.NewMatchPhraseQuery(c.Text)
+	case index.QueryTypePrefix:
+		if c.IsWildcard() {
+			return bleve.NewWildcardQuery(text)
+		}
+		if text = strings.TrimSuffix(text, "*"); text == "" {
+			return bleve.NewMatchAllQuery()
+		}
+		return bleve.NewPrefixQuery(text)
+	case index.QueryTypeFuzzy:
+		var terms []query.Query
+		for _, term := range strings.Fields(text) {
+			fq := bleve.NewFuzzyQuery(term)
+			fq.SetFuzziness(c.EditDistance())
+			terms = append(terms, fq)
+		}
+		if len(terms) == 1 {
+			return terms[0]
+		}
+		return bleve.NewConjunctionQuery(terms...)
+	default:
+		return bleve.NewMatchQuery(c.Text)
+	}
+}
+
+// expression, phrases and filters must all match, blank expression match every document
+func buildQuery(q index.Query) query.Query {
+	var must []query.Query
+	switch tree := q.Tree(); {
+	case tree != nil:
+		must = append(must, clauseQuery(tree))
+	case strings.TrimSpace(q.Expression) == "":
+	case q.Type == index.QueryTypePhrase:
+		must = append(must, bleve.NewMatchPhraseQuery(q.Expression))
+	default:
+		must = append(must, bleve.NewMatchQuery(q.Expression))
+	}
+	for _, phrase := range q.Phrases {
+		must = append(must, bleve.NewMatchPhraseQuery(phrase))
+	}
+
+	f := q.Filters
+	if f.Site != "" {
+		site := bleve.NewTermQuery(strings.ToLower(f.Site))
+		site.SetField("site")
+		must = append(must, site)
+	}
+	if f.InURL != "" {
+		// regexp must match the whole url
+		inURL := bleve.NewRegexpQuery(".*" + regexp.QuoteMeta(f.InURL) + ".*")
+		inURL.SetField("url")
+		must = append(must, inURL)
+	}
+	if f.InTitle != "" {
+		inTitle := bleve.NewMatchQuery(f.InTitle)
+		inTitle.SetField("title")
+		inTitle.SetOperator(query.MatchQueryOperatorAnd)
+		must = append(must, inTitle)
+	}
+	if !f.IndexedAfter.IsZero() || !f.IndexedBefore.IsZero() {
+		inclusive, exclusive := true, false
+		indexedAt := bleve.NewDateRangeInclusiveQuery(f.IndexedAfter, f.IndexedBefore, &inclusive, &exclusive)
+		indexedAt.SetField("indexed_at")
+		must = append(must, indexedAt)
+	}
+	if f.Language != "" {
+		language := bleve.NewTermQuery(f.Language)
+		language.SetField("language")
+		must = append(must, language)
+	}
+
+	if len(must) == 0 {
+		must = append(must, bleve.NewMatchAllQuery())
+	}
+	if len(must) == 1 && len(q.Exclude) == 0 {
+		return must[0]
+	}
+
+	bq := bleve.NewBooleanQuery()
+	bq.AddMust(must...)
+	for _, term := range q.Exclude {
+		bq.AddMustNot(bleve.NewMatchPhraseQuery(term))
+	}
+	return bq
+}
+
+// decode document from the stored fields of hit
+func hitDocument(hit *search.DocumentMatch) (*index.Document, error) {
+	id, err := uuid.Parse(hit.ID)
+	if err != nil {
+		return nil, fmt.Errorf("document id: %v", err)
+	}
+	doc := &index.Document{LinkID: id}
+	doc.URL, _ = hit.Fields["url"].(string)
+	doc.Title, _ = hit.Fields["title"].(string)
+	doc.Content, _ = hit.Fields["content"].(string)
+	doc.PageRank, _ = hit.Fields["pagerank"].(float64)
+	doc.Language, _ = hit.Fields["language"].(string)
+	if indexedAt, ok := hit.Fields["indexed_at"].(string); ok {
+		if doc.IndexedAt, err = time.Parse(time.RFC3339, indexedAt); err != nil {
+			return nil, fmt.Errorf("document indexed_at: %v", err)
+		}
+	}
+	return doc, nil
+}
+
+var _ index.ExplainIterator = (*iterator)(nil)
+var _ index.CollapseIterator = (*iterator)(nil)
+
+// iterate single page of search result
+type iterator struct {
+	result *bleve.SearchResult
+
+	idx        int
+	latchedDoc *index.Document
+	latchedErr error
+	cursor     index.Cursor
+
+	// set when the query ask for explanation
+	rank        *rankSort
+	explanation *index.Explanation
+
+	// set when the query ask for highlight
+	highlighter *highlighter
+	highlight   *index.Highlight
+	// documents of the hosts in the page, set when the result is collapsed
+	hostCounts map[string]int
+	hostCount  int
+	collapse   *collapseScan
+}
+
+// Close implements index.Iterator.
+func (it *iterator) Close() error {
+	it.idx = len(it.result.Hits)
+	return nil
+}
+
+// Document implements index.Iterator.
+func (it *iterator) Document() *index.Document {
+	return it.latchedDoc
+}
+
+// Error implements index.Iterator.
+func (it *iterator) Error() error {
+	return it.latchedErr
+}
+
+// Next implements index.Iterator.
+func (it *iterator) Next() bool {
+	if it.latchedErr != nil || it.idx >= len(it.result.Hits) {
+		return false
+	}
+	hit := it.result.Hits[it.idx]
+	it.latchedDoc, it.latchedErr = hitDocument(hit)
+	it.idx++
+	if it.latchedErr != nil {
+		return false
+	}
+	it.cursor.Score, it.cursor.LinkID = decodeScore(hit.Sort[0]), it.latchedDoc.LinkID
+	if it.rank != nil {
+		it.explanation = it.rank.explain(hit, it.latchedDoc)
+	}
+	if it.hostCounts != nil {
+		it.hostCount = it.hostCounts[hitHost(hit)]
+	}
+	if it.highlighter != nil {
+		it.highlight = it.highlighter.highlight(hit, it.latchedDoc.Content)
+		// like postgreindex, the fragments replace the content
+		it.latchedDoc.Content = ""
+	}
+	return true
+}
+
+// HostCount implements index.CollapseIterator.
+func (it *iterator) HostCount() int {
+	return it.hostCount
+}
+
+// Highlight implements index.Iterator.
+func (it *iterator) Highlight() *index.Highlight {
+	return it.highlight
+}
+
+// Explain implements index.ExplainIterator.
+func (it *iterator) Explain() *index.Explanation {
+	return it.explanation
+}
+
+// Cursor implements index.Iterator.
+func (it *iterator) Cursor() string {
+	if it.latchedDoc == nil {
+		return ""
+	}
+	if it.collapse != nil {
+		cursor := it.cursor
+		cursor.HostCounts = it.collapse.countsAt(it.latchedDoc.LinkID.String())
+		return cursor.String()
+	}
+	return it.cursor.String()
+}
+
+// TotalCount implements index.Iterator.
+func (it *iterator) TotalCount() uint64 {
+	return it.result.Total
+}
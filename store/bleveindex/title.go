@@ -0,0 +1,79 @@
+package bleveindex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2/document"
+	indexapi "github.com/blevesearch/bleve_index_api"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ index.TitleIndexer = (*bleveIndex)(nil)
+
+// Titles implements index.TitleIndexer.
+// it walk every document of the index reader and load its stored title and pagerank,
+// so the cost is linear in the number of documents.
+func (bi *bleveIndex) Titles(ctx context.Context, fn func(title string, pageRank float64) error) error {
+	adv, err := bi.idx.Advanced()
+	if err != nil {
+		return fmt.Errorf("indexer titles: %v", err)
+	}
+	r, err := adv.Reader()
+	if err != nil {
+		return fmt.Errorf("indexer titles: %v", err)
+	}
+	defer r.Close()
+
+	ids, err := r.DocIDReaderAll()
+	if err != nil {
+		return fmt.Errorf("indexer titles: %v", err)
+	}
+	defer ids.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		id, err := ids.Next()
+		if err != nil {
+			return fmt.Errorf("indexer titles: %v", err)
+		}
+		if id == nil {
+			return nil
+		}
+		externalID, err := r.ExternalID(id)
+		if err != nil {
+			return fmt.Errorf("indexer titles: %v", err)
+		}
+		doc, err := r.Document(externalID)
+		if err != nil {
+			return fmt.Errorf("indexer titles: %v", err)
+		}
+		if doc == nil {
+			// deleted after the reader is opened
+			continue
+		}
+
+		var (
+			title    string
+			pageRank float64
+		)
+		doc.VisitFields(func(field indexapi.Field) {
+			switch field.Name() {
+			case "title":
+				title = string(field.Value())
+			case "pagerank":
+				if num, ok := field.(*document.NumericField); ok {
+					pageRank, _ = num.Number()
+				}
+			}
+		})
+		if title == "" {
+			continue
+		}
+		if err := fn(title, pageRank); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package bleveindex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ index.VocabularyIndexer = (*bleveIndex)(nil)
+
+// Vocabulary implements index.VocabularyIndexer.
+// the words are the term dictionary of the words field, the count of a word
+// may include deleted documents until their segment is merged.
+func (bi *bleveIndex) Vocabulary(ctx context.Context, fn func(word string, docCount int) error) error {
+	dict, err := bi.idx.FieldDict("words")
+	if err != nil {
+		return fmt.Errorf("indexer vocabulary: %v", err)
+	}
+	defer dict.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := dict.Next()
+		if err != nil {
+			return fmt.Errorf("indexer vocabulary: %v", err)
+		}
+		if entry == nil {
+			return nil
+		}
+		if err := fn(entry.Term, int(entry.Count)); err != nil {
+			return err
+		}
+	}
+}
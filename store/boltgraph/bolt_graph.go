@@ -0,0 +1,100 @@
+// Package boltgraph implements the link graph on an embedded bbolt file,
+// so single node can run the crawler with persistent graph and no database server.
+package boltgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"go.etcd.io/bbolt"
+)
+
+var _ graph.Graph = (*boltGraph)(nil)
+
+/*
+key layout, uuid stored as it's 16 bytes so the byte order of keys
+is the same as the ID order of the other stores.
+
+links      id             -> link
+link_urls  url            -> id
+edges      src + id       -> edge    (src-ordered, Edges and RemoveStaleEdges scan it)
+edge_pairs src + dst      -> id      (uniqueness of src,dst)
+in_edges   dst + id       -> src     (backlinks)
+hosts      name           -> host fetch statistic
+changes    cursor         -> change  (append-only change log, big-endian sequence)
+*/
+var (
+	linksBucket     = []byte("links")
+	linkURLsBucket  = []byte("link_urls")
+	edgesBucket     = []byte("edges")
+	edgePairsBucket = []byte("edge_pairs")
+	inEdgesBucket   = []byte("in_edges")
+	hostsBucket     = []byte("hosts")
+	changesBucket   = []byte("changes")
+)
+
+type boltGraph struct {
+	db *bbolt.DB
+
+	// closed and replaced after every committed write, wake WaitChange
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+// New create graph on db, the buckets are created if not exist
+func New(db *bbolt.DB) (*boltGraph, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{linksBucket, linkURLsBucket, edgesBucket, edgePairsBucket, inEdgesBucket, hostsBucket, changesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create buckets: %v", err)
+	}
+	return &boltGraph{db: db, changed: make(chan struct{})}, nil
+}
+
+// concat the keys
+func key(parts ...[]byte) []byte {
+	var k []byte
+	for _, p := range parts {
+		k = append(k, p...)
+	}
+	return k
+}
+
+func idBytes(id uuid.UUID) []byte {
+	return id[:]
+}
+
+func bytesID(b []byte) uuid.UUID {
+	var id uuid.UUID
+	copy(id[:], b)
+	return id
+}
+
+func encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func decodeLink(b []byte) (*graph.Link, error) {
+	link := new(graph.Link)
+	if err := json.Unmarshal(b, link); err != nil {
+		return nil, fmt.Errorf("decode link: %v", err)
+	}
+	return link, nil
+}
+
+func decodeEdge(b []byte) (*graph.Edge, error) {
+	edge := new(graph.Edge)
+	if err := json.Unmarshal(b, edge); err != nil {
+		return nil, fmt.Errorf("decode edge: %v", err)
+	}
+	return edge, nil
+}
@@ -0,0 +1,112 @@
+package boltgraph
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/odit-bit/invoker/internal/xuuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	graphtest "github.com/odit-bit/invoker/linkgraph/graph_test"
+	"go.etcd.io/bbolt"
+)
+
+// open graph on new file that removed when the test end
+func newGraph(t *testing.T) *boltGraph {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "graph.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	g, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func Test_suite(t *testing.T) {
+	graphtest.Run(t, func(t *testing.T) graph.Graph { return newGraph(t) })
+}
+
+func Test_link_iterator_batch(t *testing.T) {
+	g := newGraph(t)
+
+	links := make([]*graph.Link, batchSize*2+1)
+	for i := range links {
+		links[i] = &graph.Link{URL: fmt.Sprintf("https://example.com/%d", i)}
+	}
+	if err := g.UpsertLinks(links); err != nil {
+		t.Fatal(err)
+	}
+	var edges []*graph.Edge
+	for i := 1; i < len(links); i++ {
+		edges = append(edges, &graph.Edge{Src: links[0].ID, Dst: links[i].ID})
+	}
+	if err := g.UpsertEdges(edges); err != nil {
+		t.Fatal(err)
+	}
+
+	it, _ := g.Links(xuuid.MIN, xuuid.MAX, graph.LinkFilter{})
+	count := 0
+	for it.Next() {
+		it.Link()
+		count++
+	}
+	if count != len(links) {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, len(links))
+	}
+
+	edgeIt, _ := g.Edges(xuuid.MIN, xuuid.MAX, time.Now().Add(time.Minute))
+	count = 0
+	for edgeIt.Next() {
+		edgeIt.Edge()
+		count++
+	}
+	if count != len(edges) {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, len(edges))
+	}
+}
+
+func Test_host(t *testing.T) {
+	g := newGraph(t)
+
+	src := &graph.Link{URL: "https://a.com/1", RetrievedAt: time.Now()}
+	dst := &graph.Link{URL: "https://b.com/1"}
+	for _, link := range []*graph.Link{src, dst} {
+		if err := g.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.UpsertEdge(&graph.Edge{Src: src.ID, Dst: dst.ID}); err != nil {
+		t.Fatal(err)
+	}
+	err := g.RecordFetches([]*graph.HostFetch{
+		{Host: "a.com", StatusCode: 200, ResponseTime: time.Second, FetchedAt: time.Now()},
+		{Host: "a.com", StatusCode: 500, ResponseTime: 3 * time.Second, FetchedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, err := g.LookupHost("a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.KnownPages != 1 || host.CrawledPages != 1 || host.OutEdges != 1 || host.ErrorRate() != 0.5 || host.AvgResponseTime() != 2*time.Second {
+		t.Fatalf("\ngot:%+v", host)
+	}
+
+	hosts, err := g.Hosts(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 1 || hosts[0].Name != "a.com" {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(hosts), 1)
+	}
+	if host, _ := g.LookupHost("b.com"); host.InEdges != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", host.InEdges, 1)
+	}
+}
@@ -0,0 +1,153 @@
+package boltgraph
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"go.etcd.io/bbolt"
+)
+
+var _ graph.ChangeFeed = (*boltGraph)(nil)
+
+// run fn in write transaction and wake the waiting subscriber after it committed
+func (b *boltGraph) update(fn func(tx *bbolt.Tx) error) error {
+	if err := b.db.Update(fn); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	close(b.changed)
+	b.changed = make(chan struct{})
+	b.mu.Unlock()
+	return nil
+}
+
+func cursorBytes(cursor uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, cursor)
+	return b
+}
+
+// append change into the log in the same transaction as the data it describe
+func recordChange(tx *bbolt.Tx, kind graph.ChangeKind, linkID, edgeID uuid.UUID) error {
+	changes := tx.Bucket(changesBucket)
+	cursor, err := changes.NextSequence()
+	if err != nil {
+		return fmt.Errorf("record change: %v", err)
+	}
+
+	val, err := encode(&graph.Change{
+		Cursor: cursor,
+		Kind:   kind,
+		LinkID: linkID,
+		EdgeID: edgeID,
+		At:     time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("record change: %v", err)
+	}
+	if err := changes.Put(cursorBytes(cursor), val); err != nil {
+		return fmt.Errorf("record change: %v", err)
+	}
+	return nil
+}
+
+// cursor of the last pruned change, the log keep every change after it
+func prunedCursor(changes *bbolt.Bucket) uint64 {
+	if k, _ := changes.Cursor().First(); k != nil {
+		return binary.BigEndian.Uint64(k) - 1
+	}
+	// everything pruned, or nothing recorded yet
+	return changes.Sequence()
+}
+
+// LastChange implements graph.ChangeFeed.
+func (b *boltGraph) LastChange(_ context.Context) (uint64, error) {
+	var last uint64
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		// the sequence is kept when the log is pruned
+		last = tx.Bucket(changesBucket).Sequence()
+		return nil
+	})
+	return last, err
+}
+
+// Changes implements graph.ChangeFeed.
+func (b *boltGraph) Changes(_ context.Context, after uint64, limit int) ([]*graph.Change, error) {
+	var list []*graph.Change
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		changes := tx.Bucket(changesBucket)
+		if after < prunedCursor(changes) {
+			return graph.ErrChangesPruned
+		}
+
+		c := changes.Cursor()
+		for k, v := c.Seek(cursorBytes(after + 1)); k != nil; k, v = c.Next() {
+			if limit > 0 && len(list) == limit {
+				break
+			}
+			change := new(graph.Change)
+			if err := json.Unmarshal(v, change); err != nil {
+				return fmt.Errorf("decode change: %v", err)
+			}
+			list = append(list, change)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// PruneChanges implements graph.ChangeFeed.
+func (b *boltGraph) PruneChanges(_ context.Context, before time.Time) (int, error) {
+	var removed int
+	err := b.db.Update(func(tx *bbolt.Tx) error {
+		// the log is ordered by time, stop at the first change to keep
+		c := tx.Bucket(changesBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			change := new(graph.Change)
+			if err := json.Unmarshal(v, change); err != nil {
+				return fmt.Errorf("decode change: %v", err)
+			}
+			if !change.At.Before(before) {
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return fmt.Errorf("prune changes: %v", err)
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// WaitChange implements graph.ChangeFeed.
+func (b *boltGraph) WaitChange(ctx context.Context, after uint64) error {
+	// taken before reading the log so change committed in between is not missed
+	b.mu.Lock()
+	changed := b.changed
+	b.mu.Unlock()
+
+	last, err := b.LastChange(ctx)
+	if err != nil {
+		return err
+	}
+	if last > after {
+		return nil
+	}
+
+	select {
+	case <-changed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
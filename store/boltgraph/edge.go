@@ -0,0 +1,190 @@
+package boltgraph
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"go.etcd.io/bbolt"
+)
+
+// UpsertEdge implements graph.Graph.
+func (b *boltGraph) UpsertEdge(edge *graph.Edge) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		return upsertEdge(tx, edge)
+	})
+}
+
+// UpsertEdges implements graph.Graph.
+func (b *boltGraph) UpsertEdges(edges []*graph.Edge) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		for _, edge := range edges {
+			if err := upsertEdge(tx, edge); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func upsertEdge(tx *bbolt.Tx, edge *graph.Edge) error {
+	links := tx.Bucket(linksBucket)
+	if links.Get(idBytes(edge.Src)) == nil || links.Get(idBytes(edge.Dst)) == nil {
+		return graph.ErrUnknownEdgeLinks
+	}
+
+	pairs := tx.Bucket(edgePairsBucket)
+	pair := key(idBytes(edge.Src), idBytes(edge.Dst))
+	if id := pairs.Get(pair); id != nil {
+		edge.ID = bytesID(id)
+	} else {
+		edge.ID = uuid.New()
+		if err := pairs.Put(pair, key(idBytes(edge.ID))); err != nil {
+			return fmt.Errorf("upsert edge: %v", err)
+		}
+		inKey := key(idBytes(edge.Dst), idBytes(edge.ID))
+		if err := tx.Bucket(inEdgesBucket).Put(inKey, key(idBytes(edge.Src))); err != nil {
+			return fmt.Errorf("upsert edge: %v", err)
+		}
+		if err := recordChange(tx, graph.ChangeEdgeChanged, edge.Src, edge.ID); err != nil {
+			return err
+		}
+	}
+	edge.UpdateAt = time.Now().UTC()
+
+	val, err := encode(edge)
+	if err != nil {
+		return fmt.Errorf("upsert edge: %v", err)
+	}
+	if err := tx.Bucket(edgesBucket).Put(key(idBytes(edge.Src), idBytes(edge.ID)), val); err != nil {
+		return fmt.Errorf("upsert edge: %v", err)
+	}
+	return nil
+}
+
+// remove edge from every bucket, removing missing edge is no-op
+func removeEdge(tx *bbolt.Tx, edge *graph.Edge) error {
+	edges := tx.Bucket(edgesBucket)
+	edgeKey := key(idBytes(edge.Src), idBytes(edge.ID))
+	if edges.Get(edgeKey) == nil {
+		return nil
+	}
+	if err := edges.Delete(edgeKey); err != nil {
+		return err
+	}
+	if err := recordChange(tx, graph.ChangeEdgeChanged, edge.Src, edge.ID); err != nil {
+		return err
+	}
+	if err := tx.Bucket(edgePairsBucket).Delete(key(idBytes(edge.Src), idBytes(edge.Dst))); err != nil {
+		return err
+	}
+	return tx.Bucket(inEdgesBucket).Delete(key(idBytes(edge.Dst), idBytes(edge.ID)))
+}
+
+// decode every edge whose key start with prefix
+func prefixEdges(edges *bbolt.Bucket, prefix []byte) ([]*graph.Edge, error) {
+	var list []*graph.Edge
+	c := edges.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		edge, err := decodeEdge(v)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, edge)
+	}
+	return list, nil
+}
+
+// edges end up at dst
+func inEdges(tx *bbolt.Tx, dst uuid.UUID) ([]*graph.Edge, error) {
+	var list []*graph.Edge
+	edges := tx.Bucket(edgesBucket)
+	c := tx.Bucket(inEdgesBucket).Cursor()
+	prefix := idBytes(dst)
+	for k, src := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, src = c.Next() {
+		edge, err := decodeEdge(edges.Get(key(src, k[len(prefix):])))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, edge)
+	}
+	return list, nil
+}
+
+// Edges implements graph.Graph.
+func (b *boltGraph) Edges(fromID uuid.UUID, toID uuid.UUID, updateBefore time.Time) (graph.EdgeIterator, error) {
+	return &edgeIterator{
+		db:           b.db,
+		from:         key(idBytes(fromID)),
+		to:           idBytes(toID),
+		updateBefore: updateBefore,
+	}, nil
+}
+
+// InEdges implements graph.Graph.
+func (b *boltGraph) InEdges(dst uuid.UUID) (graph.EdgeIterator, error) {
+	var list []*graph.Edge
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		list, err = inEdges(tx, dst)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &edgeIterator{list: list, done: true}, nil
+}
+
+// OutEdges implements graph.Graph.
+func (b *boltGraph) OutEdges(src uuid.UUID) (graph.EdgeIterator, error) {
+	var list []*graph.Edge
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		list, err = prefixEdges(tx.Bucket(edgesBucket), idBytes(src))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &edgeIterator{list: list, done: true}, nil
+}
+
+// Degree implements graph.Graph.
+func (b *boltGraph) Degree(id uuid.UUID) (in, out int, err error) {
+	err = b.db.View(func(tx *bbolt.Tx) error {
+		in = countPrefix(tx.Bucket(inEdgesBucket), idBytes(id))
+		out = countPrefix(tx.Bucket(edgesBucket), idBytes(id))
+		return nil
+	})
+	return in, out, err
+}
+
+func countPrefix(bucket *bbolt.Bucket, prefix []byte) int {
+	n := 0
+	c := bucket.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		n++
+	}
+	return n
+}
+
+// RemoveStaleEdges implements graph.Graph.
+func (b *boltGraph) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		edges, err := prefixEdges(tx.Bucket(edgesBucket), idBytes(fromID))
+		if err != nil {
+			return err
+		}
+		for _, edge := range edges {
+			if !edge.UpdateAt.Before(updatedBefore) {
+				continue
+			}
+			if err := removeEdge(tx, edge); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
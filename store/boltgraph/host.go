@@ -0,0 +1,146 @@
+package boltgraph
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"go.etcd.io/bbolt"
+)
+
+var _ graph.HostGraph = (*boltGraph)(nil)
+
+// RecordFetches implements graph.HostGraph.
+func (b *boltGraph) RecordFetches(fetches []*graph.HostFetch) error {
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		hosts := tx.Bucket(hostsBucket)
+		for _, fetch := range fetches {
+			host, err := hostStat(hosts, fetch.Host)
+			if err != nil {
+				return err
+			}
+			host.Fetches++
+			if fetch.Failed() {
+				host.FetchErrors++
+			}
+			host.TotalResponseTime += fetch.ResponseTime
+			if fetch.FetchedAt.After(host.LastCrawlAt) {
+				host.LastCrawlAt = fetch.FetchedAt.UTC()
+			}
+			if fetch.RobotsStatus != graph.RobotsUnknown {
+				host.RobotsStatus = fetch.RobotsStatus
+			}
+			if err := putHost(hosts, host); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LookupHost implements graph.HostGraph.
+func (b *boltGraph) LookupHost(name string) (*graph.Host, error) {
+	var host *graph.Host
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		stored := tx.Bucket(hostsBucket).Get([]byte(name)) != nil
+		var err error
+		if host, err = derivedHost(tx, name); err != nil {
+			return err
+		}
+		if !stored && host.KnownPages == 0 {
+			return graph.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return host, nil
+}
+
+// Hosts implements graph.HostGraph.
+func (b *boltGraph) Hosts(offset, limit int) ([]*graph.Host, error) {
+	var hosts []*graph.Host
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(hostsBucket).Cursor()
+		i := 0
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if limit > 0 && len(hosts) == limit {
+				break
+			}
+			if i++; i <= offset {
+				continue
+			}
+			host, err := derivedHost(tx, string(k))
+			if err != nil {
+				return err
+			}
+			hosts = append(hosts, host)
+		}
+		return nil
+	})
+	return hosts, err
+}
+
+// stored statistic of the host, zero host if not exist
+func hostStat(hosts *bbolt.Bucket, name string) (*graph.Host, error) {
+	host := &graph.Host{Name: name}
+	if val := hosts.Get([]byte(name)); val != nil {
+		if err := json.Unmarshal(val, host); err != nil {
+			return nil, fmt.Errorf("decode host: %v", err)
+		}
+	}
+	return host, nil
+}
+
+func putHost(hosts *bbolt.Bucket, host *graph.Host) error {
+	val, err := encode(host)
+	if err != nil {
+		return fmt.Errorf("encode host: %v", err)
+	}
+	return hosts.Put([]byte(host.Name), val)
+}
+
+// stored statistic of the host with the fields derived from links and edges
+func derivedHost(tx *bbolt.Tx, name string) (*graph.Host, error) {
+	host, err := hostStat(tx.Bucket(hostsBucket), name)
+	if err != nil {
+		return nil, err
+	}
+
+	// host of every link, needed to count inter-host edges
+	linkHost := map[string]string{}
+	err = tx.Bucket(linksBucket).ForEach(func(k, v []byte) error {
+		link, err := decodeLink(v)
+		if err != nil {
+			return err
+		}
+		linkHost[string(k)] = link.Host
+		if link.Host == name {
+			host.KnownPages++
+			if !link.RetrievedAt.IsZero() {
+				host.CrawledPages++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Bucket(edgePairsBucket).ForEach(func(k, _ []byte) error {
+		srcHost, dstHost := linkHost[string(k[:16])], linkHost[string(k[16:])]
+		switch {
+		case srcHost == dstHost:
+		case srcHost == name:
+			host.OutEdges++
+		case dstHost == name:
+			host.InEdges++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return host, nil
+}
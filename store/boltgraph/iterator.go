@@ -0,0 +1,147 @@
+package boltgraph
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"go.etcd.io/bbolt"
+)
+
+// number of records read in one read transaction,
+// so long iteration not hold the transaction and block the file growth
+const batchSize = 512
+
+var _ graph.LinkIterator = (*linkIterator)(nil)
+
+type linkIterator struct {
+	db *bbolt.DB
+
+	// next key to read and the exclusive end of range
+	from, to []byte
+	filter   graph.LinkFilter
+
+	list []*graph.Link
+	idx  int
+	done bool
+	err  error
+}
+
+// Next implements graph.Iterator.
+func (it *linkIterator) Next() bool {
+	for it.idx >= len(it.list) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.err = it.fill()
+	}
+	it.idx++
+	return true
+}
+
+// read next batch of link
+func (it *linkIterator) fill() error {
+	it.list, it.idx = it.list[:0], 0
+	return it.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(linksBucket).Cursor()
+		k, v := c.Seek(it.from)
+		for ; k != nil && bytes.Compare(k, it.to) < 0; k, v = c.Next() {
+			if len(it.list) == batchSize {
+				it.from = key(k)
+				return nil
+			}
+			link, err := decodeLink(v)
+			if err != nil {
+				return err
+			}
+			if it.filter.Match(link) {
+				it.list = append(it.list, link)
+			}
+		}
+		it.done = true
+		return nil
+	})
+}
+
+// Link implements graph.LinkIterator.
+func (it *linkIterator) Link() *graph.Link {
+	return it.list[it.idx-1]
+}
+
+// Error implements graph.Iterator.
+func (it *linkIterator) Error() error {
+	return it.err
+}
+
+// Close implements graph.Iterator.
+func (it *linkIterator) Close() error {
+	it.list, it.done = nil, true
+	return nil
+}
+
+var _ graph.EdgeIterator = (*edgeIterator)(nil)
+
+type edgeIterator struct {
+	db *bbolt.DB
+
+	// next key to read and the exclusive end of source range
+	from, to     []byte
+	updateBefore time.Time
+
+	list []*graph.Edge
+	idx  int
+	done bool
+	err  error
+}
+
+// Next implements graph.Iterator.
+func (it *edgeIterator) Next() bool {
+	for it.idx >= len(it.list) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.err = it.fill()
+	}
+	it.idx++
+	return true
+}
+
+// read next batch of edge, the key start with the source
+func (it *edgeIterator) fill() error {
+	it.list, it.idx = it.list[:0], 0
+	return it.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(edgesBucket).Cursor()
+		k, v := c.Seek(it.from)
+		for ; k != nil && bytes.Compare(k[:len(it.to)], it.to) < 0; k, v = c.Next() {
+			if len(it.list) == batchSize {
+				it.from = key(k)
+				return nil
+			}
+			edge, err := decodeEdge(v)
+			if err != nil {
+				return err
+			}
+			if edge.UpdateAt.Before(it.updateBefore) {
+				it.list = append(it.list, edge)
+			}
+		}
+		it.done = true
+		return nil
+	})
+}
+
+// Edge implements graph.EdgeIterator.
+func (it *edgeIterator) Edge() *graph.Edge {
+	return it.list[it.idx-1]
+}
+
+// Error implements graph.Iterator.
+func (it *edgeIterator) Error() error {
+	return it.err
+}
+
+// Close implements graph.Iterator.
+func (it *edgeIterator) Close() error {
+	it.list, it.done = nil, true
+	return nil
+}
@@ -0,0 +1,196 @@
+package boltgraph
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+	"go.etcd.io/bbolt"
+)
+
+// UpsertLink implements graph.Graph.
+func (b *boltGraph) UpsertLink(link *graph.Link) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		return upsertLink(tx, link)
+	})
+}
+
+// UpsertLinks implements graph.Graph.
+func (b *boltGraph) UpsertLinks(links []*graph.Link) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		for _, link := range links {
+			if err := upsertLink(tx, link); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func upsertLink(tx *bbolt.Tx, link *graph.Link) error {
+	links, urls := tx.Bucket(linksBucket), tx.Bucket(linkURLsBucket)
+	if link.Host == "" {
+		link.Host = graph.HostOf(link.URL)
+	}
+
+	// merge into exist link with the same url
+	if id := urls.Get([]byte(link.URL)); id != nil {
+		exist, err := decodeLink(links.Get(id))
+		if err != nil {
+			return err
+		}
+		if link.RetrievedAt.After(exist.RetrievedAt) {
+			if err := recordChange(tx, graph.ChangeLinkCrawled, exist.ID, uuid.Nil); err != nil {
+				return err
+			}
+		}
+		graph.MergeLink(exist, link)
+		*link = *exist
+		return putLink(links, link)
+	}
+
+	// keep given id if not taken
+	for link.ID == uuid.Nil || links.Get(idBytes(link.ID)) != nil {
+		link.ID = uuid.New()
+	}
+	link.FirstSeenAt = time.Now().UTC()
+
+	if err := urls.Put([]byte(link.URL), key(idBytes(link.ID))); err != nil {
+		return fmt.Errorf("upsert link: %v", err)
+	}
+	if err := recordChange(tx, graph.ChangeLinkCreated, link.ID, uuid.Nil); err != nil {
+		return err
+	}
+	if !link.RetrievedAt.IsZero() {
+		if err := recordChange(tx, graph.ChangeLinkCrawled, link.ID, uuid.Nil); err != nil {
+			return err
+		}
+	}
+	return putLink(links, link)
+}
+
+func putLink(links *bbolt.Bucket, link *graph.Link) error {
+	val, err := encode(link)
+	if err != nil {
+		return fmt.Errorf("upsert link: %v", err)
+	}
+	if err := links.Put(key(idBytes(link.ID)), val); err != nil {
+		return fmt.Errorf("upsert link: %v", err)
+	}
+	return nil
+}
+
+// LookupLink implements graph.Graph.
+func (b *boltGraph) LookupLink(id uuid.UUID) (*graph.Link, error) {
+	var link *graph.Link
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		val := tx.Bucket(linksBucket).Get(idBytes(id))
+		if val == nil {
+			return graph.ErrNotFound
+		}
+		var err error
+		link, err = decodeLink(val)
+		return err
+	})
+	return link, err
+}
+
+// LookupLinkByURL implements graph.Graph.
+func (b *boltGraph) LookupLinkByURL(url string) (*graph.Link, error) {
+	var link *graph.Link
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		id := tx.Bucket(linkURLsBucket).Get([]byte(url))
+		if id == nil {
+			return graph.ErrNotFound
+		}
+		var err error
+		link, err = decodeLink(tx.Bucket(linksBucket).Get(id))
+		return err
+	})
+	return link, err
+}
+
+// RemoveLink implements graph.Graph.
+func (b *boltGraph) RemoveLink(id uuid.UUID) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		val := tx.Bucket(linksBucket).Get(idBytes(id))
+		if val == nil {
+			return graph.ErrNotFound
+		}
+		link, err := decodeLink(val)
+		if err != nil {
+			return err
+		}
+		return removeLink(tx, link)
+	})
+}
+
+// RemoveOrphanLinks implements graph.Graph.
+func (b *boltGraph) RemoveOrphanLinks(createdBefore time.Time) (int, error) {
+	removed := 0
+	err := b.update(func(tx *bbolt.Tx) error {
+		var orphans []*graph.Link
+		inEdges := tx.Bucket(inEdgesBucket).Cursor()
+		err := tx.Bucket(linksBucket).ForEach(func(k, v []byte) error {
+			link, err := decodeLink(v)
+			if err != nil {
+				return err
+			}
+			if !link.RetrievedAt.IsZero() || !link.FirstSeenAt.Before(createdBefore) {
+				return nil
+			}
+			if k, _ := inEdges.Seek(k); k != nil && bytes.HasPrefix(k, idBytes(link.ID)) {
+				return nil
+			}
+			orphans = append(orphans, link)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// bucket must not be modified while ForEach
+		for _, link := range orphans {
+			if err := removeLink(tx, link); err != nil {
+				return err
+			}
+		}
+		removed = len(orphans)
+		return nil
+	})
+	return removed, err
+}
+
+// remove link and every edge originate from or end up at it
+func removeLink(tx *bbolt.Tx, link *graph.Link) error {
+	out, err := prefixEdges(tx.Bucket(edgesBucket), idBytes(link.ID))
+	if err != nil {
+		return err
+	}
+	in, err := inEdges(tx, link.ID)
+	if err != nil {
+		return err
+	}
+	for _, edge := range append(out, in...) {
+		if err := removeEdge(tx, edge); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Bucket(linkURLsBucket).Delete([]byte(link.URL)); err != nil {
+		return err
+	}
+	return tx.Bucket(linksBucket).Delete(idBytes(link.ID))
+}
+
+// Links implements graph.Graph.
+func (b *boltGraph) Links(fromID uuid.UUID, toID uuid.UUID, filter graph.LinkFilter) (graph.LinkIterator, error) {
+	return &linkIterator{
+		db:     b.db,
+		from:   key(idBytes(fromID)),
+		to:     idBytes(toID),
+		filter: filter,
+	}, nil
+}
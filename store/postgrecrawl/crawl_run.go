@@ -0,0 +1,240 @@
+package postgrecrawl
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/internal/migrate"
+	"github.com/odit-bit/invoker/linkcrawler/crawlrun"
+)
+
+var _ crawlrun.Store = (*runStore)(nil)
+var _ crawlrun.ContextStore = (*runStore)(nil)
+
+type runStore struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) (*runStore, error) {
+	rs := runStore{
+		db: db,
+	}
+
+	if err := rs.migrate(); err != nil {
+		return nil, fmt.Errorf("postgrecrawl migrate: %v", err)
+	}
+	return &rs, nil
+}
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations is the versioned schema of the crawl run store
+var Migrations = migrate.Source{Name: "crawl", FS: migrationFiles, Dir: "migrations"}
+
+// revert every migration
+func (rs *runStore) drop() error {
+	m, err := migrate.New(rs.db, Migrations)
+	if err != nil {
+		return err
+	}
+	return m.To(context.TODO(), 0)
+}
+
+func (rs *runStore) migrate() error {
+	return migrate.Up(context.TODO(), rs.db, Migrations)
+}
+
+const startRunQuery = `
+	INSERT INTO crawl_runs (partition, num_partitions, from_id, to_id, checkpoint, crawled, started_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+	RETURNING id, started_at, updated_at
+`
+
+// StartRun implements crawlrun.Store.
+func (rs *runStore) StartRun(run *crawlrun.Run) error {
+	return rs.StartRunContext(context.Background(), run)
+}
+
+// StartRunContext implements crawlrun.ContextStore.
+func (rs *runStore) StartRunContext(ctx context.Context, run *crawlrun.Run) error {
+	err := rs.db.QueryRowxContext(ctx, startRunQuery,
+		run.Partition, run.NumPartitions, run.FromID, run.ToID, run.Checkpoint, run.Crawled,
+	).Scan(&run.ID, &run.StartedAt, &run.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("start crawl run: %v", err)
+	}
+	return nil
+}
+
+const saveCheckpointQuery = `
+	UPDATE crawl_runs
+	SET checkpoint = $2, crawled = $3, updated_at = NOW()
+	WHERE id = $1
+`
+
+// SaveCheckpoint implements crawlrun.Store.
+func (rs *runStore) SaveCheckpoint(runID, checkpoint uuid.UUID, crawled int) error {
+	return rs.SaveCheckpointContext(context.Background(), runID, checkpoint, crawled)
+}
+
+// SaveCheckpointContext implements crawlrun.ContextStore.
+func (rs *runStore) SaveCheckpointContext(ctx context.Context, runID, checkpoint uuid.UUID, crawled int) error {
+	res, err := rs.db.ExecContext(ctx, saveCheckpointQuery, runID, checkpoint, crawled)
+	if err != nil {
+		return fmt.Errorf("save crawl checkpoint: %v", err)
+	}
+	return mustAffectRow(res)
+}
+
+const finishRunQuery = `
+	UPDATE crawl_runs
+	SET crawled = $2, updated_at = NOW(), finished_at = NOW()
+	WHERE id = $1
+`
+
+// FinishRun implements crawlrun.Store.
+func (rs *runStore) FinishRun(runID uuid.UUID, crawled int) error {
+	return rs.FinishRunContext(context.Background(), runID, crawled)
+}
+
+// FinishRunContext implements crawlrun.ContextStore.
+func (rs *runStore) FinishRunContext(ctx context.Context, runID uuid.UUID, crawled int) error {
+	res, err := rs.db.ExecContext(ctx, finishRunQuery, runID, crawled)
+	if err != nil {
+		return fmt.Errorf("finish crawl run: %v", err)
+	}
+	return mustAffectRow(res)
+}
+
+// unfinished runs over other extents that overlap [$1, $2)
+const abandonRunsQuery = `
+	UPDATE crawl_runs
+	SET abandoned = true, updated_at = NOW(), finished_at = NOW()
+	WHERE finished_at IS NULL AND from_id < $2 AND $1 < to_id AND (from_id <> $1 OR to_id <> $2)
+`
+
+const unfinishedRunQuery = `
+	SELECT ` + runColumns + `
+	FROM crawl_runs
+	WHERE from_id = $1 AND to_id = $2 AND finished_at IS NULL
+	ORDER BY started_at DESC
+	LIMIT 1
+`
+
+// UnfinishedRun implements crawlrun.Store.
+func (rs *runStore) UnfinishedRun(fromID, toID uuid.UUID) (*crawlrun.Run, error) {
+	return rs.UnfinishedRunContext(context.Background(), fromID, toID)
+}
+
+// UnfinishedRunContext implements crawlrun.ContextStore.
+func (rs *runStore) UnfinishedRunContext(ctx context.Context, fromID, toID uuid.UUID) (*crawlrun.Run, error) {
+	tx, err := rs.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("lookup unfinished crawl run: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, abandonRunsQuery, fromID, toID); err != nil {
+		return nil, fmt.Errorf("abandon crawl runs: %v", err)
+	}
+
+	run, err := scanRun(tx.QueryRowxContext(ctx, unfinishedRunQuery, fromID, toID))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("lookup unfinished crawl run: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("lookup unfinished crawl run: %v", err)
+	}
+	if run == nil {
+		return nil, crawlrun.ErrNotFound
+	}
+	return run, nil
+}
+
+const runsQuery = `
+	SELECT ` + runColumns + `
+	FROM crawl_runs
+	ORDER BY started_at DESC
+	OFFSET $1
+	LIMIT $2
+`
+
+// Runs implements crawlrun.Store.
+// limit <= 0 return all the remaining runs
+func (rs *runStore) Runs(offset, limit int) ([]*crawlrun.Run, error) {
+	return rs.RunsContext(context.Background(), offset, limit)
+}
+
+// RunsContext implements crawlrun.ContextStore.
+func (rs *runStore) RunsContext(ctx context.Context, offset, limit int) ([]*crawlrun.Run, error) {
+	// NULL limit is the same as no limit
+	rowLimit := sql.NullInt64{Int64: int64(limit), Valid: limit > 0}
+	rows, err := rs.db.QueryxContext(ctx, runsQuery, offset, rowLimit)
+	if err != nil {
+		return nil, fmt.Errorf("list crawl runs: %v", err)
+	}
+	defer rows.Close()
+
+	var runs []*crawlrun.Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list crawl runs: %v", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list crawl runs: %v", err)
+	}
+	return runs, nil
+}
+
+// the columns scanned by scanRun
+const runColumns = `id, partition, num_partitions, from_id, to_id, checkpoint, crawled, started_at, updated_at, finished_at, abandoned`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRun(row scanner) (*crawlrun.Run, error) {
+	var (
+		run      crawlrun.Run
+		finished sql.NullTime
+	)
+	err := row.Scan(
+		&run.ID,
+		&run.Partition,
+		&run.NumPartitions,
+		&run.FromID,
+		&run.ToID,
+		&run.Checkpoint,
+		&run.Crawled,
+		&run.StartedAt,
+		&run.UpdatedAt,
+		&finished,
+		&run.Abandoned,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if finished.Valid {
+		run.FinishedAt = finished.Time
+	}
+	return &run, nil
+}
+
+func mustAffectRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return crawlrun.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package postgrecrawl
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/linkcrawler/crawlrun"
+)
+
+func Test_crawl_run(t *testing.T) {
+	db, err := sqlx.Connect("pgx", "host=localhost user=development password=credential dbname=development sslmode=disable")
+	if err != nil {
+		t.Fatal("open db conn:", err)
+	}
+	rs, err := New(db)
+	if err != nil {
+		t.Fatal("create postgrecrawl instance:", err)
+	}
+	defer func() {
+		if err := rs.drop(); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+	}()
+
+	half := uuid.MustParse("80000000-0000-0000-0000-000000000000")
+	third := uuid.MustParse("55555555-5555-5555-5555-555555555555")
+	max := uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
+
+	if _, err := rs.UnfinishedRun(uuid.Nil, half); err != crawlrun.ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, crawlrun.ErrNotFound)
+	}
+
+	// interrupted run is resumed from its checkpoint
+	first := &crawlrun.Run{Partition: 0, NumPartitions: 2, FromID: uuid.Nil, ToID: half}
+	if err := rs.StartRun(first); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := uuid.MustParse("10000000-0000-0000-0000-000000000000")
+	if err := rs.SaveCheckpoint(first.ID, checkpoint, 10); err != nil {
+		t.Fatal(err)
+	}
+	unfinished, err := rs.UnfinishedRun(uuid.Nil, half)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unfinished.ID != first.ID || unfinished.Checkpoint != checkpoint || unfinished.Crawled != 10 {
+		t.Fatalf("\ngot: %+v\nexpect checkpoint: %v", unfinished, checkpoint)
+	}
+	if err := rs.FinishRun(first.ID, 20); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.UnfinishedRun(uuid.Nil, half); err != crawlrun.ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, crawlrun.ErrNotFound)
+	}
+	if err := rs.SaveCheckpoint(uuid.New(), checkpoint, 1); err != crawlrun.ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, crawlrun.ErrNotFound)
+	}
+
+	// unfinished run of the second of two partitions is abandoned
+	// when the partitions are rebalanced into three
+	second := &crawlrun.Run{Partition: 1, NumPartitions: 2, FromID: half, ToID: max}
+	if err := rs.StartRun(second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.UnfinishedRun(third, max); err != crawlrun.ErrNotFound {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, crawlrun.ErrNotFound)
+	}
+
+	runs, err := rs.Runs(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != second.ID || !runs[0].Abandoned || !runs[0].Finished() {
+		t.Fatalf("\ngot: %+v", runs)
+	}
+	if runs[1].ID != first.ID || runs[1].Abandoned || runs[1].Crawled != 20 {
+		t.Fatalf("\ngot: %+v", runs[1])
+	}
+
+	runs, err = rs.Runs(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].ID != first.ID {
+		t.Fatalf("\ngot: %+v", runs)
+	}
+}
@@ -0,0 +1,16 @@
+-- IF NOT EXISTS so database created before versioned migrations is adopted as it is
+CREATE TABLE IF NOT EXISTS crawl_runs(
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	partition int NOT NULL,
+	num_partitions int NOT NULL,
+	from_id UUID NOT NULL,
+	to_id UUID NOT NULL,
+	checkpoint UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+	crawled bigint NOT NULL DEFAULT 0,
+	started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS crawl_runs_unfinished_idx ON crawl_runs (from_id, to_id, started_at)
+WHERE finished_at IS NULL;
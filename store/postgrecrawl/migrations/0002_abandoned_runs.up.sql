@@ -0,0 +1,2 @@
+-- run closed because the partition extents changed before it finished
+ALTER TABLE crawl_runs ADD COLUMN IF NOT EXISTS abandoned boolean NOT NULL DEFAULT false;
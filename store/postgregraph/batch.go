@@ -0,0 +1,70 @@
+package postgregraph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+var _ graph.BatchGraph = (*postgre)(nil)
+
+// NOW() is the transaction start, the edges upserted by the same transaction
+// have it as update_at so they are kept. the cutoff use the database clock,
+// the same clock that set update_at.
+const edgeBulkRemoveStaleQuery = `
+	DELETE FROM edges e
+	USING unnest($1::uuid[]) AS input(src)
+	WHERE e.src = input.src AND e.update_at < NOW()
+`
+
+// UpdateBatchContext implements graph.BatchGraph.
+func (p *postgre) UpdateBatchContext(ctx context.Context, batch *graph.Batch) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("update batch: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertLinks(ctx, tx, batch.Links); err != nil {
+		return err
+	}
+
+	edges := make([]*graph.Edge, len(batch.Edges))
+	for i, edge := range batch.Edges {
+		edges[i] = &graph.Edge{Src: edge.Src.ID, Dst: edge.Dst.ID}
+	}
+	if err := upsertEdges(ctx, tx, edges); err != nil {
+		return err
+	}
+
+	if err := removeStaleEdges(ctx, tx, batch.StaleSources); err != nil {
+		return err
+	}
+
+	if err := recordFetches(ctx, tx, batch.Fetches); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("update batch: %v", err)
+	}
+	return nil
+}
+
+// remove the out edges of srcs that not upserted by tx
+func removeStaleEdges(ctx context.Context, tx *sqlx.Tx, srcs []*graph.Link) error {
+	if len(srcs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(srcs))
+	for i, src := range srcs {
+		ids[i] = src.ID.String()
+	}
+	if _, err := tx.ExecContext(ctx, edgeBulkRemoveStaleQuery, ids); err != nil {
+		return fmt.Errorf("remove stale edge: %v", err)
+	}
+	return nil
+}
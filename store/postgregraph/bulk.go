@@ -0,0 +1,219 @@
+package postgregraph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+// multi-row version of linkUpsertQuery,
+// the same url cannot appear twice in one statement so the input must be deduped.
+const linkBulkUpsertQuery = `
+	INSERT INTO links (id, url, retrieved_at, host, depth, status_code, content_type, content_hash, source, next_crawl_at)
+	SELECT
+		CASE
+			WHEN i.id = '' OR EXISTS (SELECT 1 FROM links WHERE id = i.id::uuid) THEN gen_random_uuid()
+			ELSE i.id::uuid
+		END,
+		i.url, i.retrieved_at, i.host, i.depth, i.status_code, i.content_type, i.content_hash, i.source, i.next_crawl_at
+	FROM unnest(
+		$1::text[], $2::timestamp[], $3::text[], $4::integer[], $5::integer[],
+		$6::text[], $7::text[], $8::text[], $9::timestamp[], $10::text[]
+	) AS i(url, retrieved_at, host, depth, status_code, content_type, content_hash, source, next_crawl_at, id)
+` + linkUpsertConflict + `
+	RETURNING ` + linkColumns
+
+// UpsertLinks implements graph.Graph.
+func (p *postgre) UpsertLinks(links []*graph.Link) error {
+	return p.UpsertLinksContext(context.Background(), links)
+}
+
+// UpsertLinksContext implements graph.ContextGraph.
+func (p *postgre) UpsertLinksContext(ctx context.Context, links []*graph.Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("upsert links: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertLinks(ctx, tx, links); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("upsert links: %v", err)
+	}
+	return nil
+}
+
+// run the bulk upsert inside tx, the stored link is copied back into links
+func upsertLinks(ctx context.Context, tx *sqlx.Tx, links []*graph.Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	// dedupe by url, merge the metadata like the upsert does
+	byURL := make(map[string][]*graph.Link, len(links))
+	pos := make(map[string]int, len(links))
+	merged := make([]*graph.Link, 0, len(links))
+	for _, link := range links {
+		link.RetrievedAt = link.RetrievedAt.UTC()
+		link.NextCrawlAt = link.NextCrawlAt.UTC()
+		if link.Host == "" {
+			link.Host = graph.HostOf(link.URL)
+		}
+
+		if idx, ok := pos[link.URL]; ok {
+			graph.MergeLink(merged[idx], link)
+		} else {
+			first := *link
+			pos[link.URL] = len(merged)
+			merged = append(merged, &first)
+		}
+		byURL[link.URL] = append(byURL[link.URL], link)
+	}
+
+	var (
+		urls         = make([]string, len(merged))
+		retrieved    = make([]time.Time, len(merged))
+		hosts        = make([]string, len(merged))
+		depths       = make([]int, len(merged))
+		statusCodes  = make([]int, len(merged))
+		contentTypes = make([]string, len(merged))
+		contentHash  = make([]string, len(merged))
+		sources      = make([]string, len(merged))
+		nextCrawl    = make([]time.Time, len(merged))
+		ids          = make([]string, len(merged))
+	)
+	for i, link := range merged {
+		urls[i] = link.URL
+		retrieved[i] = link.RetrievedAt
+		hosts[i] = link.Host
+		depths[i] = link.Depth
+		statusCodes[i] = link.StatusCode
+		contentTypes[i] = link.ContentType
+		contentHash[i] = link.ContentHash
+		sources[i] = string(link.Source)
+		nextCrawl[i] = link.NextCrawlAt
+		if link.ID != uuid.Nil {
+			ids[i] = link.ID.String()
+		}
+	}
+
+	rows, err := tx.QueryxContext(ctx, linkBulkUpsertQuery,
+		urls, retrieved, hosts, depths, statusCodes, contentTypes, contentHash, sources, nextCrawl, ids,
+	)
+	if err != nil {
+		return fmt.Errorf("upsert links: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stored graph.Link
+		if err := scanLink(rows, &stored); err != nil {
+			return fmt.Errorf("upsert links: %v", err)
+		}
+		for _, link := range byURL[stored.URL] {
+			*link = stored
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("upsert links: %v", err)
+	}
+	return nil
+}
+
+// multi-row version of edgeUpsertQuery
+const edgeBulkUpsertQuery = `
+	INSERT INTO edges (src, dst, update_at)
+	SELECT src, dst, NOW() FROM unnest($1::uuid[], $2::uuid[]) AS input(src, dst)
+	ON CONFLICT (src,dst) DO UPDATE SET update_at=NOW()
+	RETURNING id, src, dst, update_at
+`
+
+// UpsertEdges implements graph.Graph.
+func (p *postgre) UpsertEdges(edges []*graph.Edge) error {
+	return p.UpsertEdgesContext(context.Background(), edges)
+}
+
+// UpsertEdgesContext implements graph.ContextGraph.
+func (p *postgre) UpsertEdgesContext(ctx context.Context, edges []*graph.Edge) error {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("edges upsert: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertEdges(ctx, tx, edges); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("edges upsert: %v", err)
+	}
+	return nil
+}
+
+// run the bulk upsert inside tx, the stored edge is copied back into edges
+func upsertEdges(ctx context.Context, tx *sqlx.Tx, edges []*graph.Edge) error {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	// dedupe by (src,dst)
+	type edgeKey struct{ src, dst string }
+	byKey := make(map[edgeKey][]*graph.Edge, len(edges))
+	srcs := make([]string, 0, len(edges))
+	dsts := make([]string, 0, len(edges))
+	for _, edge := range edges {
+		key := edgeKey{edge.Src.String(), edge.Dst.String()}
+		if _, ok := byKey[key]; !ok {
+			srcs = append(srcs, key.src)
+			dsts = append(dsts, key.dst)
+		}
+		byKey[key] = append(byKey[key], edge)
+	}
+
+	rows, err := tx.QueryxContext(ctx, edgeBulkUpsertQuery, srcs, dsts)
+	if err != nil {
+		return edgeUpsertErr(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stored graph.Edge
+		if err := rows.Scan(&stored.ID, &stored.Src, &stored.Dst, &stored.UpdateAt); err != nil {
+			return fmt.Errorf("edges upsert: %v", err)
+		}
+		for _, edge := range byKey[edgeKey{stored.Src.String(), stored.Dst.String()}] {
+			*edge = stored
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return edgeUpsertErr(err)
+	}
+	return nil
+}
+
+// translate foreign key violation into graph.ErrUnknownEdgeLinks
+func edgeUpsertErr(err error) error {
+	pgErr, ok := err.(*pgconn.PgError)
+	if ok {
+		switch pgErr.Code {
+		case "23503":
+			return graph.ErrUnknownEdgeLinks
+		}
+	}
+	return fmt.Errorf("edges upsert: %v", err)
+}
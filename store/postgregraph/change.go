@@ -0,0 +1,229 @@
+package postgregraph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+var _ graph.ChangeFeed = (*postgre)(nil)
+
+// channel notified after change recorded
+const changeChannel = "graph_changes"
+
+// time between check of the pending changes while waiting,
+// the transaction that hold them back may end without notification
+const pendingChangePoll = time.Second
+
+// the triggers record the changes without position, the reader assign it to the changes
+// whose transaction is older than every running transaction (below the snapshot xmin),
+// in transaction order. they are committed already, and no change recorded later
+// can be older, so the position never skip change that is committed afterward.
+const tryLockChangesQuery = `
+	SELECT pg_try_advisory_xact_lock(hashtext('graph_changes'))
+`
+
+const assignChangesQuery = `
+	UPDATE graph_changes g SET pos = s.pos
+	FROM (
+		SELECT id, nextval('graph_changes_pos_seq') AS pos
+		FROM (
+			SELECT id FROM graph_changes
+			WHERE pos IS NULL AND xid < pg_snapshot_xmin(pg_current_snapshot())
+			ORDER BY xid, id
+		) stable
+	) s
+	WHERE g.id = s.id
+`
+
+// assign position to the stable changes, only one reader assign at a time,
+// the others read what is already assigned.
+func (p *postgre) assignChanges(ctx context.Context) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowxContext(ctx, tryLockChangesQuery).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, assignChangesQuery); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// the pruned cursor is returned when every change is pruned
+const lastChangeQuery = `
+	SELECT GREATEST(COALESCE(MAX(pos), 0), (SELECT cursor FROM graph_changes_pruned))
+	FROM graph_changes
+`
+
+const prunedCursorQuery = `
+	SELECT cursor FROM graph_changes_pruned
+`
+
+// LastChange implements graph.ChangeFeed.
+func (p *postgre) LastChange(ctx context.Context) (uint64, error) {
+	if err := p.assignChanges(ctx); err != nil {
+		return 0, fmt.Errorf("last change: %v", err)
+	}
+
+	var last int64
+	if err := p.db.QueryRowxContext(ctx, lastChangeQuery).Scan(&last); err != nil {
+		return 0, fmt.Errorf("last change: %v", err)
+	}
+	return uint64(last), nil
+}
+
+// limit NULL return every change
+const changesQuery = `
+	SELECT pos, kind, link_id, COALESCE(edge_id, '00000000-0000-0000-0000-000000000000'), at
+	FROM graph_changes
+	WHERE pos > $1
+	ORDER BY pos
+	LIMIT $2
+`
+
+// Changes implements graph.ChangeFeed.
+func (p *postgre) Changes(ctx context.Context, after uint64, limit int) ([]*graph.Change, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	if err := p.assignChanges(ctx); err != nil {
+		return nil, fmt.Errorf("changes: %v", err)
+	}
+
+	rows, err := p.db.QueryxContext(ctx, changesQuery, int64(after), limitArg)
+	if err != nil {
+		return nil, fmt.Errorf("changes: %v", err)
+	}
+	defer rows.Close()
+
+	var list []*graph.Change
+	for rows.Next() {
+		var (
+			change graph.Change
+			cursor int64
+			kind   string
+		)
+		if err := rows.Scan(&cursor, &kind, &change.LinkID, &change.EdgeID, &change.At); err != nil {
+			return nil, fmt.Errorf("changes: %v", err)
+		}
+		change.Cursor = uint64(cursor)
+		change.Kind = graph.ChangeKind(kind)
+		change.At = change.At.UTC()
+		list = append(list, &change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("changes: %v", err)
+	}
+
+	// checked after reading, the changes read before concurrent prune are reported as pruned too
+	var pruned int64
+	if err := p.db.QueryRowxContext(ctx, prunedCursorQuery).Scan(&pruned); err != nil {
+		return nil, fmt.Errorf("changes: %v", err)
+	}
+	if int64(after) < pruned {
+		return nil, graph.ErrChangesPruned
+	}
+	return list, nil
+}
+
+// changes are removed in cursor order, so the horizon is the last removed cursor.
+// pending changes has no position yet, they are kept.
+const pruneChangesQuery = `
+	WITH removed AS (
+		DELETE FROM graph_changes
+		WHERE pos <= (SELECT MAX(pos) FROM graph_changes WHERE at < $1)
+		RETURNING pos
+	), horizon AS (
+		UPDATE graph_changes_pruned
+		SET cursor = GREATEST(cursor, (SELECT MAX(pos) FROM removed))
+		WHERE EXISTS (SELECT 1 FROM removed)
+	)
+	SELECT count(*) FROM removed
+`
+
+// PruneChanges implements graph.ChangeFeed.
+func (p *postgre) PruneChanges(ctx context.Context, before time.Time) (int, error) {
+	var removed int
+	if err := p.db.QueryRowxContext(ctx, pruneChangesQuery, before.UTC()).Scan(&removed); err != nil {
+		return 0, fmt.Errorf("prune changes: %v", err)
+	}
+	return removed, nil
+}
+
+// pruned cursor wake the waiter, the next read report it
+const changeExistQuery = `
+	SELECT
+		EXISTS (SELECT 1 FROM graph_changes WHERE pos > $1)
+			OR $1 < (SELECT cursor FROM graph_changes_pruned),
+		EXISTS (SELECT 1 FROM graph_changes WHERE pos IS NULL)
+`
+
+// WaitChange implements graph.ChangeFeed.
+// it hold a connection of the pool while waiting for the notification.
+func (p *postgre) WaitChange(ctx context.Context, after uint64) error {
+	if err := p.assignChanges(ctx); err != nil {
+		return fmt.Errorf("wait change: %v", err)
+	}
+
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("wait change: %v", err)
+	}
+	defer conn.Close()
+
+	// listen before checking the log, so change recorded in between is not missed
+	if _, err := conn.ExecContext(ctx, "LISTEN "+changeChannel); err != nil {
+		return fmt.Errorf("wait change: %v", err)
+	}
+	defer conn.ExecContext(context.Background(), "UNLISTEN "+changeChannel)
+
+	var exist, pending bool
+	if err := conn.QueryRowContext(ctx, changeExistQuery, int64(after)).Scan(&exist, &pending); err != nil {
+		return fmt.Errorf("wait change: %v", err)
+	}
+	if exist {
+		return nil
+	}
+
+	// the pending changes get their position when the older transaction end,
+	// return after a while so the caller read again
+	waitCtx := ctx
+	if pending {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, pendingChangePoll)
+		defer cancel()
+	}
+
+	return conn.Raw(func(driverConn interface{}) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("wait change: driver does not support notification")
+		}
+		_, err := sc.Conn().WaitForNotification(waitCtx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if waitCtx.Err() != nil {
+			// poll timeout
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("wait change: %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,181 @@
+package postgregraph
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+var _ graph.HostGraph = (*postgre)(nil)
+var _ graph.ContextHostGraph = (*postgre)(nil)
+
+// host rows are upserted in name order so concurrent batches not deadlock,
+// empty robots status keep the recorded one.
+const recordFetchesQuery = `
+	INSERT INTO hosts (name, fetches, fetch_errors, total_response_ms, last_crawl_at, robots_status)
+	SELECT * FROM unnest($1::text[], $2::bigint[], $3::bigint[], $4::bigint[], $5::timestamp[], $6::text[])
+		AS i(name, fetches, fetch_errors, total_response_ms, last_crawl_at, robots_status)
+	ORDER BY name
+	ON CONFLICT (name) DO UPDATE SET
+		fetches = hosts.fetches + EXCLUDED.fetches,
+		fetch_errors = hosts.fetch_errors + EXCLUDED.fetch_errors,
+		total_response_ms = hosts.total_response_ms + EXCLUDED.total_response_ms,
+		last_crawl_at = GREATEST(hosts.last_crawl_at, EXCLUDED.last_crawl_at),
+		robots_status = COALESCE(NULLIF(EXCLUDED.robots_status, ''), hosts.robots_status)
+`
+
+// RecordFetches implements graph.HostGraph.
+func (p *postgre) RecordFetches(fetches []*graph.HostFetch) error {
+	return p.RecordFetchesContext(context.Background(), fetches)
+}
+
+// RecordFetchesContext implements graph.ContextHostGraph.
+func (p *postgre) RecordFetchesContext(ctx context.Context, fetches []*graph.HostFetch) error {
+	return recordFetches(ctx, p.db, fetches)
+}
+
+// db is the pool or the transaction of a batch
+func recordFetches(ctx context.Context, db sqlx.ExecerContext, fetches []*graph.HostFetch) error {
+	if len(fetches) == 0 {
+		return nil
+	}
+
+	// one row per host, the same host cannot appear twice in one statement
+	pos := make(map[string]int, len(fetches))
+	var (
+		names      []string
+		count      []int64
+		errCount   []int64
+		responseMS []int64
+		lastCrawl  []time.Time
+		robots     []string
+	)
+	for _, fetch := range fetches {
+		i, ok := pos[fetch.Host]
+		if !ok {
+			i = len(names)
+			pos[fetch.Host] = i
+			names = append(names, fetch.Host)
+			count = append(count, 0)
+			errCount = append(errCount, 0)
+			responseMS = append(responseMS, 0)
+			lastCrawl = append(lastCrawl, time.Time{})
+			robots = append(robots, "")
+		}
+
+		count[i]++
+		if fetch.Failed() {
+			errCount[i]++
+		}
+		responseMS[i] += fetch.ResponseTime.Milliseconds()
+		if fetchedAt := fetch.FetchedAt.UTC(); fetchedAt.After(lastCrawl[i]) {
+			lastCrawl[i] = fetchedAt
+		}
+		if fetch.RobotsStatus != graph.RobotsUnknown {
+			robots[i] = string(fetch.RobotsStatus)
+		}
+	}
+
+	_, err := db.ExecContext(ctx, recordFetchesQuery, names, count, errCount, responseMS, lastCrawl, robots)
+	if err != nil {
+		return fmt.Errorf("record fetches: %v", err)
+	}
+	return nil
+}
+
+// every host field, the links count and inter-host edges are recounted by HostCountService.
+const hostColumns = `name, known_pages, crawled_pages, fetches, fetch_errors, total_response_ms, last_crawl_at, robots_status, out_edges, in_edges`
+
+// host row without link nor fetch is left after its links removed
+const lookupHostQuery = `
+	SELECT ` + hostColumns + ` FROM hosts
+	WHERE name = $1 AND (known_pages > 0 OR fetches > 0)
+`
+
+// LookupHost implements graph.HostGraph.
+func (p *postgre) LookupHost(name string) (*graph.Host, error) {
+	return p.LookupHostContext(context.Background(), name)
+}
+
+// LookupHostContext implements graph.ContextHostGraph.
+func (p *postgre) LookupHostContext(ctx context.Context, name string) (*graph.Host, error) {
+	host, err := scanHost(p.db.QueryRowxContext(ctx, lookupHostQuery, name))
+	if err != nil {
+		if err == graph.ErrNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("lookup host: %v", err)
+	}
+	return host, nil
+}
+
+// only the fetched hosts, every host of the links has row
+const hostsQuery = `
+	SELECT ` + hostColumns + ` FROM hosts
+	WHERE fetches > 0
+	ORDER BY name
+	OFFSET $1 LIMIT $2
+`
+
+// Hosts implements graph.HostGraph.
+func (p *postgre) Hosts(offset, limit int) ([]*graph.Host, error) {
+	return p.HostsContext(context.Background(), offset, limit)
+}
+
+// HostsContext implements graph.ContextHostGraph.
+func (p *postgre) HostsContext(ctx context.Context, offset, limit int) ([]*graph.Host, error) {
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+	rows, err := p.db.QueryxContext(ctx, hostsQuery, offset, limitArg)
+	if err != nil {
+		return nil, fmt.Errorf("hosts: %v", err)
+	}
+	defer rows.Close()
+
+	var hosts []*graph.Host
+	for rows.Next() {
+		host, err := scanHost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("hosts: %v", err)
+		}
+		hosts = append(hosts, host)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("hosts: %v", err)
+	}
+	return hosts, nil
+}
+
+// scan row selected with hostColumns
+func scanHost(row scanner) (*graph.Host, error) {
+	var (
+		host       graph.Host
+		responseMS int64
+	)
+	err := row.Scan(
+		&host.Name,
+		&host.KnownPages,
+		&host.CrawledPages,
+		&host.Fetches,
+		&host.FetchErrors,
+		&responseMS,
+		&host.LastCrawlAt,
+		&host.RobotsStatus,
+		&host.OutEdges,
+		&host.InEdges,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, graph.ErrNotFound
+		}
+		return nil, err
+	}
+	host.TotalResponseTime = time.Duration(responseMS) * time.Millisecond
+	return &host, nil
+}
@@ -0,0 +1,100 @@
+package postgregraph
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// recount the links and inter-host edges of every host and write the changed counters,
+// it is the only writer of the counters so the graph writes never lock the host rows.
+// rows are written in name order, the host that no longer has link is zeroed.
+const refreshHostCountsQuery = `
+WITH pages AS (
+	SELECT host, count(*) AS known, count(*) FILTER (WHERE retrieved_at > '0001-01-01 00:00:00') AS crawled
+	FROM links WHERE host <> '' GROUP BY host
+), cross_edges AS (
+	SELECT s.host AS src, d.host AS dst FROM edges e
+		JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+		WHERE s.host <> d.host
+), edge_counts AS (
+	SELECT host, sum(out_edges) AS out_edges, sum(in_edges) AS in_edges FROM (
+		SELECT src AS host, 1 AS out_edges, 0 AS in_edges FROM cross_edges WHERE src <> ''
+		UNION ALL
+		SELECT dst, 0, 1 FROM cross_edges WHERE dst <> ''
+	) c GROUP BY host
+), counts AS (
+	SELECT COALESCE(p.host, e.host) AS host,
+		COALESCE(p.known, 0) AS known, COALESCE(p.crawled, 0) AS crawled,
+		COALESCE(e.out_edges, 0) AS out_edges, COALESCE(e.in_edges, 0) AS in_edges
+	FROM pages p FULL JOIN edge_counts e ON e.host = p.host
+), upserted AS (
+	INSERT INTO hosts (name, known_pages, crawled_pages, out_edges, in_edges)
+	SELECT host, known, crawled, out_edges, in_edges FROM counts ORDER BY host
+	ON CONFLICT (name) DO UPDATE SET
+		known_pages = EXCLUDED.known_pages,
+		crawled_pages = EXCLUDED.crawled_pages,
+		out_edges = EXCLUDED.out_edges,
+		in_edges = EXCLUDED.in_edges
+	WHERE (hosts.known_pages, hosts.crawled_pages, hosts.out_edges, hosts.in_edges)
+		IS DISTINCT FROM (EXCLUDED.known_pages, EXCLUDED.crawled_pages, EXCLUDED.out_edges, EXCLUDED.in_edges)
+)
+UPDATE hosts h SET known_pages = 0, crawled_pages = 0, out_edges = 0, in_edges = 0
+WHERE NOT EXISTS (SELECT 1 FROM counts c WHERE c.host = h.name)
+	AND (h.known_pages, h.crawled_pages, h.out_edges, h.in_edges) <> (0, 0, 0, 0)
+`
+
+// scan every link and edge, it is run periodically by HostCountService
+func (p *postgre) refreshHostCounts(ctx context.Context) error {
+	if _, err := p.db.ExecContext(ctx, refreshHostCountsQuery); err != nil {
+		return fmt.Errorf("refresh host counts: %v", err)
+	}
+	return nil
+}
+
+// HostCountService periodically recount the links and inter-host edges of the hosts.
+// the counters lag behind the graph until the next refresh,
+// the fetch statistic is recorded by the crawler and always current.
+type HostCountService struct {
+	graph    *postgre
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewHostCountService(g *postgre, interval time.Duration) (*HostCountService, error) {
+	if g == nil {
+		return nil, fmt.Errorf("graph has not been provided")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid value for host count interval")
+	}
+	return &HostCountService{
+		graph:    g,
+		interval: interval,
+		logger:   log.New(os.Stdout, "[host-count]", log.Ldate|log.Ltime),
+	}, nil
+}
+
+// Name implements service.Service
+func (svc *HostCountService) Name() string { return "host count" }
+
+// Run implements service.Service
+func (svc *HostCountService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(svc.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := svc.graph.refreshHostCounts(ctx); err != nil && ctx.Err() == nil {
+			// keep the previous count, try again next tick
+			svc.logger.Printf("[ERROR] %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -13,16 +13,53 @@ import (
 //==========
 
 const linksIterationQuery = `
-	SELECT id, url, retrieved_at 
-	FROM links 
-	WHERE id >= $1 AND id < $2 AND retrieved_at < $3
-	`
+	SELECT ` + linkColumns + `
+	FROM links
+	WHERE id >= $1 AND id < $2`
+
+// append condition of non-zero filter field to linksIterationQuery
+func linksQuery(fromID, toID uuid.UUID, filter graph.LinkFilter) (string, []interface{}) {
+	query := linksIterationQuery
+	args := []interface{}{fromID, toID}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+cond, len(args))
+	}
+
+	if !filter.RetrievedBefore.IsZero() {
+		where("retrieved_at < $%d", filter.RetrievedBefore.UTC())
+	}
+	if !filter.NextCrawlBefore.IsZero() {
+		where("next_crawl_at < $%d", filter.NextCrawlBefore.UTC())
+	}
+	if filter.Host != "" {
+		where("host = $%d", filter.Host)
+	}
+	if filter.MaxDepth > 0 {
+		where("depth <= $%d", filter.MaxDepth)
+	}
+	if filter.StatusCode != 0 {
+		where("status_code = $%d", filter.StatusCode)
+	}
+	if filter.ContentType != "" {
+		where("content_type = $%d", filter.ContentType)
+	}
+	if filter.Source != graph.SourceUnknown {
+		where("source = $%d", string(filter.Source))
+	}
+
+	return query + " ORDER BY id", args
+}
 
 // Links implements graph.Graph.
-func (p *postgre) Links(fromID uuid.UUID, toID uuid.UUID, accessBefore time.Time) (graph.LinkIterator, error) {
+func (p *postgre) Links(fromID uuid.UUID, toID uuid.UUID, filter graph.LinkFilter) (graph.LinkIterator, error) {
+	return p.LinksContext(context.Background(), fromID, toID, filter)
+}
 
-	// rows := p.db.QueryRowxContext(context.Background(), linksQuery, fromID, toID, retrieveBefore.UTC())
-	rows, err := p.db.QueryxContext(context.TODO(), linksIterationQuery, fromID, toID, accessBefore.UTC())
+// LinksContext implements graph.ContextGraph.
+func (p *postgre) LinksContext(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, filter graph.LinkFilter) (graph.LinkIterator, error) {
+	query, args := linksQuery(fromID, toID, filter)
+	rows, err := p.db.QueryxContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,12 +78,18 @@ const edgesIterationQuery = `
 	SELECT id, src, dst, update_at 
 	FROM edges 
 	WHERE src >= $1 AND src < $2 AND update_at < $3
+	ORDER BY src, id
 `
 
 // Edges implements graph.Graph.
 func (p *postgre) Edges(fromID uuid.UUID, toID uuid.UUID, updateBefore time.Time) (graph.EdgeIterator, error) {
+	return p.EdgesContext(context.Background(), fromID, toID, updateBefore)
+}
+
+// EdgesContext implements graph.ContextGraph.
+func (p *postgre) EdgesContext(ctx context.Context, fromID uuid.UUID, toID uuid.UUID, updateBefore time.Time) (graph.EdgeIterator, error) {
 	//find edges row
-	rows, err := p.db.QueryxContext(context.TODO(), edgesIterationQuery, fromID, toID, updateBefore.UTC())
+	rows, err := p.db.QueryxContext(ctx, edgesIterationQuery, fromID, toID, updateBefore.UTC())
 	if err != nil {
 		return nil, fmt.Errorf("edge iterator: %v", err)
 	}
@@ -94,7 +137,7 @@ func (it *iterator) Error() error {
 // Link implements graph.LinkIterator.
 func (it *iterator) Link() *graph.Link {
 	var link graph.Link
-	it.lastErr = it.rows.Scan(&link.ID, &link.URL, &link.RetrievedAt) //Scan(&link)
+	it.lastErr = scanLink(it.rows, &link)
 	if it.lastErr != nil {
 		return nil
 	}
@@ -2,14 +2,15 @@ package postgregraph
 
 import (
 	"context"
-	"fmt"
 	"log"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/internal/migrate"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 )
 
 var _ graph.Graph = (*postgre)(nil)
+var _ graph.ContextGraph = (*postgre)(nil)
 
 type postgre struct {
 	db *sqlx.DB
@@ -25,46 +26,7 @@ func New(db *sqlx.DB) *postgre {
 	return &p
 }
 
-// ==============
-
-// const dropLinksTable = `
-// DROP TABLE IF EXISTS links;
-// `
-
-// const dropEdgeTable = `
-// DROP TABLE IF EXISTS edges;
-// `
-
-const createLinkTableQuery = `
-		CREATE TABLE IF NOT EXISTS links(
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			url text UNIQUE,
-			retrieved_at TIMESTAMP 
-		);
-`
-
-const createEdgeTableQuery = `
-		CREATE TABLE IF NOT EXISTS edges(
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			src UUID NOT NULL REFERENCES links(id) ON DELETE CASCADE,
-			dst UUID NOT NULL REFERENCES links(id) ON DELETE CASCADE,
-			update_at TIMESTAMP,
-			CONSTRAINT edge_links UNIQUE(src,dst)
-		);
-`
-
+// Migrate apply the schema migrations of the graph that not applied yet
 func (p *postgre) Migrate() error {
-	//link table
-	_, err := p.db.ExecContext(context.TODO(), createLinkTableQuery)
-	if err != nil {
-		return fmt.Errorf("create table: %v", err)
-	}
-
-	//edge table
-	_, err = p.db.ExecContext(context.TODO(), createEdgeTableQuery)
-	if err != nil {
-		return fmt.Errorf("create table: %v", err)
-	}
-
-	return nil
+	return migrate.Up(context.TODO(), p.db, Migrations)
 }
@@ -14,6 +14,7 @@ import (
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/odit-bit/invoker/linkgraph/graph"
+	graphtest "github.com/odit-bit/invoker/linkgraph/graph_test"
 )
 
 type Migrate struct {
@@ -26,7 +27,15 @@ var linkTable = Migrate{
 		CREATE TABLE IF NOT EXISTS links(
 			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
 			url text UNIQUE,
-			retrieved_at TIMESTAMP
+			retrieved_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+			host text NOT NULL DEFAULT '',
+			depth integer NOT NULL DEFAULT 0,
+			status_code integer NOT NULL DEFAULT 0,
+			content_type text NOT NULL DEFAULT '',
+			content_hash text NOT NULL DEFAULT '',
+			source text NOT NULL DEFAULT '',
+			next_crawl_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00'
 		);
 	`,
 	Drop: `
@@ -63,6 +72,17 @@ var pg = func() *postgre {
 	return pg
 }()
 
+// run the migration script on tables created by the test
+func execMigration(t *testing.T, name string) {
+	script, err := migrationFiles.ReadFile("migrations/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pg.db.ExecContext(context.TODO(), string(script)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func Test_postgredb(t *testing.T) {
 	t.Run("link upsert logic", test_upsert_link)
 	t.Run("link lookup logic", test_lookup_link)
@@ -71,7 +91,30 @@ func Test_postgredb(t *testing.T) {
 	t.Run("link iterator filter login logic", test_Link_iterator_Timefilter)
 
 	t.Run("edge upsert logic", test_upsert_edge)
+	t.Run("in/out edges and degree", test_neighbour_edges)
+	t.Run("link removal", test_remove_link)
+	t.Run("batch update", test_update_batch)
+	t.Run("link metadata", test_link_metadata)
+	t.Run("host statistic", test_host)
+	t.Run("shared suite", test_suite)
+
+}
 
+// every test of the suite run on fresh tables
+func test_suite(t *testing.T) {
+	graphtest.Run(t, func(t *testing.T) graph.Graph {
+		pg.db.ExecContext(context.TODO(), linkTable.Create)
+		pg.db.ExecContext(context.TODO(), edgeTable.Create)
+		execMigration(t, "0006_change_log.up.sql")
+		execMigration(t, "0008_change_log_retention.up.sql")
+		execMigration(t, "0009_change_log_statement.up.sql")
+		t.Cleanup(func() {
+			pg.db.ExecContext(context.TODO(), "DROP TABLE IF EXISTS graph_changes, graph_changes_pruned; DROP SEQUENCE IF EXISTS graph_changes_pos_seq")
+			pg.db.ExecContext(context.TODO(), edgeTable.Drop)
+			pg.db.ExecContext(context.TODO(), linkTable.Drop)
+		})
+		return pg
+	})
 }
 
 func test_upsert_edge(t *testing.T) {
@@ -149,6 +192,328 @@ func test_upsert_edge(t *testing.T) {
 	}
 }
 
+func test_neighbour_edges(t *testing.T) {
+	pg.db.ExecContext(context.TODO(), linkTable.Create)
+	pg.db.ExecContext(context.TODO(), edgeTable.Create)
+	defer func() {
+		pg.db.ExecContext(context.TODO(), edgeTable.Drop)
+		pg.db.ExecContext(context.TODO(), linkTable.Drop)
+	}()
+
+	linkUUIDs := make([]uuid.UUID, 3)
+	for i := 0; i < 3; i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		if err := pg.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+		linkUUIDs[i] = link.ID
+	}
+
+	// 0 -> 1, 0 -> 2, 2 -> 1
+	for _, pair := range [][2]int{{0, 1}, {0, 2}, {2, 1}} {
+		if err := pg.UpsertEdge(&graph.Edge{Src: linkUUIDs[pair[0]], Dst: linkUUIDs[pair[1]]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in, out, err := pg.Degree(linkUUIDs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in != 2 || out != 0 {
+		t.Fatalf("\ngot:%v/%v\nexpect:%v/%v", in, out, 2, 0)
+	}
+
+	it, err := pg.InEdges(linkUUIDs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for it.Next() {
+		if edge := it.Edge(); edge.Dst != linkUUIDs[1] {
+			t.Fatalf("\ngot:%v\nexpect:%v", edge.Dst, linkUUIDs[1])
+		}
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	it.Close()
+	if count != 2 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 2)
+	}
+
+	it, err = pg.OutEdges(linkUUIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	count = 0
+	for it.Next() {
+		if edge := it.Edge(); edge.Src != linkUUIDs[0] {
+			t.Fatalf("\ngot:%v\nexpect:%v", edge.Src, linkUUIDs[0])
+		}
+		count++
+	}
+	it.Close()
+	if count != 2 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 2)
+	}
+}
+
+func test_remove_link(t *testing.T) {
+	pg.db.ExecContext(context.TODO(), linkTable.Create)
+	pg.db.ExecContext(context.TODO(), edgeTable.Create)
+	defer func() {
+		pg.db.ExecContext(context.TODO(), edgeTable.Drop)
+		pg.db.ExecContext(context.TODO(), linkTable.Drop)
+	}()
+
+	// src is retrieved, dst is only discovered, orphan is neither
+	src := &graph.Link{URL: "https://src.com", RetrievedAt: time.Now()}
+	dst := &graph.Link{URL: "https://dst.com"}
+	orphan := &graph.Link{URL: "https://orphan.com"}
+	for _, link := range []*graph.Link{src, dst, orphan} {
+		if err := pg.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pg.UpsertEdge(&graph.Edge{Src: src.ID, Dst: dst.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := pg.LookupLinkByURL(dst.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != dst.ID {
+		t.Fatalf("\ngot:%v\nexpect:%v", found.ID, dst.ID)
+	}
+
+	n, err := pg.RemoveOrphanLinks(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", n, 1)
+	}
+	if _, err := pg.LookupLinkByURL(orphan.URL); err != graph.ErrNotFound {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+
+	// removing src cascade to it's edge
+	if err := pg.RemoveLink(src.ID); err != nil {
+		t.Fatal(err)
+	}
+	in, _, err := pg.Degree(dst.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in != 0 {
+		t.Fatalf("\ngot:%v\nexpect:%v", in, 0)
+	}
+	if err := pg.RemoveLink(src.ID); err != graph.ErrNotFound {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+}
+
+func test_update_batch(t *testing.T) {
+	pg.db.ExecContext(context.TODO(), linkTable.Create)
+	pg.db.ExecContext(context.TODO(), edgeTable.Create)
+	defer func() {
+		pg.db.ExecContext(context.TODO(), edgeTable.Drop)
+		pg.db.ExecContext(context.TODO(), linkTable.Drop)
+	}()
+
+	src := &graph.Link{URL: "https://src.com"}
+	old := &graph.Link{URL: "https://old.com"}
+	for _, link := range []*graph.Link{src, old} {
+		if err := pg.UpsertLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pg.UpsertEdge(&graph.Edge{Src: src.ID, Dst: old.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the edge not in the batch is removed, the new one kept
+	crawled := &graph.Link{URL: src.URL, RetrievedAt: time.Now()}
+	dst := &graph.Link{URL: "https://dst.com"}
+	err := pg.UpdateBatchContext(context.TODO(), &graph.Batch{
+		Links:        []*graph.Link{crawled, dst},
+		Edges:        []*graph.BatchEdge{{Src: crawled, Dst: dst}},
+		StaleSources: []*graph.Link{crawled},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crawled.ID != src.ID {
+		t.Fatalf("\ngot:%v\nexpect:%v", crawled.ID, src.ID)
+	}
+	_, out, err := pg.Degree(src.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", out, 1)
+	}
+
+	// nothing is written when one of the writes fail
+	failed := &graph.Link{URL: "https://failed.com"}
+	err = pg.UpdateBatchContext(context.TODO(), &graph.Batch{
+		Links: []*graph.Link{failed},
+		Edges: []*graph.BatchEdge{{Src: failed, Dst: &graph.Link{ID: uuid.New()}}},
+	})
+	if err != graph.ErrUnknownEdgeLinks {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrUnknownEdgeLinks)
+	}
+	if _, err := pg.LookupLinkByURL(failed.URL); err != graph.ErrNotFound {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+}
+
+func test_link_metadata(t *testing.T) {
+	pg.db.ExecContext(context.TODO(), linkTable.Create)
+	pg.db.ExecContext(context.TODO(), edgeTable.Create)
+	defer func() {
+		pg.db.ExecContext(context.TODO(), edgeTable.Drop)
+		pg.db.ExecContext(context.TODO(), linkTable.Drop)
+	}()
+
+	// discovered by crawl, then submitted by user and fetched
+	link := &graph.Link{URL: "https://www.example.com/page", Depth: 2, Source: graph.SourceCrawl}
+	if err := pg.UpsertLink(link); err != nil {
+		t.Fatal(err)
+	}
+	if link.Host != "www.example.com" {
+		t.Fatalf("\ngot:%v\nexpect:%v", link.Host, "www.example.com")
+	}
+	update := &graph.Link{URL: link.URL, Depth: 0, Source: graph.SourceUser}
+	fetched := &graph.Link{URL: link.URL, Depth: 1, StatusCode: 200, ContentType: "text/html", RetrievedAt: time.Now()}
+	if err := pg.UpsertLinks([]*graph.Link{update, fetched}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := pg.LookupLink(link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Depth != 0 || stored.Source != graph.SourceCrawl || stored.StatusCode != 200 || stored.ContentType != "text/html" {
+		t.Fatalf("\ngot:%+v", stored)
+	}
+
+	it, err := pg.Links(uuid.Nil, uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff"), graph.LinkFilter{Host: "www.example.com", StatusCode: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for it.Next() {
+		it.Link()
+		count++
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+	it.Close()
+	if count != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 1)
+	}
+}
+
+func test_host(t *testing.T) {
+	pg.db.ExecContext(context.TODO(), linkTable.Create)
+	pg.db.ExecContext(context.TODO(), edgeTable.Create)
+	execMigration(t, "0005_hosts.up.sql")
+	execMigration(t, "0007_host_counters.up.sql")
+	execMigration(t, "0010_host_counts_refresh.up.sql")
+	defer func() {
+		pg.db.ExecContext(context.TODO(), `DROP TABLE IF EXISTS hosts`)
+		pg.db.ExecContext(context.TODO(), edgeTable.Drop)
+		pg.db.ExecContext(context.TODO(), linkTable.Drop)
+	}()
+
+	page := &graph.Link{URL: "https://a.com/page", RetrievedAt: time.Now()}
+	other := &graph.Link{URL: "https://a.com/other"}
+	external := &graph.Link{URL: "https://b.com"}
+	if err := pg.UpsertLinks([]*graph.Link{page, other, external}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.UpsertEdges([]*graph.Edge{{Src: page.ID, Dst: other.ID}, {Src: page.ID, Dst: external.ID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := pg.RecordFetches([]*graph.HostFetch{
+		{Host: "a.com", StatusCode: 200, ResponseTime: 100 * time.Millisecond, FetchedAt: time.Now()},
+		{Host: "a.com", StatusCode: 500, ResponseTime: 300 * time.Millisecond, FetchedAt: time.Now(), RobotsStatus: graph.RobotsFound},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.refreshHostCounts(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	host, err := pg.LookupHost("a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.KnownPages != 2 || host.CrawledPages != 1 || host.OutEdges != 1 || host.Fetches != 2 || host.FetchErrors != 1 || host.RobotsStatus != graph.RobotsFound {
+		t.Fatalf("\ngot:%+v", host)
+	}
+
+	host, err = pg.LookupHost("b.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.KnownPages != 1 || host.InEdges != 1 || host.Fetches != 0 {
+		t.Fatalf("\ngot:%+v", host)
+	}
+
+	if _, err := pg.LookupHost("c.com"); err != graph.ErrNotFound {
+		t.Fatalf("\ngot:%v\nexpect:%v", err, graph.ErrNotFound)
+	}
+
+	hosts, err := pg.Hosts(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 1 || hosts[0].Name != "a.com" {
+		t.Fatalf("\ngot:%+v", hosts)
+	}
+
+	// the fetch without robots status keep the recorded one
+	if err := pg.RecordFetches([]*graph.HostFetch{{Host: "a.com", StatusCode: 200, FetchedAt: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the counters follow the removal after refresh, the edges of removed link included
+	if err := pg.RemoveStaleEdges(page.ID, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.UpsertEdge(&graph.Edge{Src: external.ID, Dst: page.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.RemoveLink(page.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := pg.refreshHostCounts(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	host, err = pg.LookupHost("a.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.KnownPages != 1 || host.CrawledPages != 0 || host.InEdges != 0 || host.OutEdges != 0 || host.RobotsStatus != graph.RobotsFound {
+		t.Fatalf("\ngot:%+v", host)
+	}
+	host, err = pg.LookupHost("b.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.InEdges != 0 || host.OutEdges != 0 {
+		t.Fatalf("\ngot:%+v", host)
+	}
+}
+
 func test_upsert_link(t *testing.T) {
 	pg.db.ExecContext(context.TODO(), linkTable.Create)
 	pg.db.ExecContext(context.TODO(), edgeTable.Create)
@@ -424,7 +789,7 @@ func test_Link_iterator_Timefilter(t *testing.T) {
 
 func partitionLinkIter(pg graph.Graph, t *testing.T, partition, numPartition int, accessBefore time.Time) (graph.LinkIterator, error) {
 	from, to := partitionRange(t, partition, numPartition)
-	return pg.Links(from, to, accessBefore)
+	return pg.Links(from, to, graph.LinkFilter{RetrievedBefore: accessBefore})
 }
 
 func partitionRange(t *testing.T, partition, numPartition int) (from, to uuid.UUID) {
@@ -10,16 +10,21 @@ import (
 )
 
 const lookupLinkQuery = `
-	SELECT id, url, retrieved_at
+	SELECT ` + linkColumns + `
 	FROM links
 	WHERE id = $1
 `
 
 // LookupLink implements graph.Graph.
 func (p *postgre) LookupLink(id uuid.UUID) (*graph.Link, error) {
+	return p.LookupLinkContext(context.Background(), id)
+}
+
+// LookupLinkContext implements graph.ContextGraph.
+func (p *postgre) LookupLinkContext(ctx context.Context, id uuid.UUID) (*graph.Link, error) {
 	var link graph.Link
 
-	err := p.db.QueryRowxContext(context.TODO(), lookupLinkQuery, id).Scan(&link.ID, &link.URL, &link.RetrievedAt)
+	err := scanLink(p.db.QueryRowxContext(ctx, lookupLinkQuery, id), &link)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, graph.ErrNotFound
@@ -29,3 +34,29 @@ func (p *postgre) LookupLink(id uuid.UUID) (*graph.Link, error) {
 
 	return &link, nil
 }
+
+const lookupLinkByURLQuery = `
+	SELECT ` + linkColumns + `
+	FROM links
+	WHERE url = $1
+`
+
+// LookupLinkByURL implements graph.Graph.
+func (p *postgre) LookupLinkByURL(url string) (*graph.Link, error) {
+	return p.LookupLinkByURLContext(context.Background(), url)
+}
+
+// LookupLinkByURLContext implements graph.ContextGraph.
+func (p *postgre) LookupLinkByURLContext(ctx context.Context, url string) (*graph.Link, error) {
+	var link graph.Link
+
+	err := scanLink(p.db.QueryRowxContext(ctx, lookupLinkByURLQuery, url), &link)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, graph.ErrNotFound
+		}
+		return nil, fmt.Errorf("lookup link by url: %v", err)
+	}
+
+	return &link, nil
+}
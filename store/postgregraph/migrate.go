@@ -0,0 +1,13 @@
+package postgregraph
+
+import (
+	"embed"
+
+	"github.com/odit-bit/invoker/internal/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations is the versioned schema of the graph store
+var Migrations = migrate.Source{Name: "graph", FS: migrationFiles, Dir: "migrations"}
@@ -0,0 +1,14 @@
+-- IF NOT EXISTS so database created before versioned migrations is adopted as it is
+CREATE TABLE IF NOT EXISTS links(
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	url text UNIQUE,
+	retrieved_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS edges(
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	src UUID NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+	dst UUID NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+	update_at TIMESTAMP,
+	CONSTRAINT edge_links UNIQUE(src,dst)
+);
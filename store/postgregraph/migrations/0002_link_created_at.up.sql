@@ -0,0 +1,2 @@
+-- existing rows take the migration time as their creation time
+ALTER TABLE links ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc');
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS links_host_idx;
+
+ALTER TABLE links
+	DROP COLUMN IF EXISTS host,
+	DROP COLUMN IF EXISTS depth,
+	DROP COLUMN IF EXISTS status_code,
+	DROP COLUMN IF EXISTS content_type,
+	DROP COLUMN IF EXISTS content_hash,
+	DROP COLUMN IF EXISTS source,
+	DROP COLUMN IF EXISTS next_crawl_at;
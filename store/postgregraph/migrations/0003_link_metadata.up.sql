@@ -0,0 +1,15 @@
+-- existing rows take the default until the link upserted again
+ALTER TABLE links
+	ADD COLUMN IF NOT EXISTS host text NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS depth integer NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS status_code integer NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS content_type text NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS content_hash text NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS source text NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS next_crawl_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00';
+
+-- fill host of rows inserted before the column exist
+UPDATE links SET host = substring(url from '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]+)')
+WHERE host = '' AND url ~ '^[a-zA-Z][a-zA-Z0-9+.-]*://';
+
+CREATE INDEX IF NOT EXISTS links_host_idx ON links(host);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS edges_dst_idx;
@@ -0,0 +1,3 @@
+-- src already indexed by edge_links constraint,
+-- dst need it's own index for backlink queries
+CREATE INDEX IF NOT EXISTS edges_dst_idx ON edges(dst);
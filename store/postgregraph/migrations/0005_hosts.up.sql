@@ -0,0 +1,10 @@
+-- only the incremental statistic stored,
+-- links count and inter-host edges are derived from links and edges table.
+CREATE TABLE IF NOT EXISTS hosts(
+	name text PRIMARY KEY,
+	fetches bigint NOT NULL DEFAULT 0,
+	fetch_errors bigint NOT NULL DEFAULT 0,
+	total_response_ms bigint NOT NULL DEFAULT 0,
+	last_crawl_at TIMESTAMP NOT NULL DEFAULT '0001-01-01 00:00:00',
+	robots_status text NOT NULL DEFAULT ''
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS edges_change ON edges;
+DROP TRIGGER IF EXISTS links_change ON links;
+DROP FUNCTION IF EXISTS record_edge_change();
+DROP FUNCTION IF EXISTS record_link_change();
+DROP TABLE IF EXISTS graph_changes;
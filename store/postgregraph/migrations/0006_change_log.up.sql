@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS graph_changes(
+	id BIGSERIAL PRIMARY KEY,
+	kind text NOT NULL,
+	link_id UUID NOT NULL,
+	edge_id UUID,
+	at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc')
+);
+
+-- the changes are recorded by triggers so bulk and cascading writes are included.
+-- the advisory lock is held until commit, so the id of committed changes always increase
+-- and subscriber never skip change committed after the one it has seen.
+CREATE OR REPLACE FUNCTION record_link_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('graph_changes'));
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id) VALUES ('link_created', NEW.id);
+		IF NEW.retrieved_at > '0001-01-01 00:00:00' THEN
+			INSERT INTO graph_changes (kind, link_id) VALUES ('link_crawled', NEW.id);
+		END IF;
+	ELSIF NEW.retrieved_at > COALESCE(OLD.retrieved_at, '0001-01-01 00:00:00') THEN
+		INSERT INTO graph_changes (kind, link_id) VALUES ('link_crawled', NEW.id);
+	END IF;
+	PERFORM pg_notify('graph_changes', '');
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_edge_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('graph_changes'));
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, edge_id) VALUES ('edge_changed', NEW.src, NEW.id);
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, edge_id) VALUES ('edge_changed', OLD.src, OLD.id);
+	END IF;
+	PERFORM pg_notify('graph_changes', '');
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS links_change ON links;
+CREATE TRIGGER links_change AFTER INSERT OR UPDATE OF retrieved_at ON links
+	FOR EACH ROW EXECUTE FUNCTION record_link_change();
+
+DROP TRIGGER IF EXISTS edges_change ON edges;
+CREATE TRIGGER edges_change AFTER INSERT OR DELETE ON edges
+	FOR EACH ROW EXECUTE FUNCTION record_edge_change();
@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS edges_delete_count ON edges;
+DROP TRIGGER IF EXISTS edges_insert_count ON edges;
+DROP TRIGGER IF EXISTS links_remove_edges ON links;
+DROP TRIGGER IF EXISTS links_delete_count ON links;
+DROP TRIGGER IF EXISTS links_update_count ON links;
+DROP TRIGGER IF EXISTS links_insert_count ON links;
+DROP FUNCTION IF EXISTS remove_link_edges();
+DROP FUNCTION IF EXISTS count_host_edges();
+DROP FUNCTION IF EXISTS count_host_links();
+
+ALTER TABLE hosts
+	DROP COLUMN IF EXISTS in_edges,
+	DROP COLUMN IF EXISTS out_edges,
+	DROP COLUMN IF EXISTS crawled_pages,
+	DROP COLUMN IF EXISTS known_pages;
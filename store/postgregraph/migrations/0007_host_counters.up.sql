@@ -0,0 +1,137 @@
+-- the links count and inter-host edges are kept with the fetch statistic,
+-- triggers update them so every write path and cascading delete is counted.
+ALTER TABLE hosts
+	ADD COLUMN IF NOT EXISTS known_pages bigint NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS crawled_pages bigint NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS out_edges bigint NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS in_edges bigint NOT NULL DEFAULT 0;
+
+-- count the rows written before the triggers exist
+INSERT INTO hosts (name, known_pages, crawled_pages)
+SELECT host, count(*), count(*) FILTER (WHERE retrieved_at > '0001-01-01 00:00:00')
+FROM links WHERE host <> '' GROUP BY host
+ON CONFLICT (name) DO UPDATE SET
+	known_pages = EXCLUDED.known_pages,
+	crawled_pages = EXCLUDED.crawled_pages;
+
+INSERT INTO hosts (name, out_edges, in_edges)
+SELECT host, sum(out_edges), sum(in_edges) FROM (
+	SELECT s.host, 1 AS out_edges, 0 AS in_edges FROM edges e
+		JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+		WHERE s.host <> d.host AND s.host <> ''
+	UNION ALL
+	SELECT d.host, 0, 1 FROM edges e
+		JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+		WHERE s.host <> d.host AND d.host <> ''
+) c GROUP BY host
+ON CONFLICT (name) DO UPDATE SET
+	out_edges = EXCLUDED.out_edges,
+	in_edges = EXCLUDED.in_edges;
+
+-- the triggers run once per statement with the changed rows, removed rows are added negative.
+-- host rows are upserted in name order so concurrent statements not deadlock.
+CREATE OR REPLACE FUNCTION count_host_links() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO hosts (name, known_pages, crawled_pages)
+		SELECT host, count(*), count(*) FILTER (WHERE retrieved_at > '0001-01-01 00:00:00')
+		FROM new_links WHERE host <> '' GROUP BY host ORDER BY host
+		ON CONFLICT (name) DO UPDATE SET
+			known_pages = hosts.known_pages + EXCLUDED.known_pages,
+			crawled_pages = hosts.crawled_pages + EXCLUDED.crawled_pages;
+	ELSIF TG_OP = 'UPDATE' THEN
+		INSERT INTO hosts (name, known_pages, crawled_pages)
+		SELECT host, sum(known), sum(crawled) FROM (
+			SELECT host, 1 AS known, (retrieved_at > '0001-01-01 00:00:00')::int AS crawled FROM new_links
+			UNION ALL
+			SELECT host, -1, -(retrieved_at > '0001-01-01 00:00:00')::int FROM old_links
+		) c WHERE host <> '' GROUP BY host
+		HAVING sum(known) <> 0 OR sum(crawled) <> 0
+		ORDER BY host
+		ON CONFLICT (name) DO UPDATE SET
+			known_pages = hosts.known_pages + EXCLUDED.known_pages,
+			crawled_pages = hosts.crawled_pages + EXCLUDED.crawled_pages;
+	ELSE
+		INSERT INTO hosts (name, known_pages, crawled_pages)
+		SELECT host, -count(*), -count(*) FILTER (WHERE retrieved_at > '0001-01-01 00:00:00')
+		FROM old_links WHERE host <> '' GROUP BY host ORDER BY host
+		ON CONFLICT (name) DO UPDATE SET
+			known_pages = hosts.known_pages + EXCLUDED.known_pages,
+			crawled_pages = hosts.crawled_pages + EXCLUDED.crawled_pages;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION count_host_edges() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO hosts (name, out_edges, in_edges)
+		SELECT host, sum(out_edges), sum(in_edges) FROM (
+			SELECT s.host, 1 AS out_edges, 0 AS in_edges FROM new_edges e
+				JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+				WHERE s.host <> d.host AND s.host <> ''
+			UNION ALL
+			SELECT d.host, 0, 1 FROM new_edges e
+				JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+				WHERE s.host <> d.host AND d.host <> ''
+		) c GROUP BY host ORDER BY host
+		ON CONFLICT (name) DO UPDATE SET
+			out_edges = hosts.out_edges + EXCLUDED.out_edges,
+			in_edges = hosts.in_edges + EXCLUDED.in_edges;
+	ELSE
+		INSERT INTO hosts (name, out_edges, in_edges)
+		SELECT host, -sum(out_edges), -sum(in_edges) FROM (
+			SELECT s.host, 1 AS out_edges, 0 AS in_edges FROM old_edges e
+				JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+				WHERE s.host <> d.host AND s.host <> ''
+			UNION ALL
+			SELECT d.host, 0, 1 FROM old_edges e
+				JOIN links s ON s.id = e.src JOIN links d ON d.id = e.dst
+				WHERE s.host <> d.host AND d.host <> ''
+		) c GROUP BY host ORDER BY host
+		ON CONFLICT (name) DO UPDATE SET
+			out_edges = hosts.out_edges + EXCLUDED.out_edges,
+			in_edges = hosts.in_edges + EXCLUDED.in_edges;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- the cascading delete run after the link is gone and its host cannot be joined,
+-- so the edges of the link are removed before it.
+CREATE OR REPLACE FUNCTION remove_link_edges() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM edges WHERE src = OLD.id OR dst = OLD.id;
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS links_insert_count ON links;
+CREATE TRIGGER links_insert_count AFTER INSERT ON links
+	REFERENCING NEW TABLE AS new_links
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_links();
+
+DROP TRIGGER IF EXISTS links_update_count ON links;
+CREATE TRIGGER links_update_count AFTER UPDATE ON links
+	REFERENCING OLD TABLE AS old_links NEW TABLE AS new_links
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_links();
+
+DROP TRIGGER IF EXISTS links_delete_count ON links;
+CREATE TRIGGER links_delete_count AFTER DELETE ON links
+	REFERENCING OLD TABLE AS old_links
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_links();
+
+DROP TRIGGER IF EXISTS links_remove_edges ON links;
+CREATE TRIGGER links_remove_edges BEFORE DELETE ON links
+	FOR EACH ROW EXECUTE FUNCTION remove_link_edges();
+
+DROP TRIGGER IF EXISTS edges_insert_count ON edges;
+CREATE TRIGGER edges_insert_count AFTER INSERT ON edges
+	REFERENCING NEW TABLE AS new_edges
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_edges();
+
+DROP TRIGGER IF EXISTS edges_delete_count ON edges;
+CREATE TRIGGER edges_delete_count AFTER DELETE ON edges
+	REFERENCING OLD TABLE AS old_edges
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_edges();
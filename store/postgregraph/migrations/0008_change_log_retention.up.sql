@@ -0,0 +1,64 @@
+-- the changes are recorded by deferred triggers that run when the transaction commit,
+-- so the advisory lock that keep the id in commit order is only held during commit
+-- and concurrent writers are not serialized while they are still running.
+CREATE OR REPLACE FUNCTION record_link_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('graph_changes'));
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, at)
+		VALUES ('link_created', NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+		IF NEW.retrieved_at > '0001-01-01 00:00:00' THEN
+			INSERT INTO graph_changes (kind, link_id, at)
+			VALUES ('link_crawled', NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+		END IF;
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, at)
+		VALUES ('link_crawled', NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+	END IF;
+	PERFORM pg_notify('graph_changes', '');
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_edge_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('graph_changes'));
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, edge_id, at)
+		VALUES ('edge_changed', NEW.src, NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, edge_id, at)
+		VALUES ('edge_changed', OLD.src, OLD.id, clock_timestamp() AT TIME ZONE 'utc');
+	END IF;
+	PERFORM pg_notify('graph_changes', '');
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS links_change ON links;
+CREATE CONSTRAINT TRIGGER links_change AFTER INSERT ON links
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION record_link_change();
+
+-- update that does not move retrieved_at forward is filtered before it is queued
+DROP TRIGGER IF EXISTS links_crawl_change ON links;
+CREATE CONSTRAINT TRIGGER links_crawl_change AFTER UPDATE OF retrieved_at ON links
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW
+	WHEN (NEW.retrieved_at > COALESCE(OLD.retrieved_at, '0001-01-01 00:00:00'))
+	EXECUTE FUNCTION record_link_change();
+
+DROP TRIGGER IF EXISTS edges_change ON edges;
+CREATE CONSTRAINT TRIGGER edges_change AFTER INSERT OR DELETE ON edges
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION record_edge_change();
+
+-- retention, changes older than the retention are pruned by the garbage collector.
+-- the cursor of the last pruned change is kept, subscriber behind it must resync.
+CREATE TABLE IF NOT EXISTS graph_changes_pruned(
+	id boolean PRIMARY KEY DEFAULT true CHECK (id),
+	cursor bigint NOT NULL
+);
+INSERT INTO graph_changes_pruned (cursor) VALUES (0) ON CONFLICT DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS graph_changes_at_idx ON graph_changes (at);
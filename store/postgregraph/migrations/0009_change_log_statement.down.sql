@@ -0,0 +1,63 @@
+DROP TRIGGER IF EXISTS edges_delete_change ON edges;
+DROP TRIGGER IF EXISTS edges_insert_change ON edges;
+DROP TRIGGER IF EXISTS links_update_change ON links;
+DROP TRIGGER IF EXISTS links_insert_change ON links;
+DROP FUNCTION IF EXISTS record_edge_changes();
+DROP FUNCTION IF EXISTS record_link_changes();
+
+-- the id become the cursor again, subscriber must resync from the last change.
+-- the pending changes are dropped
+DELETE FROM graph_changes WHERE pos IS NULL;
+DROP INDEX IF EXISTS graph_changes_pending_idx;
+DROP SEQUENCE IF EXISTS graph_changes_pos_seq;
+ALTER TABLE graph_changes DROP COLUMN IF EXISTS xid;
+ALTER TABLE graph_changes DROP COLUMN IF EXISTS pos;
+
+-- the deferred row triggers of 0008
+CREATE OR REPLACE FUNCTION record_link_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('graph_changes'));
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, at)
+		VALUES ('link_created', NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+		IF NEW.retrieved_at > '0001-01-01 00:00:00' THEN
+			INSERT INTO graph_changes (kind, link_id, at)
+			VALUES ('link_crawled', NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+		END IF;
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, at)
+		VALUES ('link_crawled', NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+	END IF;
+	PERFORM pg_notify('graph_changes', '');
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_edge_change() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_advisory_xact_lock(hashtext('graph_changes'));
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, edge_id, at)
+		VALUES ('edge_changed', NEW.src, NEW.id, clock_timestamp() AT TIME ZONE 'utc');
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, edge_id, at)
+		VALUES ('edge_changed', OLD.src, OLD.id, clock_timestamp() AT TIME ZONE 'utc');
+	END IF;
+	PERFORM pg_notify('graph_changes', '');
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER links_change AFTER INSERT ON links
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION record_link_change();
+
+CREATE CONSTRAINT TRIGGER links_crawl_change AFTER UPDATE OF retrieved_at ON links
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW
+	WHEN (NEW.retrieved_at > COALESCE(OLD.retrieved_at, '0001-01-01 00:00:00'))
+	EXECUTE FUNCTION record_link_change();
+
+CREATE CONSTRAINT TRIGGER edges_change AFTER INSERT OR DELETE ON edges
+	DEFERRABLE INITIALLY DEFERRED
+	FOR EACH ROW EXECUTE FUNCTION record_edge_change();
@@ -0,0 +1,89 @@
+-- the changes are recorded once per statement from the transition tables, without lock.
+-- the id follow the insert order, not the commit order, so the cursor is the separate
+-- position assigned by the reader (see postgregraph/change.go) to the changes
+-- whose transaction is older than every running transaction, in transaction order.
+DROP TRIGGER IF EXISTS edges_change ON edges;
+DROP TRIGGER IF EXISTS links_crawl_change ON links;
+DROP TRIGGER IF EXISTS links_change ON links;
+DROP FUNCTION IF EXISTS record_edge_change();
+DROP FUNCTION IF EXISTS record_link_change();
+
+ALTER TABLE graph_changes
+	ADD COLUMN IF NOT EXISTS xid xid8 DEFAULT pg_current_xact_id(),
+	ADD COLUMN IF NOT EXISTS pos bigint UNIQUE;
+
+-- the changes recorded before keep their id as position
+UPDATE graph_changes SET pos = id WHERE pos IS NULL;
+
+CREATE SEQUENCE IF NOT EXISTS graph_changes_pos_seq;
+SELECT setval('graph_changes_pos_seq', GREATEST(last, 1), last > 0)
+FROM (SELECT GREATEST(
+	(SELECT COALESCE(MAX(pos), 0) FROM graph_changes),
+	(SELECT cursor FROM graph_changes_pruned)
+) AS last) s;
+
+CREATE INDEX IF NOT EXISTS graph_changes_pending_idx ON graph_changes (xid, id) WHERE pos IS NULL;
+
+CREATE OR REPLACE FUNCTION record_link_changes() RETURNS trigger AS $$
+DECLARE
+	recorded bigint;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, at)
+		SELECT c.kind, l.id, clock_timestamp() AT TIME ZONE 'utc'
+		FROM new_links l
+		CROSS JOIN LATERAL (VALUES ('link_created', true), ('link_crawled', l.retrieved_at > '0001-01-01 00:00:00')) AS c(kind, ok)
+		WHERE c.ok;
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, at)
+		SELECT 'link_crawled', n.id, clock_timestamp() AT TIME ZONE 'utc'
+		FROM new_links n JOIN old_links o ON o.id = n.id
+		WHERE n.retrieved_at > COALESCE(o.retrieved_at, '0001-01-01 00:00:00');
+	END IF;
+
+	GET DIAGNOSTICS recorded = ROW_COUNT;
+	IF recorded > 0 THEN
+		PERFORM pg_notify('graph_changes', '');
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_edge_changes() RETURNS trigger AS $$
+DECLARE
+	recorded bigint;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO graph_changes (kind, link_id, edge_id, at)
+		SELECT 'edge_changed', src, id, clock_timestamp() AT TIME ZONE 'utc' FROM new_edges;
+	ELSE
+		INSERT INTO graph_changes (kind, link_id, edge_id, at)
+		SELECT 'edge_changed', src, id, clock_timestamp() AT TIME ZONE 'utc' FROM old_edges;
+	END IF;
+
+	GET DIAGNOSTICS recorded = ROW_COUNT;
+	IF recorded > 0 THEN
+		PERFORM pg_notify('graph_changes', '');
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- transition table cannot be used with column list, every update is compared
+DROP TRIGGER IF EXISTS links_insert_change ON links;
+CREATE TRIGGER links_insert_change AFTER INSERT ON links
+	REFERENCING NEW TABLE AS new_links
+	FOR EACH STATEMENT EXECUTE FUNCTION record_link_changes();
+DROP TRIGGER IF EXISTS links_update_change ON links;
+CREATE TRIGGER links_update_change AFTER UPDATE ON links
+	REFERENCING OLD TABLE AS old_links NEW TABLE AS new_links
+	FOR EACH STATEMENT EXECUTE FUNCTION record_link_changes();
+
+DROP TRIGGER IF EXISTS edges_insert_change ON edges;
+CREATE TRIGGER edges_insert_change AFTER INSERT ON edges
+	REFERENCING NEW TABLE AS new_edges
+	FOR EACH STATEMENT EXECUTE FUNCTION record_edge_changes();
+DROP TRIGGER IF EXISTS edges_delete_change ON edges;
+CREATE TRIGGER edges_delete_change AFTER DELETE ON edges
+	REFERENCING OLD TABLE AS old_edges
+	FOR EACH STATEMENT EXECUTE FUNCTION record_edge_changes();
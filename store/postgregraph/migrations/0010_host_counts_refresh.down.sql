@@ -0,0 +1,23 @@
+-- the counters keep the count of the last refresh, the triggers add to them from here
+CREATE TRIGGER links_insert_count AFTER INSERT ON links
+	REFERENCING NEW TABLE AS new_links
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_links();
+
+CREATE TRIGGER links_update_count AFTER UPDATE ON links
+	REFERENCING OLD TABLE AS old_links NEW TABLE AS new_links
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_links();
+
+CREATE TRIGGER links_delete_count AFTER DELETE ON links
+	REFERENCING OLD TABLE AS old_links
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_links();
+
+CREATE TRIGGER links_remove_edges BEFORE DELETE ON links
+	FOR EACH ROW EXECUTE FUNCTION remove_link_edges();
+
+CREATE TRIGGER edges_insert_count AFTER INSERT ON edges
+	REFERENCING NEW TABLE AS new_edges
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_edges();
+
+CREATE TRIGGER edges_delete_count AFTER DELETE ON edges
+	REFERENCING OLD TABLE AS old_edges
+	FOR EACH STATEMENT EXECUTE FUNCTION count_host_edges();
@@ -0,0 +1,15 @@
+-- the robots status is recorded by the crawler,
+-- the column was dropped by the earlier version of 0007.
+ALTER TABLE hosts ADD COLUMN IF NOT EXISTS robots_status text NOT NULL DEFAULT '';
+
+-- the links count and inter-host edges are recounted periodically by HostCountService,
+-- the statement triggers upsert the row of every touched host and serialize the writers of popular host.
+-- the functions are kept for the down migration.
+DROP TRIGGER IF EXISTS edges_delete_count ON edges;
+DROP TRIGGER IF EXISTS edges_insert_count ON edges;
+DROP TRIGGER IF EXISTS links_delete_count ON links;
+DROP TRIGGER IF EXISTS links_update_count ON links;
+DROP TRIGGER IF EXISTS links_insert_count ON links;
+
+-- the edges are removed by ON DELETE CASCADE again
+DROP TRIGGER IF EXISTS links_remove_edges ON links;
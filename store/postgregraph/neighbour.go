@@ -0,0 +1,72 @@
+package postgregraph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
+)
+
+const inEdgesQuery = `
+	SELECT id, src, dst, update_at
+	FROM edges
+	WHERE dst = $1
+`
+
+// InEdges implements graph.Graph.
+func (p *postgre) InEdges(dst uuid.UUID) (graph.EdgeIterator, error) {
+	return p.InEdgesContext(context.Background(), dst)
+}
+
+// InEdgesContext implements graph.ContextGraph.
+func (p *postgre) InEdgesContext(ctx context.Context, dst uuid.UUID) (graph.EdgeIterator, error) {
+	rows, err := p.db.QueryxContext(ctx, inEdgesQuery, dst)
+	if err != nil {
+		return nil, fmt.Errorf("in edges: %v", err)
+	}
+
+	return &iterator{rows: rows}, nil
+}
+
+const outEdgesQuery = `
+	SELECT id, src, dst, update_at
+	FROM edges
+	WHERE src = $1
+`
+
+// OutEdges implements graph.Graph.
+func (p *postgre) OutEdges(src uuid.UUID) (graph.EdgeIterator, error) {
+	return p.OutEdgesContext(context.Background(), src)
+}
+
+// OutEdgesContext implements graph.ContextGraph.
+func (p *postgre) OutEdgesContext(ctx context.Context, src uuid.UUID) (graph.EdgeIterator, error) {
+	rows, err := p.db.QueryxContext(ctx, outEdgesQuery, src)
+	if err != nil {
+		return nil, fmt.Errorf("out edges: %v", err)
+	}
+
+	return &iterator{rows: rows}, nil
+}
+
+const degreeQuery = `
+	SELECT
+		(SELECT COUNT(*) FROM edges WHERE dst = $1),
+		(SELECT COUNT(*) FROM edges WHERE src = $1)
+`
+
+// Degree implements graph.Graph.
+func (p *postgre) Degree(id uuid.UUID) (int, int, error) {
+	return p.DegreeContext(context.Background(), id)
+}
+
+// DegreeContext implements graph.ContextGraph.
+func (p *postgre) DegreeContext(ctx context.Context, id uuid.UUID) (int, int, error) {
+	var in, out int
+	err := p.db.QueryRowxContext(ctx, degreeQuery, id).Scan(&in, &out)
+	if err != nil {
+		return 0, 0, fmt.Errorf("degree: %v", err)
+	}
+	return in, out, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/linkgraph/graph"
 )
 
 //================
@@ -17,10 +18,66 @@ const edgeRemoveStaleQuery = `
 
 // RemoveStaleEdges implements graph.Graph.
 func (p *postgre) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error {
-	_, err := p.db.ExecContext(context.TODO(), edgeRemoveStaleQuery, fromID, updatedBefore.UTC())
+	return p.RemoveStaleEdgesContext(context.Background(), fromID, updatedBefore)
+}
+
+// RemoveStaleEdgesContext implements graph.ContextGraph.
+func (p *postgre) RemoveStaleEdgesContext(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error {
+	_, err := p.db.ExecContext(ctx, edgeRemoveStaleQuery, fromID, updatedBefore.UTC())
 	if err != nil {
 		return fmt.Errorf("remove stale edge: %v", err)
 	}
 
 	return nil
 }
+
+// edges referencing the link are removed by ON DELETE CASCADE
+const linkRemoveQuery = `
+	DELETE FROM links
+	WHERE id=$1
+`
+
+// RemoveLink implements graph.Graph.
+func (p *postgre) RemoveLink(id uuid.UUID) error {
+	return p.RemoveLinkContext(context.Background(), id)
+}
+
+// RemoveLinkContext implements graph.ContextGraph.
+func (p *postgre) RemoveLinkContext(ctx context.Context, id uuid.UUID) error {
+	res, err := p.db.ExecContext(ctx, linkRemoveQuery, id)
+	if err != nil {
+		return fmt.Errorf("remove link: %v", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return graph.ErrNotFound
+	}
+
+	return nil
+}
+
+// never retrieved link is stored with zero timestamp
+const linkRemoveOrphanQuery = `
+	DELETE FROM links l
+	WHERE (l.retrieved_at IS NULL OR l.retrieved_at <= $1)
+		AND l.created_at < $2
+		AND NOT EXISTS (SELECT 1 FROM edges e WHERE e.dst = l.id)
+`
+
+// RemoveOrphanLinks implements graph.Graph.
+func (p *postgre) RemoveOrphanLinks(createdBefore time.Time) (int, error) {
+	return p.RemoveOrphanLinksContext(context.Background(), createdBefore)
+}
+
+// RemoveOrphanLinksContext implements graph.ContextGraph.
+func (p *postgre) RemoveOrphanLinksContext(ctx context.Context, createdBefore time.Time) (int, error) {
+	res, err := p.db.ExecContext(ctx, linkRemoveOrphanQuery, time.Time{}, createdBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("remove orphan links: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("remove orphan links: %v", err)
+	}
+
+	return int(n), nil
+}
@@ -4,32 +4,109 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/odit-bit/invoker/linkgraph/graph"
 )
 
-const linkUpsertQuery = `
-	INSERT INTO links (url, retrieved_at) 
-	VALUES ($1, $2)
-	ON CONFLICT (url) DO UPDATE SET retrieved_at=GREATEST(links.retrieved_at, $2)
-	RETURNING id,retrieved_at
+// every column of link, in the order scanned by scanLink
+const linkColumns = `id, url, retrieved_at, host, depth, status_code, content_type, content_hash, source, created_at, next_crawl_at`
+
+// same rule as graph.MergeLink
+const linkUpsertConflict = `
+	ON CONFLICT (url) DO UPDATE SET
+		retrieved_at=GREATEST(links.retrieved_at, EXCLUDED.retrieved_at),
+		host=COALESCE(NULLIF(EXCLUDED.host, ''), links.host),
+		depth=LEAST(links.depth, EXCLUDED.depth),
+		status_code=COALESCE(NULLIF(EXCLUDED.status_code, 0), links.status_code),
+		content_type=COALESCE(NULLIF(EXCLUDED.content_type, ''), links.content_type),
+		content_hash=COALESCE(NULLIF(EXCLUDED.content_hash, ''), links.content_hash),
+		source=COALESCE(NULLIF(links.source, ''), EXCLUDED.source),
+		next_crawl_at=CASE
+			WHEN EXCLUDED.next_crawl_at > '0001-01-01 00:00:00' THEN EXCLUDED.next_crawl_at
+			ELSE links.next_crawl_at
+		END
 `
 
+// the id of new link is kept if it is given and not taken by other link
+const linkUpsertQuery = `
+	INSERT INTO links (id, url, retrieved_at, host, depth, status_code, content_type, content_hash, source, next_crawl_at)
+	VALUES (
+		CASE
+			WHEN $10::uuid IS NULL OR EXISTS (SELECT 1 FROM links WHERE id = $10::uuid) THEN gen_random_uuid()
+			ELSE $10::uuid
+		END,
+		$1, $2, $3, $4, $5, $6, $7, $8, $9
+	)
+` + linkUpsertConflict + `
+	RETURNING ` + linkColumns
+
 // UpsertLink implements graph.Graph.
 // TODO: make fix time standar so no need to call UTC() every time
 func (p *postgre) UpsertLink(link *graph.Link) error {
+	return p.UpsertLinkContext(context.Background(), link)
+}
+
+// UpsertLinkContext implements graph.ContextGraph.
+func (p *postgre) UpsertLinkContext(ctx context.Context, link *graph.Link) error {
 	link.RetrievedAt = link.RetrievedAt.UTC()
-	err := p.db.QueryRowxContext(context.TODO(), linkUpsertQuery, link.URL, link.RetrievedAt).Scan(
-		&link.ID,
-		&link.RetrievedAt,
+	link.NextCrawlAt = link.NextCrawlAt.UTC()
+	if link.Host == "" {
+		link.Host = graph.HostOf(link.URL)
+	}
+
+	row := p.db.QueryRowxContext(ctx, linkUpsertQuery,
+		link.URL,
+		link.RetrievedAt,
+		link.Host,
+		link.Depth,
+		link.StatusCode,
+		link.ContentType,
+		link.ContentHash,
+		string(link.Source),
+		link.NextCrawlAt,
+		nullableID(link.ID),
 	)
-	if err != nil {
+	if err := scanLink(row, link); err != nil {
 		return fmt.Errorf("upsert link: %v ", err)
 	}
 
 	return nil
 }
 
+// uuid.Nil is stored as NULL
+func nullableID(id uuid.UUID) interface{} {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
+
+// implemented by sqlx.Row and sqlx.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scan the row selected with linkColumns into link
+func scanLink(row scanner, link *graph.Link) error {
+	var source string
+	err := row.Scan(
+		&link.ID,
+		&link.URL,
+		&link.RetrievedAt,
+		&link.Host,
+		&link.Depth,
+		&link.StatusCode,
+		&link.ContentType,
+		&link.ContentHash,
+		&source,
+		&link.FirstSeenAt,
+		&link.NextCrawlAt,
+	)
+	link.Source = graph.Source(source)
+	return err
+}
+
 const edgeUpsertQuery = `
 	INSERT INTO edges (src, dst, update_at) 
 	VALUES ($1, $2, NOW())
@@ -40,9 +117,14 @@ const edgeUpsertQuery = `
 // UpsertEdge implements graph.Graph.
 // TODO: make fix time standar so no need to call UTC() every time
 func (p *postgre) UpsertEdge(edge *graph.Edge) error {
+	return p.UpsertEdgeContext(context.Background(), edge)
+}
+
+// UpsertEdgeContext implements graph.ContextGraph.
+func (p *postgre) UpsertEdgeContext(ctx context.Context, edge *graph.Edge) error {
 	edge.UpdateAt = edge.UpdateAt.UTC()
 
-	err := p.db.QueryRowxContext(context.TODO(), edgeUpsertQuery, edge.Src, edge.Dst).Scan(&edge.ID, &edge.UpdateAt)
+	err := p.db.QueryRowxContext(ctx, edgeUpsertQuery, edge.Src, edge.Dst).Scan(&edge.ID, &edge.UpdateAt)
 	if err != nil {
 		pgErr, ok := err.(*pgconn.PgError)
 		if ok {
@@ -0,0 +1,112 @@
+package postgreindex
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// positional arguments of query, add return the placeholder of the value
+type sqlArgs []any
+
+func (a *sqlArgs) add(v any) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// matchedDocCondition followed by the condition of the query tree, if any
+func matchedCondition(tree *index.Clause, args *sqlArgs) string {
+	if tree == nil {
+		return matchedDocCondition
+	}
+	return matchedDocCondition + "\tAND " + clauseCondition(tree, args) + "\n"
+}
+
+// compile the clause into sql boolean expression
+func clauseCondition(c *index.Clause, args *sqlArgs) string {
+	switch c.Op {
+	case index.ClauseNot:
+		if len(c.Clauses) == 0 {
+			return "true"
+		}
+		return "NOT " + clauseCondition(c.Clauses[0], args)
+	case index.ClauseAnd, index.ClauseOr:
+		if len(c.Clauses) == 0 {
+			return "true"
+		}
+		op := " AND "
+		if c.Op == index.ClauseOr {
+			op = " OR "
+		}
+		conds := make([]string, 0, len(c.Clauses))
+		for _, child := range c.Clauses {
+			conds = append(conds, clauseCondition(child, args))
+		}
+		return "(" + strings.Join(conds, op) + ")"
+	}
+
+	switch c.Type {
+	case index.QueryTypePhrase:
+		return fmt.Sprintf("ts @@ phraseto_tsquery('english', %s)", args.add(c.Text))
+	case index.QueryTypePrefix:
+		if c.IsWildcard() {
+			return fmt.Sprintf("%s ~* %s", docText, args.add(wildcardRegexp(c.Text)))
+		}
+		prefix := strings.TrimSpace(strings.TrimSuffix(c.Text, "*"))
+		if prefix == "" {
+			return "true"
+		}
+		return fmt.Sprintf("ts @@ to_tsquery('english', %s)", args.add(prefixTSQuery(prefix)))
+	case index.QueryTypeFuzzy:
+		return fmt.Sprintf("word_similarity(%s, %s) >= %s::real",
+			args.add(c.Text), docText, args.add(fuzzySimilarity(c.EditDistance())))
+	default:
+		return fmt.Sprintf("ts @@ plainto_tsquery('english', %s)", args.add(c.Text))
+	}
+}
+
+// quoted lexeme with prefix mark, 'go':*
+func prefixTSQuery(prefix string) string {
+	prefix = strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(prefix)
+	return "'" + prefix + "':*"
+}
+
+// whole word pattern of the wildcard, * is any word characters and ? is one
+func wildcardRegexp(wildcard string) string {
+	var sb strings.Builder
+	sb.WriteString(`\m`)
+	for _, r := range wildcard {
+		switch {
+		case r == '*':
+			sb.WriteString(`\w*`)
+		case r == '?':
+			sb.WriteString(`\w`)
+		case r < 128 && !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'):
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteString(`\M`)
+	return sb.String()
+}
+
+// trigram word similarity required for edit distance,
+// trigram is not edit distance so every edit just lower the required similarity.
+func fuzzySimilarity(distance int) float64 {
+	return math.Max(0.2, 0.8-0.25*float64(distance))
+}
+
+// text relevance and title match use the words of the tree that are not negated
+func relevanceExpression(query index.Query) string {
+	expression := websearchExpression(query)
+	if tree := query.Tree(); tree != nil {
+		if terms := tree.Terms(); len(terms) > 0 {
+			expression += " " + strings.Join(terms, " or ")
+		}
+	}
+	return expression
+}
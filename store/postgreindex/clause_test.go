@@ -0,0 +1,39 @@
+package postgreindex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+func Test_clause_condition(t *testing.T) {
+	tree := &index.Clause{Op: index.ClauseAnd, Clauses: []*index.Clause{
+		{Op: index.ClauseOr, Clauses: []*index.Clause{
+			{Type: index.QueryTypePrefix, Text: "go*"},
+			{Type: index.QueryTypeFuzzy, Text: "rust", Fuzziness: 2},
+		}},
+		{Op: index.ClauseNot, Clauses: []*index.Clause{{Type: index.QueryTypePhrase, Text: "bad word"}}},
+		{Type: index.QueryTypePrefix, Text: "g?ph*r"},
+	}}
+
+	args := sqlArgs{"expression"}
+	got := clauseCondition(tree, &args)
+	expect := "((ts @@ to_tsquery('english', $2) OR word_similarity($3, " + docText + ") >= $4::real)" +
+		" AND NOT ts @@ phraseto_tsquery('english', $5) AND " + docText + " ~* $6)"
+	if got != expect {
+		t.Fatalf("\ngot:%v\nexpect:%v", got, expect)
+	}
+
+	expectArgs := fmt.Sprint(sqlArgs{"expression", "'go':*", "rust", fuzzySimilarity(2), "bad word", `\mg\wph\w*r\M`})
+	if fmt.Sprint(args) != expectArgs {
+		t.Fatalf("\ngot:%v\nexpect:%v", args, expectArgs)
+	}
+
+	if got := prefixTSQuery(`it's\`); got != `'it''s\\':*` {
+		t.Fatalf("\ngot:%v", got)
+	}
+	if got := wildcardRegexp("a.b*"); got != `\ma\.b\w*\M` {
+		t.Fatalf("\ngot:%v", got)
+	}
+}
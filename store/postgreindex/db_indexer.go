@@ -11,6 +11,7 @@ import (
 var batchSize int = 10
 
 var _ index.Indexer = (*indexdb)(nil)
+var _ index.ContextIndexer = (*indexdb)(nil)
 
 type indexdb struct {
 	db *sqlx.DB
@@ -2,6 +2,7 @@ package postgreindex
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 	"testing"
@@ -58,6 +59,141 @@ func Test_postgre_indexer(t *testing.T) {
 	// assertDocIterator
 	asserDocIterator(docs, docIt, t)
 
+	// filters
+	filterDocs := []*index.Document{
+		{LinkID: uuid.New(), URL: "https://pkg.go.dev/net/http", Title: "http package", Content: "gopher server", Language: "en"},
+		{LinkID: uuid.New(), URL: "https://go.dev/blog", Title: "go blog", Content: "gopher news", Language: "en"},
+		{LinkID: uuid.New(), URL: "https://golang.org/doc", Title: "dokumentasi", Content: "gopher server", Language: "id"},
+	}
+	if err := pgIndex.IndexBatch(filterDocs); err != nil {
+		t.Fatal(err)
+	}
+	filterTests := []struct {
+		query  index.Query
+		expect uint64
+	}{
+		{index.Query{Expression: "gopher"}, 3},
+		{index.Query{Expression: "gopher", Filters: index.Filters{Site: "go.dev"}}, 2},
+		{index.Query{Filters: index.Filters{InURL: "/doc"}}, 1},
+		{index.Query{Expression: "gopher", Filters: index.Filters{InTitle: "blog"}}, 1},
+		{index.Query{Expression: "gopher", Filters: index.Filters{Language: "id"}}, 1},
+		{index.Query{Expression: "gopher", Filters: index.Filters{IndexedBefore: time.Now().Add(-time.Hour)}}, 0},
+		{index.Query{Expression: "gopher", Exclude: []string{"server"}}, 1},
+		{index.Query{Phrases: []string{"gopher news"}}, 1},
+		{index.Query{Type: index.QueryTypePrefix, Expression: "serv*"}, 2},
+		{index.Query{Type: index.QueryTypePrefix, Expression: "h?tp"}, 1},
+		{index.Query{Type: index.QueryTypeFuzzy, Expression: "gophr"}, 3},
+		{index.Query{Type: index.QueryTypeBoolean, Boolean: &index.Clause{Op: index.ClauseAnd, Clauses: []*index.Clause{
+			{Op: index.ClauseOr, Clauses: []*index.Clause{{Text: "news"}, {Text: "http"}}},
+			{Op: index.ClauseNot, Clauses: []*index.Clause{{Text: "server"}}},
+		}}}, 1},
+	}
+	for _, test := range filterTests {
+		it, err := pgIndex.Search(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if it.TotalCount() != test.expect {
+			t.Fatalf("\nquery:%+v\ngot:%v\nexpect:%v", test.query, it.TotalCount(), test.expect)
+		}
+		it.Close()
+	}
+
+	// stemmed match is highlighted, the content is left out
+	hlIt, err := pgIndex.Search(index.Query{Expression: "servers", HighlightLength: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hlIt.Next() {
+		hl := hlIt.Highlight()
+		if hlIt.Document().Content != "" || hl == nil || len(hl.Fragments) == 0 || len(hl.Fragments[0].Matches) == 0 {
+			t.Fatalf("\ngot:%+v\nexpect highlighted fragment", hl)
+		}
+		if m := hl.Fragments[0].Matches[0]; hl.Fragments[0].Text[m.Start:m.End] != "server" {
+			t.Fatalf("\ngot:%v\nexpect:%v", hl.Fragments[0].Text[m.Start:m.End], "server")
+		}
+	}
+	if err := hlIt.Error(); err != nil {
+		t.Fatal(err)
+	}
+	hlIt.Close()
+
+	// vocabulary is not stemmed
+	vocabulary := map[string]int{}
+	err = pgIndex.Vocabulary(context.Background(), func(word string, docCount int) error {
+		vocabulary[word] = docCount
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vocabulary["gopher"] != 3 || vocabulary["news"] != 1 {
+		t.Fatalf("\ngot:%v", vocabulary)
+	}
+
+	// every document indexed so far has title
+	titles := map[string]float64{}
+	err = pgIndex.Titles(context.Background(), func(title string, pageRank float64) error {
+		titles[title] = pageRank
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != len(docs)+len(filterDocs) || titles["example_4"] != 4 {
+		t.Fatalf("\ngot:%v", titles)
+	}
+
+	// one document of every host, the other document of go.dev is collapsed
+	if err := pgIndex.Index(&index.Document{LinkID: uuid.New(), URL: "https://go.dev/tour", Title: "tour", Content: "gopher tour"}); err != nil {
+		t.Fatal(err)
+	}
+	collapseIt, err := pgIndex.Search(index.Query{Expression: "gopher", CollapseByHost: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts, n := map[string]int{}, 0
+	for ; collapseIt.Next(); n++ {
+		hosts[index.Host(collapseIt.Document().URL)] = collapseIt.(index.CollapseIterator).HostCount()
+	}
+	if err := collapseIt.Error(); err != nil {
+		t.Fatal(err)
+	}
+	collapseIt.Close()
+	if n != 3 || len(hosts) != 3 || hosts["go.dev"] != 2 || hosts["golang.org"] != 1 || collapseIt.TotalCount() != 4 {
+		t.Fatalf("\ngot:%v total:%v", hosts, collapseIt.TotalCount())
+	}
+
+	// the other gopher documents are related, the source is excluded
+	gopher := &index.Document{LinkID: uuid.New(), URL: "https://example.com/gopher", Title: "gopher", Content: "gopher tunnels"}
+	if err := pgIndex.Index(gopher); err != nil {
+		t.Fatal(err)
+	}
+	related, err := pgIndex.Related(gopher.LinkID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(related) != 4 {
+		t.Fatalf("\ngot:%v\nexpect:%v", len(related), 4)
+	}
+	for _, doc := range related {
+		if doc.LinkID == gopher.LinkID {
+			t.Fatalf("\ngot:%v\nexpect source excluded", doc.LinkID)
+		}
+	}
+
+	// the lexeme count is recounted outside of the write path
+	if err := pgIndex.refreshTermStats(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var ndoc int
+	if err := db.QueryRow("SELECT ndoc FROM term_stats WHERE term = 'gopher'").Scan(&ndoc); err != nil {
+		t.Fatal(err)
+	}
+	if ndoc != 5 {
+		t.Fatalf("\ngot:%v\nexpect:%v", ndoc, 5)
+	}
+
 	//===================
 	idx1 := &index.Document{
 		LinkID:    uuid.New(),
@@ -0,0 +1,36 @@
+package postgreindex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// ts_headline separate the fragments with it, like the match markers it is stripped from the content
+const fragmentDelimiter = "\x1f"
+
+// average characters of english word including the space, ts_headline count words
+const avgWordLength = 6
+
+// options of ts_headline for about length characters of fragments
+func headlineOptions(length int) string {
+	if length <= 0 {
+		return ""
+	}
+	maxWords := max(length/avgWordLength/index.MaxFragments, 2)
+	return fmt.Sprintf(`StartSel="%s", StopSel="%s", FragmentDelimiter="%s", MaxFragments=%d, MaxWords=%d, MinWords=%d`,
+		index.MatchStart, index.MatchEnd, fragmentDelimiter, index.MaxFragments, maxWords, maxWords/2)
+}
+
+// split the marked headline into fragments
+func parseHeadline(headline string) *index.Highlight {
+	hl := &index.Highlight{}
+	for _, marked := range strings.Split(headline, fragmentDelimiter) {
+		if strings.TrimSpace(marked) == "" {
+			continue
+		}
+		hl.Fragments = append(hl.Fragments, index.ParseFragment(marked))
+	}
+	return hl
+}
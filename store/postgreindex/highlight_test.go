@@ -0,0 +1,37 @@
+package postgreindex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+func Test_parseHeadline(t *testing.T) {
+	headline := "the \x02gopher\x03 was \x02running\x03" + fragmentDelimiter + " " + fragmentDelimiter + "\x02run\x03 again"
+	hl := parseHeadline(headline)
+
+	expect := []index.Fragment{
+		{Text: "the gopher was running", Matches: []index.Span{{Start: 4, End: 10}, {Start: 15, End: 22}}},
+		{Text: "run again", Matches: []index.Span{{Start: 0, End: 3}}},
+	}
+	if fmt.Sprint(hl.Fragments) != fmt.Sprint(expect) {
+		t.Fatalf("\ngot:%v\nexpect:%v", hl.Fragments, expect)
+	}
+}
+
+func Test_headlineOptions(t *testing.T) {
+	tests := []struct {
+		length int
+		expect string
+	}{
+		{0, ""},
+		{180, "StartSel=\"\x02\", StopSel=\"\x03\", FragmentDelimiter=\"\x1f\", MaxFragments=3, MaxWords=10, MinWords=5"},
+		{10, "StartSel=\"\x02\", StopSel=\"\x03\", FragmentDelimiter=\"\x1f\", MaxFragments=3, MaxWords=2, MinWords=1"},
+	}
+	for _, test := range tests {
+		if got := headlineOptions(test.length); got != test.expect {
+			t.Fatalf("\ngot:%q\nexpect:%q", got, test.expect)
+		}
+	}
+}
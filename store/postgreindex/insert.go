@@ -3,33 +3,106 @@ package postgreindex
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/odit-bit/invoker/textIndex/index"
 )
 
-const insertDocumentQuery = `
-	INSERT INTO documents (linkID, url, title, content, indexed_at, pagerank)
-	VALUES($1,$2,$3,$4, $5, $6)
-	ON CONFLICT (linkID) DO 
+// Index implements index.Indexer.
+// it uses to insert new document
+func (i *indexdb) Index(doc *index.Document) error {
+	return i.IndexContext(context.Background(), doc)
+}
+
+// IndexContext implements index.ContextIndexer.
+// it is a batch of one document, so the top terms is computed the same way.
+func (i *indexdb) IndexContext(ctx context.Context, doc *index.Document) error {
+	if doc.LinkID == uuid.Nil {
+		return fmt.Errorf("indexer insert document: uuid cannot be nil")
+	}
+	if err := i.IndexBatchContext(ctx, []*index.Document{doc}); err != nil {
+		return fmt.Errorf("%v, doc detail: %v", err, doc.URL)
+	}
+	return nil
+}
+
+// insert or update the documents, the same linkID cannot appear twice in one statement
+// so the input must be deduped. the top terms are computed after the documents is written.
+const insertDocumentBatchQuery = `
+	INSERT INTO documents (linkID, url, title, content, indexed_at, pagerank, language)
+	SELECT * FROM unnest($1::uuid[], $2::text[], $3::text[], $4::text[], $5::timestamp[], $6::float8[], $7::text[])
+	ON CONFLICT (linkID) DO
 	UPDATE
 		SET url = EXCLUDED.url,
 			title = EXCLUDED.title,
 			content = EXCLUDED.content,
+			language = EXCLUDED.language,
+			top_terms = '{}',
 			indexed_at = NOW();
 `
 
-// Index implements index.Indexer.
-// it uses to insert new document
-func (i *indexdb) Index(doc *index.Document) error {
+// IndexBatch implements index.Indexer.
+// it uses to insert many document within one transaction
+func (i *indexdb) IndexBatch(docs []*index.Document) error {
+	return i.IndexBatchContext(context.Background(), docs)
+}
 
-	if doc.LinkID == uuid.Nil {
-		return fmt.Errorf("indexer insert document: uuid cannot be nil")
+// IndexBatchContext implements index.ContextIndexer.
+func (i *indexdb) IndexBatchContext(ctx context.Context, docs []*index.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	// the last document win if linkID is duplicated
+	pos := make(map[uuid.UUID]int, len(docs))
+	var (
+		ids       = make([]string, 0, len(docs))
+		urls      = make([]string, 0, len(docs))
+		titles    = make([]string, 0, len(docs))
+		contents  = make([]string, 0, len(docs))
+		indexedAt = make([]time.Time, 0, len(docs))
+		pageranks = make([]float64, 0, len(docs))
+		languages = make([]string, 0, len(docs))
+	)
+	for _, doc := range docs {
+		if doc.LinkID == uuid.Nil {
+			return fmt.Errorf("indexer insert documents: uuid cannot be nil")
+		}
+		doc.IndexedAt = doc.IndexedAt.UTC()
+
+		if idx, ok := pos[doc.LinkID]; ok {
+			urls[idx], titles[idx], contents[idx] = doc.URL, doc.Title, doc.Content
+			indexedAt[idx], pageranks[idx], languages[idx] = doc.IndexedAt, doc.PageRank, doc.Language
+			continue
+		}
+		pos[doc.LinkID] = len(ids)
+		ids = append(ids, doc.LinkID.String())
+		urls = append(urls, doc.URL)
+		titles = append(titles, doc.Title)
+		contents = append(contents, doc.Content)
+		indexedAt = append(indexedAt, doc.IndexedAt)
+		pageranks = append(pageranks, doc.PageRank)
+		languages = append(languages, doc.Language)
 	}
-	doc.IndexedAt = doc.IndexedAt.UTC()
-	_, err := i.db.ExecContext(context.TODO(), insertDocumentQuery, doc.LinkID, doc.URL, doc.Title, doc.Content, doc.IndexedAt, doc.PageRank)
+
+	tx, err := i.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("indexer insert document error: %v, doc detail: %v", err, doc.URL)
+		return fmt.Errorf("indexer insert documents: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, insertDocumentBatchQuery, ids, urls, titles, contents, indexedAt, pageranks, languages)
+	if err != nil {
+		return fmt.Errorf("indexer insert documents error: %v, batch size: %v", err, len(ids))
+	}
+
+	if _, err := tx.ExecContext(ctx, updateTopTermsQuery, ids, index.RelatedTerms); err != nil {
+		return fmt.Errorf("indexer insert documents: top terms: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("indexer insert documents: %v", err)
 	}
 	return nil
 }
@@ -44,7 +117,12 @@ const updateScoreQuery = `
 // UpdateScore implements index.Indexer.
 // update the pagerank score's dcoument by linkID
 func (i *indexdb) UpdateScore(linkID uuid.UUID, score float64) error {
-	_, err := i.db.ExecContext(context.TODO(), updateScoreQuery, score, linkID)
+	return i.UpdateScoreContext(context.Background(), linkID, score)
+}
+
+// UpdateScoreContext implements index.ContextIndexer.
+func (i *indexdb) UpdateScoreContext(ctx context.Context, linkID uuid.UUID, score float64) error {
+	_, err := i.db.ExecContext(ctx, updateScoreQuery, score, linkID)
 	if err != nil {
 		return fmt.Errorf("update pagerank document : %v", err)
 	}
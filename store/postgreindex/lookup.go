@@ -9,20 +9,26 @@ import (
 )
 
 const lookupDocumentQuery = `
-	SELECT linkID, url, title, content, indexed_at, pagerank FROM documents
+	SELECT linkID, url, title, content, indexed_at, pagerank, language FROM documents
 	WHERE linkID = $1
 `
 
 // Lookup implements index.Indexer.
 func (i *indexdb) Lookup(linkID uuid.UUID) (*index.Document, error) {
+	return i.LookupContext(context.Background(), linkID)
+}
+
+// LookupContext implements index.ContextIndexer.
+func (i *indexdb) LookupContext(ctx context.Context, linkID uuid.UUID) (*index.Document, error) {
 	var doc index.Document
-	err := i.db.QueryRowxContext(context.TODO(), lookupDocumentQuery, linkID).Scan(
+	err := i.db.QueryRowxContext(ctx, lookupDocumentQuery, linkID).Scan(
 		&doc.LinkID,
 		&doc.URL,
 		&doc.Title,
 		&doc.Content,
 		&doc.IndexedAt,
 		&doc.PageRank,
+		&doc.Language,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("indexer lookup document: %v", err)
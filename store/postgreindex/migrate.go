@@ -2,74 +2,26 @@ package postgreindex
 
 import (
 	"context"
-	"fmt"
+	"embed"
+
+	"github.com/odit-bit/invoker/internal/migrate"
 )
 
-const dropDocumentsTable = `
-	DROP TABLE IF EXISTS documents;
-`
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
 
-const dropDocumentsIndex = `
-	DROP INDEX ts_idx;
-`
+// Migrations is the versioned schema of the index store
+var Migrations = migrate.Source{Name: "index", FS: migrationFiles, Dir: "migrations"}
 
+// revert every migration
 func (i *indexdb) drop() error {
-	_, err := i.db.ExecContext(context.TODO(), dropDocumentsIndex)
-	if err != nil {
-		return err
-	}
-
-	_, err = i.db.ExecContext(context.TODO(), dropDocumentsTable)
+	m, err := migrate.New(i.db, Migrations)
 	if err != nil {
 		return err
 	}
-	return nil
+	return m.To(context.TODO(), 0)
 }
 
-// create table
-const createDocumentsTable = `
-	CREATE TABLE IF NOT EXISTS documents(
-		linkID uuid UNIQUE NOT NULL,
-		url text NOT NULL,
-		title text,
-		content text,
-		indexed_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		pagerank double precision
-	);
-`
-
-// full text search implementation
-
-var (
-	//generate text-search column for table
-	alterColumnSearch = fmt.Sprintf(`
-	ALTER TABLE documents
-	ADD COLUMN IF NOT EXISTS ts tsvector GENERATED ALWAYS AS (%s) STORED;
-`, tsvector)
-
-	//create index for text-search
-	createSearchIndex = fmt.Sprintf(`
-	CREATE INDEX IF NOT EXISTS ts_idx ON documents USING gin(%v)
-`, tsvector)
-)
-
 func (i *indexdb) migrate() error {
-	//create table
-	_, err := i.db.ExecContext(context.TODO(), createDocumentsTable)
-	if err != nil {
-		return fmt.Errorf("create table: %v", err)
-	}
-
-	// alter columns ts
-	_, err = i.db.ExecContext(context.TODO(), alterColumnSearch)
-	if err != nil {
-		return fmt.Errorf("alter ts column: %v", err)
-	}
-
-	//create index search
-	_, err = i.db.ExecContext(context.TODO(), createSearchIndex)
-	if err != nil {
-		return err
-	}
-	return nil
+	return migrate.Up(context.TODO(), i.db, Migrations)
 }
@@ -0,0 +1,9 @@
+-- IF NOT EXISTS so database created before versioned migrations is adopted as it is
+CREATE TABLE IF NOT EXISTS documents(
+	linkID uuid UNIQUE NOT NULL,
+	url text NOT NULL,
+	title text,
+	content text,
+	indexed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	pagerank double precision
+);
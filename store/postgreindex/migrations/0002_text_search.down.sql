@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS ts_idx;
+ALTER TABLE documents DROP COLUMN IF EXISTS ts;
@@ -0,0 +1,5 @@
+-- generated text-search column and it's index
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS ts tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(title, '') || ' ' || coalesce(content,''))) STORED;
+
+CREATE INDEX IF NOT EXISTS ts_idx ON documents USING gin(to_tsvector('english', coalesce(title, '') || ' ' || coalesce(content,'')));
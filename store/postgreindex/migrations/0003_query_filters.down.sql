@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS documents_indexed_at_idx;
+DROP INDEX IF EXISTS documents_host_idx;
+ALTER TABLE documents DROP COLUMN IF EXISTS host;
+ALTER TABLE documents DROP COLUMN IF EXISTS language;
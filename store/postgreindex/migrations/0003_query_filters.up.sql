@@ -0,0 +1,8 @@
+-- columns for the search filters, host derived from url so site: can use index
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS language text NOT NULL DEFAULT '';
+
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS host text GENERATED ALWAYS AS (lower(substring(url from '^[^:/?#]+://(?:[^@/?#]*@)?([^/:?#]+)'))) STORED;
+
+CREATE INDEX IF NOT EXISTS documents_host_idx ON documents (host);
+CREATE INDEX IF NOT EXISTS documents_indexed_at_idx ON documents (indexed_at);
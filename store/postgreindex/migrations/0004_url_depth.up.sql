@@ -0,0 +1,5 @@
+-- number of non-empty path segments of the url, factor of the ranking
+ALTER TABLE documents
+ADD COLUMN IF NOT EXISTS url_depth int GENERATED ALWAYS AS (
+	COALESCE(cardinality(array_remove(string_to_array(substring(url from '^[^:/?#]+://[^/?#]+([^?#]*)'), '/'), '')), 0)
+) STORED;
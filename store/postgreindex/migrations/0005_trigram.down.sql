@@ -0,0 +1 @@
+DROP INDEX IF EXISTS documents_trgm_idx;
@@ -0,0 +1,5 @@
+-- fuzzy and wildcard query match the text by trigram
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS documents_trgm_idx ON documents
+USING gin ((coalesce(title, '') || ' ' || coalesce(content, '')) gin_trgm_ops);
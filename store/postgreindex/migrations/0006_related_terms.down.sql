@@ -0,0 +1,3 @@
+ALTER TABLE documents DROP COLUMN IF EXISTS top_terms;
+
+DROP TABLE IF EXISTS term_stats;
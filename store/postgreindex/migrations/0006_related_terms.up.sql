@@ -0,0 +1,14 @@
+-- number of documents contain the lexeme, the idf of the tf-idf term weight.
+-- it is recounted periodically by TermStatsService, the indexer does not touch it,
+-- so document indexed between the refresh is weighted with the previous count
+CREATE TABLE IF NOT EXISTS term_stats (
+	term text PRIMARY KEY,
+	ndoc bigint NOT NULL
+);
+
+INSERT INTO term_stats (term, ndoc)
+SELECT word, ndoc FROM ts_stat('SELECT ts FROM documents')
+ON CONFLICT (term) DO NOTHING;
+
+-- best weighted lexemes of the document at index time, existing document get them on first related search
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS top_terms text[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,135 @@
+package postgreindex
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ index.RelatedIndexer = (*indexdb)(nil)
+
+// store the best $2 lexemes of the documents $1 weighted by tf-idf,
+// tf is the positions of the lexeme and the idf is smoothed like the bleveindex,
+// the count of the lexeme is taken from term_stats that refreshed by TermStatsService.
+// the document count is the planner estimate, the document itself is counted.
+const updateTopTermsQuery = `
+UPDATE documents d SET top_terms = w.terms
+FROM (
+	SELECT linkID, array_agg(lexeme ORDER BY weight DESC, lexeme) AS terms
+	FROM (
+		SELECT linkID, lexeme, weight, row_number() OVER (PARTITION BY linkID ORDER BY weight DESC, lexeme) AS rank
+		FROM (
+			SELECT d.linkID, u.lexeme,
+				coalesce(array_length(u.positions, 1), 1) * ln(1 + (n.docs + 1) / (1 + greatest(s.ndoc, 0))) AS weight
+			FROM documents d
+			CROSS JOIN LATERAL unnest(d.ts) u
+			CROSS JOIN (SELECT greatest(reltuples, 0)::float8 AS docs FROM pg_class WHERE oid = 'documents'::regclass) n
+			LEFT JOIN term_stats s ON s.term = u.lexeme
+			WHERE d.linkID = ANY($1::uuid[]) AND length(u.lexeme) > 1
+		) weighted
+	) ranked
+	WHERE rank <= $2
+	GROUP BY linkID
+) w
+WHERE d.linkID = w.linkID
+`
+
+// one row per term in weight order, a row of null term if the document has none
+const topTermsQuery = `
+SELECT t.term FROM documents d
+LEFT JOIN LATERAL unnest(d.top_terms) WITH ORDINALITY AS t(term, pos) ON true
+WHERE d.linkID = $1
+ORDER BY t.pos
+`
+
+// documents match any of the terms, the more terms they match the higher the rank.
+// the terms are lexemes already so they are quoted into the query instead of parsed,
+// quote and backslash of the lexeme is escaped like the tsquery input expect.
+const relatedDocumentsQuery = `
+WITH q AS (
+	SELECT string_agg('''' || replace(replace(term, '\', '\\'), '''', '''''') || '''', ' | ')::tsquery AS query
+	FROM unnest($2::text[]) AS term
+)
+SELECT linkID, url, title, content, indexed_at, pagerank, language FROM documents, q
+WHERE linkID <> $1 AND ts @@ q.query
+ORDER BY ts_rank(ts, q.query) DESC, linkID DESC
+LIMIT $3
+`
+
+// Related implements index.RelatedIndexer.
+func (i *indexdb) Related(linkID uuid.UUID, n int) ([]*index.Document, error) {
+	return i.RelatedContext(context.Background(), linkID, n)
+}
+
+// RelatedContext implements index.RelatedIndexer.
+func (i *indexdb) RelatedContext(ctx context.Context, linkID uuid.UUID, n int) ([]*index.Document, error) {
+	terms, err := i.topTerms(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("indexer related documents: %w", err)
+	}
+	if len(terms) == 0 || n <= 0 {
+		return nil, nil
+	}
+
+	rows, err := i.db.QueryxContext(ctx, relatedDocumentsQuery, linkID, terms, n)
+	if err != nil {
+		return nil, fmt.Errorf("indexer related documents: %v", err)
+	}
+	defer rows.Close()
+
+	var related []*index.Document
+	for rows.Next() {
+		var doc index.Document
+		if err := rows.Scan(&doc.LinkID, &doc.URL, &doc.Title, &doc.Content, &doc.IndexedAt, &doc.PageRank, &doc.Language); err != nil {
+			return nil, fmt.Errorf("indexer related documents: %v", err)
+		}
+		related = append(related, &doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("indexer related documents: %v", err)
+	}
+	return related, nil
+}
+
+// stored top terms of the document, document indexed before the terms is stored get them now
+func (i *indexdb) topTerms(ctx context.Context, linkID uuid.UUID) ([]string, error) {
+	terms, err := i.storedTerms(ctx, linkID)
+	if err != nil || len(terms) > 0 {
+		return terms, err
+	}
+	if _, err := i.db.ExecContext(ctx, updateTopTermsQuery, []string{linkID.String()}, index.RelatedTerms); err != nil {
+		return nil, err
+	}
+	return i.storedTerms(ctx, linkID)
+}
+
+func (i *indexdb) storedTerms(ctx context.Context, linkID uuid.UUID) ([]string, error) {
+	rows, err := i.db.QueryContext(ctx, topTermsQuery, linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := false
+	var terms []string
+	for rows.Next() {
+		found = true
+		var term sql.NullString
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		if term.Valid {
+			terms = append(terms, term.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, index.ErrNotFound
+	}
+	return terms, nil
+}
@@ -2,56 +2,164 @@ package postgreindex
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/odit-bit/invoker/textIndex/index"
 )
 
-// like matchDocQuery but whe $1 is blank or space it will return all
-const searchDocCountQuery = `
-SELECT COUNT(*) FROM documents
-WHERE
+// condition of matched documents, shared by search and count query.
+// $1 is the text search expression, blank or space match every document.
+// $2 to $7 are the filters, empty or null filter nothing.
+const matchedDocCondition = `
 	CASE
 		WHEN length(trim($1)) = 0 THEN true
 		ELSE ts @@ websearch_to_tsquery('english', $1)
 	END
+	AND ($2::text = '' OR host = $2 OR host LIKE '%.' || $2)
+	AND ($3::text = '' OR strpos(url, $3) > 0)
+	AND ($4::text = '' OR to_tsvector('english', coalesce(title, '')) @@ plainto_tsquery('english', $4))
+	AND ($5::timestamp IS NULL OR indexed_at >= $5::timestamp)
+	AND ($6::timestamp IS NULL OR indexed_at < $6::timestamp)
+	AND ($7::text = '' OR language = $7)
 `
 
-// plainto_tsquery vs to_tsquery
-var searchDocQuery = `
-SELECT linkID, url, title, content, indexed_at, pagerank
-FROM documents
-WHERE
-	CASE
-		WHEN length(trim($1)) = 0 THEN true
-		ELSE ts @@ websearch_to_tsquery('english', $1)
-	END
-ORDER BY
-	pagerank DESC,
+// condition of the query tree is appended to matchedDocCondition,
+// its arguments follow the other arguments of the query.
+// fuzzy and wildcard match the text with pg_trgm, the expression is the same
+// as the trigram index so it can be used.
+const docText = `(coalesce(title, '') || ' ' || coalesce(content, ''))`
 
-	CASE
-		WHEN length(trim($1)) = 0 THEN NULL
-	 	ELSE ts_rank(ts, websearch_to_tsquery('english', $1), 32)
-	END DESC	
+const searchDocCountQuery = `
+SELECT COUNT(*) FROM documents
+WHERE`
+
+// planner estimate of the matched documents, the first plan node is the scan
+const searchDocEstimateQuery = `
+EXPLAIN (FORMAT JSON)
+SELECT 1 FROM documents
+WHERE`
+
+// estimated count below it is counted exactly,
+// planner estimate of small result is not accurate and counting it is cheap.
+const estimateCountThreshold = 10000
 
-OFFSET ($2) ROWS
-FETCH FIRST ($3) ROWS ONLY;
+// the result ordered by (score, linkID) so the page after a cursor
+// is found by row comparison instead of skipping the previous pages.
+// %s is the matched condition.
+// the score blend the factors like index.Ranking.Explain,
+// $8 is the expression of text relevance and title match,
+// $9 to $15 are the ranking weights, pivot and half-life in seconds,
+// $16 is the reference time of freshness and $17 is false when there is no cursor.
+// $22 is true when the content is replaced with the headline of ts_headline options $23,
+// it is computed after the page is cut so only the returned documents are highlighted.
+// the second %s is the ranked documents of the host, one of rankedDocs or rankedByHost,
+// document with host rank above $24 is collapsed.
+const searchDocQuery = `
+WITH matched AS (
+	SELECT linkID, url, host, title, content, indexed_at, COALESCE(pagerank, 0) AS pagerank, language, url_depth,
+		CASE
+			WHEN length(trim($8)) = 0 THEN 0
+			ELSE ts_rank(ts, websearch_to_tsquery('english', $8))
+		END::double precision AS relevance,
+		CASE
+			WHEN length(trim($8)) = 0 THEN false
+			ELSE to_tsvector('english', coalesce(title, '')) @@ websearch_to_tsquery('english', $8)
+		END AS title_match
+	FROM documents
+	WHERE %s), scored AS (
+	SELECT *,
+		$9::double precision * relevance / (relevance + 1)
+		+ $10::double precision * pagerank / (pagerank + $14::double precision)
+		+ $11::double precision * power(0.5::double precision,
+			GREATEST(extract(epoch FROM ($16::timestamp - indexed_at))::double precision, 0) / $15::double precision)
+		+ $12::double precision * title_match::int
+		+ $13::double precision / (1 + url_depth) AS score
+	FROM matched
+), ranked AS (%s
+), page AS (
+	SELECT * FROM ranked
+	WHERE host_rank <= $24::int
+		AND (NOT $17::boolean OR (score, linkID) < ($18::double precision, $19::uuid))
+	ORDER BY score DESC, linkID DESC
+	OFFSET ($20) ROWS
+	FETCH FIRST ($21) ROWS ONLY
+)
+SELECT linkID, url, title,
+	CASE WHEN $22::boolean THEN '' ELSE content END,
+	CASE
+		WHEN $22::boolean THEN ts_headline('english', translate(coalesce(content, ''), E'\x02\x03\x1f', ''),
+			websearch_to_tsquery('english', $8), $23)
+		ELSE ''
+	END,
+	indexed_at, pagerank, language, relevance, title_match, url_depth, score, host_count
+FROM page
+ORDER BY score DESC, linkID DESC;
 `
 
+// every document is kept when the result is not collapsed
+const rankedDocs = `
+	SELECT *, 1 AS host_rank, 0 AS host_count FROM scored`
+
+// documents of the same host numbered from the best one,
+// url without host is a group of its own like index.Host.
+const rankedByHost = `
+	SELECT *,
+		row_number() OVER (PARTITION BY coalesce(host, linkID::text) ORDER BY score DESC, linkID DESC) AS host_rank,
+		count(*) OVER (PARTITION BY coalesce(host, linkID::text)) AS host_count
+	FROM scored`
+
 // Search full-text index document.
 func (i *indexdb) Search(query index.Query) (index.Iterator, error) {
-	//get the matchedCount document
-	var matchedCount int
-	err := i.db.QueryRowxContext(context.TODO(), searchDocCountQuery, query.Expression).Scan(&matchedCount)
+	return i.SearchContext(context.Background(), query)
+}
+
+// SearchContext implements index.ContextIndexer.
+func (i *indexdb) SearchContext(ctx context.Context, query index.Query) (index.Iterator, error) {
+	var (
+		after     = index.Cursor{At: time.Now().UTC()}
+		hasCursor = query.Cursor != ""
+		offset    = query.Offset
+	)
+	if hasCursor {
+		var err error
+		if after, err = index.ParseCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+		offset = 0
+	}
+
+	args := matchedDocArgs(query)
+	countArgs := append(sqlArgs(nil), args...)
+	countCond := matchedCondition(query.Tree(), &countArgs)
+	matchedCount, err := i.countMatched(ctx, countCond, countArgs, query.EstimateCount)
 	if err != nil {
-		return nil, fmt.Errorf("index search documents matched count: %v", err)
+		return nil, err
 	}
 
-	pageSize := batchSize
-	offset := query.Offset
+	limit := batchSize
+	if query.Limit > 0 {
+		limit = query.Limit
+	}
 
-	rows, err := i.db.QueryxContext(context.TODO(), searchDocQuery, query.Expression, offset, pageSize)
+	ranking := index.RankingOf(query)
+	args = append(args, relevanceExpression(query),
+		ranking.Relevance, ranking.PageRank, ranking.Freshness, ranking.TitleMatch, ranking.URLDepth,
+		ranking.PageRankPivot, ranking.FreshnessHalfLife.Seconds(), after.At,
+		hasCursor, after.Score, after.LinkID, offset, limit,
+		query.HighlightLength > 0, headlineOptions(query.HighlightLength),
+	)
+	// host rank of document is always 1 when not collapsed
+	ranked, collapse := rankedDocs, 1
+	if query.CollapseByHost > 0 {
+		ranked, collapse = rankedByHost, query.CollapseByHost
+	}
+	args = append(args, collapse)
+	cond := matchedCondition(query.Tree(), &args)
+	rows, err := i.db.QueryxContext(ctx, fmt.Sprintf(searchDocQuery, cond, ranked), args...)
 	if err != nil {
 		return nil, fmt.Errorf("index search documents: %v", err)
 	}
@@ -62,11 +170,87 @@ func (i *indexdb) Search(query index.Query) (index.Iterator, error) {
 		latchedErr:   nil,
 		expression:   query.Expression,
 		totalMatched: matchedCount,
+		cursor:       index.Cursor{At: after.At},
 	}
+	if query.Explain {
+		docIterator.ranking = &ranking
+	}
+	docIterator.highlighted = query.HighlightLength > 0
 	return &docIterator, err
 }
 
-var _ index.Iterator = (*iterator)(nil)
+// arguments of matchedDocCondition
+func matchedDocArgs(query index.Query) sqlArgs {
+	f := query.Filters
+	return sqlArgs{
+		websearchExpression(query),
+		strings.ToLower(f.Site),
+		f.InURL,
+		f.InTitle,
+		nullTime(f.IndexedAfter),
+		nullTime(f.IndexedBefore),
+		f.Language,
+	}
+}
+
+// phrases and excluded terms are appended to the expression in websearch_to_tsquery syntax,
+// the expression of query matched by its tree is left out.
+func websearchExpression(query index.Query) string {
+	var sb strings.Builder
+	if query.Tree() == nil {
+		sb.WriteString(query.Expression)
+	}
+	for _, phrase := range query.Phrases {
+		fmt.Fprintf(&sb, ` "%s"`, strings.ReplaceAll(phrase, `"`, " "))
+	}
+	for _, term := range query.Exclude {
+		term = strings.ReplaceAll(term, `"`, " ")
+		if strings.ContainsAny(strings.TrimSpace(term), " \t") {
+			fmt.Fprintf(&sb, ` -"%s"`, term)
+		} else {
+			fmt.Fprintf(&sb, " -%s", strings.TrimSpace(term))
+		}
+	}
+	return sb.String()
+}
+
+// zero time is stored as null, indexed_at is stored as UTC
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// number of documents matched the condition
+func (i *indexdb) countMatched(ctx context.Context, cond string, args []any, estimate bool) (uint64, error) {
+	if estimate {
+		var plan string
+		if err := i.db.QueryRowxContext(ctx, searchDocEstimateQuery+cond, args...).Scan(&plan); err != nil {
+			return 0, fmt.Errorf("index search documents estimated count: %v", err)
+		}
+		var explain []struct {
+			Plan struct {
+				Rows float64 `json:"Plan Rows"`
+			} `json:"Plan"`
+		}
+		if err := json.Unmarshal([]byte(plan), &explain); err != nil || len(explain) == 0 {
+			return 0, fmt.Errorf("index search documents estimated count: unexpected plan %q", plan)
+		}
+		if rows := uint64(explain[0].Plan.Rows); rows >= estimateCountThreshold {
+			return rows, nil
+		}
+	}
+
+	var matchedCount uint64
+	if err := i.db.QueryRowxContext(ctx, searchDocCountQuery+cond, args...).Scan(&matchedCount); err != nil {
+		return 0, fmt.Errorf("index search documents matched count: %v", err)
+	}
+	return matchedCount, nil
+}
+
+var _ index.ExplainIterator = (*iterator)(nil)
+var _ index.CollapseIterator = (*iterator)(nil)
 
 type iterator struct {
 	rows *sqlx.Rows
@@ -75,7 +259,20 @@ type iterator struct {
 	latchedErr error
 
 	expression   string
-	totalMatched int
+	totalMatched uint64
+
+	// position of latchedDoc
+	cursor index.Cursor
+
+	// set when the query ask for explanation
+	ranking     *index.Ranking
+	explanation *index.Explanation
+
+	// the query ask for highlight
+	highlighted bool
+	highlight   *index.Highlight
+	// documents of the host of latchedDoc, zero when not collapsed
+	hostCount int
 }
 
 // Close implements index.Iterator.
@@ -107,7 +304,30 @@ func (it *iterator) Next() bool {
 
 // TotalCount implements index.Iterator.
 func (it *iterator) TotalCount() uint64 {
-	return uint64(it.totalMatched)
+	return it.totalMatched
+}
+
+// Explain implements index.ExplainIterator.
+func (it *iterator) Explain() *index.Explanation {
+	return it.explanation
+}
+
+// HostCount implements index.CollapseIterator.
+func (it *iterator) HostCount() int {
+	return it.hostCount
+}
+
+// Highlight implements index.Iterator.
+func (it *iterator) Highlight() *index.Highlight {
+	return it.highlight
+}
+
+// Cursor implements index.Iterator.
+func (it *iterator) Cursor() string {
+	if it.latchedDoc == nil {
+		return ""
+	}
+	return it.cursor.String()
 }
 
 func (it *iterator) fecthDoc() bool {
@@ -118,19 +338,40 @@ func (it *iterator) fecthDoc() bool {
 		return false
 	}
 
-	var doc index.Document
+	var (
+		doc      index.Document
+		factors  index.RankFactors
+		headline string
+	)
 	err := it.rows.Scan(
 		&doc.LinkID,
 		&doc.URL,
 		&doc.Title,
 		&doc.Content,
+		&headline,
 		&doc.IndexedAt,
 		&doc.PageRank,
+		&doc.Language,
+		&factors.Relevance,
+		&factors.TitleMatch,
+		&factors.URLDepth,
+		&it.cursor.Score,
+		&it.hostCount,
 	)
 	if err != nil {
 		it.latchedErr = err
 		return false
 	}
 	it.latchedDoc = &doc
+	it.cursor.LinkID = doc.LinkID
+	if it.ranking != nil {
+		factors.PageRank, factors.IndexedAt = doc.PageRank, doc.IndexedAt
+		it.explanation = it.ranking.Explain(factors, it.cursor.At)
+		// report the score the result is ordered by
+		it.explanation.Score = it.cursor.Score
+	}
+	if it.highlighted {
+		it.highlight = parseHeadline(headline)
+	}
 	return true
 }
@@ -0,0 +1,77 @@
+package postgreindex
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// recount the document count of every lexeme from the documents and replace term_stats with it,
+// it is the only writer of term_stats so the indexer never lock the rows of common lexemes.
+// rows are written in term order, the lexemes no longer in any document are removed.
+const refreshTermStatsQuery = `
+WITH stat AS (
+	SELECT word, ndoc FROM ts_stat('SELECT ts FROM documents')
+), upserted AS (
+	INSERT INTO term_stats (term, ndoc)
+	SELECT word, ndoc FROM stat ORDER BY word
+	ON CONFLICT (term) DO UPDATE SET ndoc = EXCLUDED.ndoc
+	WHERE term_stats.ndoc <> EXCLUDED.ndoc
+)
+DELETE FROM term_stats t WHERE NOT EXISTS (SELECT 1 FROM stat WHERE stat.word = t.term)
+`
+
+// scan every document, it is run periodically by TermStatsService
+func (i *indexdb) refreshTermStats(ctx context.Context) error {
+	if _, err := i.db.ExecContext(ctx, refreshTermStatsQuery); err != nil {
+		return fmt.Errorf("indexer refresh term stats: %v", err)
+	}
+	return nil
+}
+
+// TermStatsService periodically recount the document count of the lexemes (the idf of top terms).
+// document indexed between the refresh is weighted with the previous count,
+// lexeme that is not counted yet weight like it is found in no other document.
+type TermStatsService struct {
+	idx      *indexdb
+	interval time.Duration
+	logger   *log.Logger
+}
+
+func NewTermStatsService(idx *indexdb, interval time.Duration) (*TermStatsService, error) {
+	if idx == nil {
+		return nil, fmt.Errorf("index has not been provided")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid value for term stats interval")
+	}
+	return &TermStatsService{
+		idx:      idx,
+		interval: interval,
+		logger:   log.New(os.Stdout, "[term-stats]", log.Ldate|log.Ltime),
+	}, nil
+}
+
+// Name implements service.Service
+func (svc *TermStatsService) Name() string { return "term stats" }
+
+// Run implements service.Service
+func (svc *TermStatsService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(svc.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := svc.idx.refreshTermStats(ctx); err != nil && ctx.Err() == nil {
+			// keep the previous count, try again next tick
+			svc.logger.Printf("[ERROR] %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
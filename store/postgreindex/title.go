@@ -0,0 +1,42 @@
+package postgreindex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ index.TitleIndexer = (*indexdb)(nil)
+
+// single scan streamed from the server, blank title is skipped
+const titlesQuery = `
+SELECT title, COALESCE(pagerank, 0) FROM documents
+WHERE COALESCE(title, '') <> ''
+`
+
+// Titles implements index.TitleIndexer.
+func (i *indexdb) Titles(ctx context.Context, fn func(title string, pageRank float64) error) error {
+	rows, err := i.db.QueryContext(ctx, titlesQuery)
+	if err != nil {
+		return fmt.Errorf("indexer titles: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			title    string
+			pageRank float64
+		)
+		if err := rows.Scan(&title, &pageRank); err != nil {
+			return fmt.Errorf("indexer titles: %v", err)
+		}
+		if err := fn(title, pageRank); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("indexer titles: %v", err)
+	}
+	return nil
+}
@@ -1,3 +0,0 @@
-package postgreindex
-
-var tsvector = "to_tsvector('english', coalesce(title, '') || ' ' || coalesce(content,''))"
@@ -0,0 +1,42 @@
+package postgreindex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+var _ index.VocabularyIndexer = (*indexdb)(nil)
+
+// ts column is stemmed, the words are taken from the text with the simple configuration.
+// it scan every document, the caller is expected to cache the result.
+const vocabularyQuery = `
+SELECT word, ndoc FROM ts_stat($$SELECT to_tsvector('simple', ` + docText + `) FROM documents$$)
+`
+
+// Vocabulary implements index.VocabularyIndexer.
+func (i *indexdb) Vocabulary(ctx context.Context, fn func(word string, docCount int) error) error {
+	rows, err := i.db.QueryContext(ctx, vocabularyQuery)
+	if err != nil {
+		return fmt.Errorf("indexer vocabulary: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			word     string
+			docCount int
+		)
+		if err := rows.Scan(&word, &docCount); err != nil {
+			return fmt.Errorf("indexer vocabulary: %v", err)
+		}
+		if err := fn(word, docCount); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("indexer vocabulary: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package postgreleader
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/internal/migrate"
+)
+
+// Lease elect single leader among instances by holding a lease row.
+// the holder renew the lease periodically, the other instances keep
+// trying to acquire it and take over when the lease expired.
+//
+// lease row is used instead of advisory lock because advisory lock belong
+// to a single connection, and sqlx.DB hand queries to any connection in the pool.
+type Lease struct {
+	db *sqlx.DB
+
+	name   string
+	holder string
+	ttl    time.Duration
+
+	mu     sync.RWMutex
+	leader bool
+
+	// the leader step down at this time unless the lease is renewed,
+	// it is the start of the last successful renewal plus ttl minus safety margin.
+	validUntil time.Time
+
+	// acquire or renew the lease, replaced in test
+	acquireFn func(ctx context.Context) (bool, error)
+
+	logger *log.Logger
+}
+
+// fraction of the ttl the leader step down before its lease expire,
+// so a standby never take over while this instance still think it lead
+// even with some clock drift between instances and database.
+const leaseSafetyDivisor = 4
+
+// Config encapsulates the settings for Lease
+type Config struct {
+	// name of the lease, instances compete for the same name
+	Name string
+
+	// unique name of this instance, if empty hostname + random suffix will be used
+	InstanceID string
+
+	// the lease expired if holder not renew it for this duration,
+	// default 30 second.
+	LeaseTTL time.Duration
+
+	Logger *log.Logger
+}
+
+func New(db *sqlx.DB, cfg Config) (*Lease, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("postgreleader: lease name cannot be empty")
+	}
+	if cfg.InstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "instance"
+		}
+		cfg.InstanceID = fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = 30 * time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stdout, "[leader]", log.Ldate|log.Ltime)
+	}
+
+	l := Lease{
+		db:     db,
+		name:   cfg.Name,
+		holder: cfg.InstanceID,
+		ttl:    cfg.LeaseTTL,
+		logger: cfg.Logger,
+	}
+	l.acquireFn = l.acquire
+	if err := l.migrate(); err != nil {
+		return nil, fmt.Errorf("postgreleader migrate: %v", err)
+	}
+	return &l, nil
+}
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations is the versioned schema of the lease store
+var Migrations = migrate.Source{Name: "leader", FS: migrationFiles, Dir: "migrations"}
+
+func (l *Lease) migrate() error {
+	return migrate.Up(context.TODO(), l.db, Migrations)
+}
+
+// Holder return the name of this instance
+func (l *Lease) Holder() string {
+	return l.holder
+}
+
+// IsLeader report whether this instance hold the lease,
+// it is false once the last renewal is too old even if the renewal is still in progress.
+func (l *Lease) IsLeader() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.leader && time.Now().Before(l.validUntil)
+}
+
+// Run campaign for the lease until ctx is done,
+// the lease is released on exit so standby can take over right away.
+func (l *Lease) Run(ctx context.Context) error {
+	l.logger.Printf("campaign lease: %v holder: %v ttl: %v\n", l.name, l.holder, l.ttl)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		l.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			return l.release()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *Lease) campaign(ctx context.Context) {
+	// the lease expire ttl after the query reach database, that is after this
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, l.ttl/leaseSafetyDivisor)
+	defer cancel()
+
+	acquired, err := l.acquireFn(ctx)
+	if err != nil {
+		// the lease still valid until ttl if this instance is the leader,
+		// step down once it is about to expire so standby can take over safely.
+		l.logger.Printf("[WARN] acquire lease: %v\n", err)
+		l.mu.Lock()
+		expired := l.leader && !time.Now().Before(l.validUntil)
+		if expired {
+			l.leader = false
+		}
+		l.mu.Unlock()
+		if expired {
+			l.logger.Printf("[INFO] %v step down from %v, lease not renewed\n", l.holder, l.name)
+		}
+		return
+	}
+
+	l.mu.Lock()
+	changed := l.leader != acquired
+	l.leader = acquired
+	if acquired {
+		l.validUntil = start.Add(l.ttl - l.ttl/leaseSafetyDivisor)
+	}
+	l.mu.Unlock()
+
+	if changed && acquired {
+		l.logger.Printf("[INFO] %v become leader of %v\n", l.holder, l.name)
+	} else if changed {
+		l.logger.Printf("[INFO] %v lost leadership of %v\n", l.holder, l.name)
+	}
+}
+
+// insert the lease or take it over if it's ours or expired,
+// no row is returned if other instance hold it.
+const acquireQuery = `
+	INSERT INTO leader_leases (name, holder, acquired_at, expires_at)
+	VALUES ($1, $2, NOW(), NOW() + make_interval(secs => $3))
+	ON CONFLICT (name) DO UPDATE
+	SET holder = EXCLUDED.holder,
+		expires_at = EXCLUDED.expires_at,
+		acquired_at = CASE
+			WHEN leader_leases.holder = EXCLUDED.holder THEN leader_leases.acquired_at
+			ELSE NOW()
+		END
+	WHERE leader_leases.holder = EXCLUDED.holder OR leader_leases.expires_at < NOW()
+	RETURNING holder
+`
+
+func (l *Lease) acquire(ctx context.Context) (bool, error) {
+	var holder string
+	err := l.db.QueryRowxContext(ctx, acquireQuery, l.name, l.holder, l.ttl.Seconds()).Scan(&holder)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return holder == l.holder, nil
+}
+
+const releaseQuery = `
+	DELETE FROM leader_leases WHERE name = $1 AND holder = $2
+`
+
+func (l *Lease) release() error {
+	l.mu.Lock()
+	l.leader = false
+	l.mu.Unlock()
+
+	_, err := l.db.ExecContext(context.Background(), releaseQuery, l.name, l.holder)
+	if err != nil {
+		return fmt.Errorf("release lease: %v", err)
+	}
+	return nil
+}
+
+const leaderQuery = `
+	SELECT holder FROM leader_leases
+	WHERE name = $1 AND expires_at >= NOW()
+`
+
+// Leader return holder of the lease, empty if nobody hold it
+func (l *Lease) Leader() (string, error) {
+	return l.LeaderContext(context.Background())
+}
+
+// LeaderContext is Leader with context
+func (l *Lease) LeaderContext(ctx context.Context) (string, error) {
+	var holder string
+	err := l.db.QueryRowxContext(ctx, leaderQuery, l.name).Scan(&holder)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("lease leader: %v", err)
+	}
+	return holder, nil
+}
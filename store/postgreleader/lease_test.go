@@ -0,0 +1,104 @@
+package postgreleader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+func Test_lease(t *testing.T) {
+	db, err := sqlx.Connect("pgx", "host=localhost user=development password=credential dbname=development sslmode=disable")
+	if err != nil {
+		t.Fatal("open db conn:", err)
+	}
+	defer db.Close()
+
+	l1, err := New(db, Config{Name: "test", InstanceID: "instance-1", LeaseTTL: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.ExecContext(context.TODO(), `DROP TABLE IF EXISTS leader_leases`)
+
+	l2, err := New(db, Config{Name: "test", InstanceID: "instance-2", LeaseTTL: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l1.campaign(context.TODO())
+	l2.campaign(context.TODO())
+	if !l1.IsLeader() || l2.IsLeader() {
+		t.Fatalf("\ngot: %v/%v\nexpect: %v/%v", l1.IsLeader(), l2.IsLeader(), true, false)
+	}
+
+	leader, err := l2.Leader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader != "instance-1" {
+		t.Fatalf("\ngot: %v\nexpect: %v", leader, "instance-1")
+	}
+
+	// leader stop renewing, standby take over after lease expired
+	time.Sleep(1500 * time.Millisecond)
+	l2.campaign(context.TODO())
+	l1.campaign(context.TODO())
+	if l1.IsLeader() || !l2.IsLeader() {
+		t.Fatalf("\ngot: %v/%v\nexpect: %v/%v", l1.IsLeader(), l2.IsLeader(), false, true)
+	}
+
+	// released lease can be acquired right away
+	if err := l2.release(); err != nil {
+		t.Fatal(err)
+	}
+	l1.campaign(context.TODO())
+	if !l1.IsLeader() {
+		t.Fatalf("\ngot: %v\nexpect: %v", l1.IsLeader(), true)
+	}
+}
+
+func Test_lease_step_down(t *testing.T) {
+	ttl := 200 * time.Millisecond
+	var acquireErr error
+	l := &Lease{
+		name:   "test",
+		holder: "instance-1",
+		ttl:    ttl,
+		logger: log.New(io.Discard, "", 0),
+	}
+	l.acquireFn = func(ctx context.Context) (bool, error) { return acquireErr == nil, acquireErr }
+
+	l.campaign(context.TODO())
+	if !l.IsLeader() {
+		t.Fatalf("\ngot: %v\nexpect: %v", l.IsLeader(), true)
+	}
+
+	// database unreachable, the leader keep leading while the lease is surely valid
+	acquireErr = fmt.Errorf("connection refused")
+	l.campaign(context.TODO())
+	if !l.IsLeader() {
+		t.Fatalf("\ngot: %v\nexpect: %v", l.IsLeader(), true)
+	}
+
+	// and step down before the lease expire, so standby that take it over is the only leader
+	time.Sleep(ttl - ttl/leaseSafetyDivisor)
+	if l.IsLeader() {
+		t.Fatalf("\ngot: %v\nexpect: %v", l.IsLeader(), false)
+	}
+	l.campaign(context.TODO())
+	if l.IsLeader() || l.leader {
+		t.Fatalf("\ngot: %v\nexpect: %v", l.IsLeader(), false)
+	}
+
+	// renewed lease make it leader again
+	acquireErr = nil
+	l.campaign(context.TODO())
+	if !l.IsLeader() {
+		t.Fatalf("\ngot: %v\nexpect: %v", l.IsLeader(), true)
+	}
+}
@@ -0,0 +1,7 @@
+-- IF NOT EXISTS so database created before versioned migrations is adopted as it is
+CREATE TABLE IF NOT EXISTS leader_leases(
+	name text PRIMARY KEY,
+	holder text NOT NULL,
+	acquired_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL
+);
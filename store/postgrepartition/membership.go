@@ -0,0 +1,181 @@
+package postgrepartition
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/internal/migrate"
+	"github.com/odit-bit/invoker/partition"
+)
+
+var _ partition.Detector = (*Membership)(nil)
+
+// Membership is a partition.Detector backed by membership table.
+// every instance register itself and keep it's lease alive by heartbeat,
+// live instances ordered by join time and the position of instance
+// in that order is it's partition number.
+// when instance join, leave or lease expired the order change
+// and every instance observe the new assignment on the next PartitionInfo call.
+type Membership struct {
+	db *sqlx.DB
+
+	instanceID string
+	leaseTTL   time.Duration
+
+	logger *log.Logger
+}
+
+// Config encapsulates the settings for Membership
+type Config struct {
+	// unique name of this instance, if empty hostname + random suffix will be used
+	InstanceID string
+
+	// the lease expired if instance not heartbeat for this duration,
+	// default 30 second.
+	LeaseTTL time.Duration
+
+	Logger *log.Logger
+}
+
+func New(db *sqlx.DB, cfg Config) (*Membership, error) {
+	if cfg.InstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "instance"
+		}
+		cfg.InstanceID = fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	}
+	if cfg.LeaseTTL <= 0 {
+		cfg.LeaseTTL = 30 * time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stdout, "[partition]", log.Ldate|log.Ltime)
+	}
+
+	m := Membership{
+		db:         db,
+		instanceID: cfg.InstanceID,
+		leaseTTL:   cfg.LeaseTTL,
+		logger:     cfg.Logger,
+	}
+	if err := m.migrate(); err != nil {
+		return nil, fmt.Errorf("postgrepartition migrate: %v", err)
+	}
+	return &m, nil
+}
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations is the versioned schema of the membership store
+var Migrations = migrate.Source{Name: "partition", FS: migrationFiles, Dir: "migrations"}
+
+func (m *Membership) migrate() error {
+	return migrate.Up(context.TODO(), m.db, Migrations)
+}
+
+// InstanceID return the name of this instance in membership table
+func (m *Membership) InstanceID() string {
+	return m.instanceID
+}
+
+// Run keep the lease of this instance alive until ctx is done,
+// then the instance leave the membership so it's partition is rebalanced.
+func (m *Membership) Run(ctx context.Context) error {
+	m.logger.Printf("join membership instance: %v lease: %v\n", m.instanceID, m.leaseTTL)
+	if err := m.heartbeat(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(m.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return m.leave()
+		case <-ticker.C:
+			// a missed heartbeat is not fatal, the lease still valid until TTL
+			if err := m.heartbeat(ctx); err != nil {
+				m.logger.Printf("[WARN] heartbeat: %v\n", err)
+			}
+		}
+	}
+}
+
+// joined_at is kept when lease renewed, so the order is stable.
+// instance that re-join after it's lease expired get new joined_at.
+const heartbeatQuery = `
+	INSERT INTO partition_members (instance_id, joined_at, heartbeat_at, expires_at)
+	VALUES ($1, NOW(), NOW(), NOW() + make_interval(secs => $2))
+	ON CONFLICT (instance_id) DO UPDATE
+	SET heartbeat_at = NOW(),
+		expires_at = EXCLUDED.expires_at,
+		joined_at = CASE
+			WHEN partition_members.expires_at < NOW() THEN NOW()
+			ELSE partition_members.joined_at
+		END
+`
+
+const removeExpiredQuery = `
+	DELETE FROM partition_members WHERE expires_at < NOW()
+`
+
+func (m *Membership) heartbeat(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, heartbeatQuery, m.instanceID, m.leaseTTL.Seconds())
+	if err != nil {
+		return fmt.Errorf("renew lease: %v", err)
+	}
+
+	_, err = m.db.ExecContext(ctx, removeExpiredQuery)
+	if err != nil {
+		return fmt.Errorf("remove expired lease: %v", err)
+	}
+	return nil
+}
+
+const leaveQuery = `
+	DELETE FROM partition_members WHERE instance_id = $1
+`
+
+func (m *Membership) leave() error {
+	_, err := m.db.ExecContext(context.Background(), leaveQuery, m.instanceID)
+	if err != nil {
+		return fmt.Errorf("leave membership: %v", err)
+	}
+	m.logger.Printf("leave membership instance: %v\n", m.instanceID)
+	return nil
+}
+
+const partitionInfoQuery = `
+	SELECT partition, num_partitions FROM (
+		SELECT
+			instance_id,
+			ROW_NUMBER() OVER (ORDER BY joined_at, instance_id) - 1 AS partition,
+			COUNT(*) OVER () AS num_partitions
+		FROM partition_members
+		WHERE expires_at >= NOW()
+	) AS members
+	WHERE instance_id = $1
+`
+
+// PartitionInfo implements partition.Detector.
+func (m *Membership) PartitionInfo() (int, int, error) {
+	var cur, num int
+	err := m.db.QueryRowxContext(context.TODO(), partitionInfoQuery, m.instanceID).Scan(&cur, &num)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// not registered yet or the lease expired
+			return -1, -1, partition.ErrNoPartitionDataAvailableYet
+		}
+		return -1, -1, fmt.Errorf("partition detector: %w", err)
+	}
+	return cur, num, nil
+}
@@ -0,0 +1,68 @@
+package postgrepartition
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/odit-bit/invoker/partition"
+)
+
+func Test_membership(t *testing.T) {
+	db, err := sqlx.Connect("pgx", "host=localhost user=development password=credential dbname=development sslmode=disable")
+	if err != nil {
+		t.Fatal("open db conn:", err)
+	}
+	defer db.Close()
+
+	m1, err := New(db, Config{InstanceID: "instance-1", LeaseTTL: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.ExecContext(context.TODO(), `DROP TABLE IF EXISTS partition_members`)
+
+	// not registered yet
+	if _, _, err := m1.PartitionInfo(); err != partition.ErrNoPartitionDataAvailableYet {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, partition.ErrNoPartitionDataAvailableYet)
+	}
+
+	m2, err := New(db, Config{InstanceID: "instance-2", LeaseTTL: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m1.heartbeat(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.heartbeat(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	cur, num, err := m2.PartitionInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur != 1 || num != 2 {
+		t.Fatalf("\ngot: %v/%v\nexpect: %v/%v", cur, num, 1, 2)
+	}
+
+	// first instance leave, the second take over partition 0
+	if err := m1.leave(); err != nil {
+		t.Fatal(err)
+	}
+	cur, num, err = m2.PartitionInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur != 0 || num != 1 {
+		t.Fatalf("\ngot: %v/%v\nexpect: %v/%v", cur, num, 0, 1)
+	}
+
+	// lease expired
+	time.Sleep(1500 * time.Millisecond)
+	if _, _, err := m2.PartitionInfo(); err != partition.ErrNoPartitionDataAvailableYet {
+		t.Fatalf("\ngot: %v\nexpect: %v", err, partition.ErrNoPartitionDataAvailableYet)
+	}
+}
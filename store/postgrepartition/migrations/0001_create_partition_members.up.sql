@@ -0,0 +1,7 @@
+-- IF NOT EXISTS so database created before versioned migrations is adopted as it is
+CREATE TABLE IF NOT EXISTS partition_members(
+	instance_id text PRIMARY KEY,
+	joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	heartbeat_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,90 @@
+package index
+
+import "strings"
+
+// ClauseOp is the operator of Clause
+type ClauseOp uint8
+
+const (
+	// leaf match its Text by its Type
+	ClauseLeaf ClauseOp = iota
+
+	// every child must match
+	ClauseAnd
+
+	// one of the children must match
+	ClauseOr
+
+	// the only child must not match
+	ClauseNot
+)
+
+// maximum edit distance of fuzzy clause
+const MaxFuzziness = 2
+
+// Clause is a node of boolean query tree
+type Clause struct {
+	Op ClauseOp
+
+	// children of And, Or and Not
+	Clauses []*Clause
+
+	// type of leaf, QueryTypeMatch, QueryTypePhrase, QueryTypePrefix or QueryTypeFuzzy
+	Type QueryType
+	Text string
+
+	// maximum edit distance of fuzzy leaf, zero use 1
+	Fuzziness int
+}
+
+// Tree return the query as clause tree, it is nil for match and phrase query
+// since they are matched by the Expression as it is.
+func (q Query) Tree() *Clause {
+	switch q.Type {
+	case QueryTypePrefix, QueryTypeFuzzy:
+		return &Clause{Op: ClauseLeaf, Type: q.Type, Text: q.Expression, Fuzziness: q.Fuzziness}
+	case QueryTypeBoolean:
+		if q.Boolean == nil {
+			return &Clause{Op: ClauseAnd}
+		}
+		return q.Boolean
+	}
+	return nil
+}
+
+// EditDistance return fuzziness of the leaf limited to [1, MaxFuzziness]
+func (c *Clause) EditDistance() int {
+	switch {
+	case c.Fuzziness <= 0:
+		return 1
+	case c.Fuzziness > MaxFuzziness:
+		return MaxFuzziness
+	}
+	return c.Fuzziness
+}
+
+// Terms return the words of the leaves that are not negated,
+// wildcard characters are removed. it is used for relevance and highlight.
+func (c *Clause) Terms() []string {
+	var terms []string
+	var walk func(c *Clause)
+	walk = func(c *Clause) {
+		switch c.Op {
+		case ClauseNot:
+		case ClauseLeaf:
+			text := strings.NewReplacer("*", " ", "?", " ").Replace(c.Text)
+			terms = append(terms, strings.Fields(text)...)
+		default:
+			for _, child := range c.Clauses {
+				walk(child)
+			}
+		}
+	}
+	walk(c)
+	return terms
+}
+
+// IsWildcard report whether prefix leaf has wildcard other than the trailing *
+func (c *Clause) IsWildcard() bool {
+	return strings.ContainsAny(strings.TrimSuffix(c.Text, "*"), "*?")
+}
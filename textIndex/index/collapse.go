@@ -0,0 +1,25 @@
+package index
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Host is the lowercase host of the url without port, the key of Query.CollapseByHost.
+// url without host has empty host, every such document is a group of its own.
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// CollapseIterator is implemented by iterator that can collapse the result by host
+type CollapseIterator interface {
+	Iterator
+
+	// number of matched documents of the current document host including the collapsed one,
+	// it is zero unless Query.CollapseByHost is set.
+	HostCount() int
+}
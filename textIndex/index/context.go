@@ -0,0 +1,65 @@
+package index
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ContextIndexer is Indexer whose methods accept context,
+// indexer that talk to a database cancel the running query when ctx is done.
+type ContextIndexer interface {
+	IndexContext(ctx context.Context, doc *Document) error
+	IndexBatchContext(ctx context.Context, docs []*Document) error
+	LookupContext(ctx context.Context, linkID uuid.UUID) (*Document, error)
+	SearchContext(ctx context.Context, query Query) (Iterator, error)
+	UpdateScoreContext(ctx context.Context, linkID uuid.UUID, score float64) error
+}
+
+// WithContext return idx as ContextIndexer, indexer that not accept context
+// is wrapped so the call fail early when ctx is done but can not be cancelled once started.
+func WithContext(idx Indexer) ContextIndexer {
+	if ci, ok := idx.(ContextIndexer); ok {
+		return ci
+	}
+	return contextAdapter{idx: idx}
+}
+
+type contextAdapter struct {
+	idx Indexer
+}
+
+func (a contextAdapter) IndexContext(ctx context.Context, doc *Document) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.idx.Index(doc)
+}
+
+func (a contextAdapter) IndexBatchContext(ctx context.Context, docs []*Document) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.idx.IndexBatch(docs)
+}
+
+func (a contextAdapter) LookupContext(ctx context.Context, linkID uuid.UUID) (*Document, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.idx.Lookup(linkID)
+}
+
+func (a contextAdapter) SearchContext(ctx context.Context, query Query) (Iterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.idx.Search(query)
+}
+
+func (a contextAdapter) UpdateScoreContext(ctx context.Context, linkID uuid.UUID, score float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.idx.UpdateScore(linkID, score)
+}
@@ -0,0 +1,93 @@
+package index
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid search cursor")
+
+// Cursor is the position of document in search result,
+// the result is ordered by the ranking score then link ID, both descending.
+// the next page start at the first document after the cursor.
+type Cursor struct {
+	Score  float64
+	LinkID uuid.UUID
+
+	// reference time of the freshness factor, so every page is ranked the same
+	At time.Time
+
+	// documents of every host up to the cursor keyed by HostKey, for collapsed result
+	// whose store count the host rank itself. nil when it is not kept.
+	HostCounts map[uint64]int
+}
+
+// length of encoded cursor, score, uuid and unix nano
+const cursorSize = 8 + 16 + 8
+
+// length of encoded host count, key and count
+const hostCountSize = 8 + 1
+
+// MaxCursorHosts is the most hosts kept in the cursor so the token fit in url,
+// the store rescan the result from the best document without them.
+const MaxCursorHosts = 256
+
+// HostKey is the key of Cursor.HostCounts for the host returned by Host
+func HostKey(host string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(host))
+	return h.Sum64()
+}
+
+// String encode the cursor into opaque url-safe token,
+// the host counts are left out when there is more than MaxCursorHosts.
+func (c Cursor) String() string {
+	hosts := len(c.HostCounts)
+	if hosts > MaxCursorHosts {
+		hosts = 0
+	}
+	b := make([]byte, cursorSize, cursorSize+hosts*hostCountSize)
+	binary.BigEndian.PutUint64(b[0:8], math.Float64bits(c.Score))
+	copy(b[8:24], c.LinkID[:])
+	if !c.At.IsZero() {
+		binary.BigEndian.PutUint64(b[24:], uint64(c.At.UnixNano()))
+	}
+	if hosts > 0 {
+		var entry [hostCountSize]byte
+		for key, count := range c.HostCounts {
+			binary.BigEndian.PutUint64(entry[:8], key)
+			entry[8] = byte(min(count, math.MaxUint8))
+			b = append(b, entry[:]...)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decode token returned by Cursor.String
+func ParseCursor(token string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < cursorSize || (len(b)-cursorSize)%hostCountSize != 0 {
+		return Cursor{}, fmt.Errorf("%w: %q", ErrInvalidCursor, token)
+	}
+
+	var c Cursor
+	c.Score = math.Float64frombits(binary.BigEndian.Uint64(b[0:8]))
+	copy(c.LinkID[:], b[8:24])
+	if at := int64(binary.BigEndian.Uint64(b[24:])); at != 0 {
+		c.At = time.Unix(0, at).UTC()
+	}
+	if hosts := b[cursorSize:]; len(hosts) > 0 {
+		c.HostCounts = make(map[uint64]int, len(hosts)/hostCountSize)
+		for i := 0; i < len(hosts); i += hostCountSize {
+			c.HostCounts[binary.BigEndian.Uint64(hosts[i:i+8])] = int(hosts[i+8])
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,45 @@
+package index
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func Test_cursor(t *testing.T) {
+	c := Cursor{Score: 1.5e-3, LinkID: uuid.New(), At: time.Now().UTC()}
+	for _, c := range []Cursor{c, {LinkID: c.LinkID}} {
+		got, err := ParseCursor(c.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Score != c.Score || got.LinkID != c.LinkID || !got.At.Equal(c.At) {
+			t.Fatalf("\ngot:%+v\nexpect:%+v", got, c)
+		}
+	}
+
+	// the host counts are kept up to MaxCursorHosts
+	c.HostCounts = map[uint64]int{HostKey("go.dev"): 2, HostKey("pkg.go.dev"): 1}
+	got, err := ParseCursor(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.HostCounts) != 2 || got.HostCounts[HostKey("go.dev")] != 2 || got.HostCounts[HostKey("pkg.go.dev")] != 1 {
+		t.Fatalf("\ngot:%v\nexpect:%v", got.HostCounts, c.HostCounts)
+	}
+	many := Cursor{LinkID: c.LinkID, HostCounts: make(map[uint64]int)}
+	for i := 0; i <= MaxCursorHosts; i++ {
+		many.HostCounts[uint64(i)] = 1
+	}
+	if got, err := ParseCursor(many.String()); err != nil || got.HostCounts != nil {
+		t.Fatalf("\ngot:%v %v\nexpect no host counts", got.HostCounts, err)
+	}
+
+	for _, token := range []string{"", "!!", c.String()[:10], c.String()[:50]} {
+		if _, err := ParseCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("\ntoken:%q\ngot:%v\nexpect:%v", token, err, ErrInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package index
+
+import "fmt"
+
+// ErrNotFound returned when the document of the linkID is not indexed
+var ErrNotFound = fmt.Errorf("not found")
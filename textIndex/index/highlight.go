@@ -0,0 +1,65 @@
+package index
+
+import "strings"
+
+// the indexer mark the matched terms of the fragment text with these,
+// they are control characters that not appear in the text extracted from page.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// most fragments of a document, Query.HighlightLength is shared by them
+const MaxFragments = 3
+
+// Highlight is the best fragments of the document content for the query,
+// computed by the indexer so the terms matched by stemming are highlighted too.
+type Highlight struct {
+	// in content order, the beginning of the content when nothing matched
+	Fragments []Fragment
+}
+
+// Fragment is a plain text piece of the content
+type Fragment struct {
+	Text string
+
+	// byte offsets of the matched terms in Text, ordered and not overlapped
+	Matches []Span
+}
+
+// Span is the byte range [Start, End) of the text
+type Span struct {
+	Start int
+	End   int
+}
+
+// ParseFragment split the text marked with MatchStart and MatchEnd
+// into the plain text and the offsets of the marked terms.
+// unbalanced marker is dropped.
+func ParseFragment(marked string) Fragment {
+	var (
+		f     Fragment
+		sb    strings.Builder
+		start = -1
+	)
+	for len(marked) > 0 {
+		i := strings.IndexAny(marked, MatchStart+MatchEnd)
+		if i < 0 {
+			sb.WriteString(marked)
+			break
+		}
+		sb.WriteString(marked[:i])
+		switch marked[i : i+1] {
+		case MatchStart:
+			start = sb.Len()
+		case MatchEnd:
+			if start >= 0 && sb.Len() > start {
+				f.Matches = append(f.Matches, Span{Start: start, End: sb.Len()})
+			}
+			start = -1
+		}
+		marked = marked[i+1:]
+	}
+	f.Text = sb.String()
+	return f
+}
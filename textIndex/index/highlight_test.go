@@ -0,0 +1,24 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_ParseFragment(t *testing.T) {
+	tests := []struct {
+		marked string
+		expect Fragment
+	}{
+		{"plain text", Fragment{Text: "plain text"}},
+		{"a \x02gopher\x03 run", Fragment{Text: "a gopher run", Matches: []Span{{Start: 2, End: 8}}}},
+		{"\x02ünï\x03\x02code\x03", Fragment{Text: "ünïcode", Matches: []Span{{Start: 0, End: 5}, {Start: 5, End: 9}}}},
+		// unbalanced and empty marker
+		{"a\x03 b\x02\x03 \x02c", Fragment{Text: "a b c"}},
+	}
+	for _, test := range tests {
+		if got := ParseFragment(test.marked); fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Fatalf("\nmarked:%q\ngot:%v\nexpect:%v", test.marked, got, test.expect)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package index
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,12 +28,19 @@ type Document struct {
 
 	//pageracnk score by pagerank calculator
 	PageRank float64
+
+	// primary language subtag of the document (ex: "en"), empty if unknown
+	Language string
 }
 
 type Indexer interface {
 	// index will insert or update the index entry (doc)
 	Index(doc *Document) error
 
+	// IndexBatch is the bulk version of Index,
+	// all documents will be inserted or updated as one unit of write.
+	IndexBatch(docs []*Document) error
+
 	// Perform a lookup for a document by its ID
 	Lookup(linkID uuid.UUID) (*Document, error)
 
@@ -53,7 +61,17 @@ type Iterator interface {
 
 	Document() *Document
 
+	// number of matched documents, it may be estimated when Query.EstimateCount is set
 	TotalCount() uint64
+
+	// Cursor return the position of the last document returned by Next,
+	// pass it as Query.Cursor to get the documents after it.
+	// it is empty before the first document.
+	Cursor() string
+
+	// Highlight return the fragments of the current document content that matched the query,
+	// it is nil unless Query.HighlightLength is set.
+	Highlight() *Highlight
 }
 
 // determine query type that support by indexer
@@ -62,6 +80,15 @@ type QueryType uint8
 const (
 	QueryTypeMatch = iota
 	QueryTypePhrase
+
+	// Expression is single term with wildcard, "go*" or "g?pher"
+	QueryTypePrefix
+
+	// Expression terms are matched within edit distance of Query.Fuzziness
+	QueryTypeFuzzy
+
+	// documents matched by Query.Boolean, Expression is not used
+	QueryTypeBoolean
 )
 
 type Query struct {
@@ -72,6 +99,88 @@ type Query struct {
 	// entered by the end user.
 	Expression string
 
-	// number of search document skipped
+	// number of search document skipped,
+	// not used when Cursor is set
 	Offset uint64
+
+	// return documents after the position returned by Iterator.Cursor,
+	// it stay fast on deep pages unlike Offset.
+	Cursor string
+
+	// maximum documents returned by the iterator, zero use the page size of the store
+	Limit int
+
+	// allow the total count of large result to be estimated instead of counted
+	EstimateCount bool
+
+	// weights of the result score, nil use DefaultRanking
+	Ranking *Ranking
+
+	// iterator explain the score of each document, see ExplainIterator
+	Explain bool
+
+	// about this many characters of highlighted fragments is returned for each document,
+	// see Iterator.Highlight. Document.Content may be left empty when it is set.
+	HighlightLength int
+
+	// only the best this many documents of each host is returned, across all pages,
+	// see CollapseIterator. TotalCount is the count before collapsing, zero is not collapsed.
+	CollapseByHost int
+
+	// maximum edit distance of QueryTypeFuzzy, zero use 1
+	Fuzziness int
+
+	// tree of QueryTypeBoolean
+	Boolean *Clause
+
+	// documents must contain every phrase, in addition to the expression
+	Phrases []string
+
+	// documents must not contain any of these, each one can be a term or a phrase
+	Exclude []string
+
+	Filters Filters
+}
+
+// Filters narrow down documents matched by the query,
+// zero value of each field filter nothing.
+type Filters struct {
+	// host of the document url, its subdomains are matched too
+	// so "go.dev" match "go.dev" and "pkg.go.dev"
+	Site string
+
+	// substring of the document url
+	InURL string
+
+	// terms that all must appear in the title
+	InTitle string
+
+	// IndexedAt range, After is inclusive and Before is exclusive
+	IndexedAfter  time.Time
+	IndexedBefore time.Time
+
+	// primary language subtag of the document
+	Language string
+}
+
+// IsZero report whether there is no filter
+func (f Filters) IsZero() bool {
+	return f.Site == "" && f.InURL == "" && f.InTitle == "" &&
+		f.IndexedAfter.IsZero() && f.IndexedBefore.IsZero() && f.Language == ""
+}
+
+// NormalizeLanguage return the lowercase primary subtag of language tag,
+// "en-US" become "en". it return empty string for malformed tag.
+func NormalizeLanguage(tag string) string {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	if len(primary) < 2 || len(primary) > 8 {
+		return ""
+	}
+	for _, r := range primary {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return ""
+		}
+	}
+	return strings.ToLower(primary)
 }
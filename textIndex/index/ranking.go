@@ -0,0 +1,151 @@
+package index
+
+import (
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Ranking is the weights of the factors blended into the score of search result.
+// every factor is normalized into [0,1] before weighted,
+// so the score is between 0 and the sum of the weights.
+type Ranking struct {
+	// text relevance of the expression, the raw relevance is specific to the indexer
+	Relevance float64
+
+	// pagerank score of the document
+	PageRank float64
+
+	// how recent the document is indexed
+	Freshness float64
+
+	// every term of the expression appear in the title
+	TitleMatch float64
+
+	// url with fewer path segments is preferred
+	URLDepth float64
+
+	// pagerank normalized into 0.5, pagerank of large graph is small number.
+	// zero use the default
+	PageRankPivot float64
+
+	// age of the document when its freshness fall into 0.5, zero use the default
+	FreshnessHalfLife time.Duration
+}
+
+// DefaultRanking favor text relevance and pagerank equally
+func DefaultRanking() Ranking {
+	return Ranking{
+		Relevance:         1,
+		PageRank:          1,
+		Freshness:         0.2,
+		TitleMatch:        0.3,
+		URLDepth:          0.1,
+		PageRankPivot:     0.001,
+		FreshnessHalfLife: 30 * 24 * time.Hour,
+	}
+}
+
+// WithDefaults return the ranking with zero pivot and half-life replaced by the default
+func (r Ranking) WithDefaults() Ranking {
+	def := DefaultRanking()
+	if r.PageRankPivot <= 0 {
+		r.PageRankPivot = def.PageRankPivot
+	}
+	if r.FreshnessHalfLife <= 0 {
+		r.FreshnessHalfLife = def.FreshnessHalfLife
+	}
+	return r
+}
+
+// RankingOf return the ranking of the query with the defaults applied
+func RankingOf(q Query) Ranking {
+	if q.Ranking == nil {
+		return DefaultRanking()
+	}
+	return q.Ranking.WithDefaults()
+}
+
+// RankFactors is the raw value of the factors of one document
+type RankFactors struct {
+	Relevance  float64
+	PageRank   float64
+	IndexedAt  time.Time
+	TitleMatch bool
+	URLDepth   int
+}
+
+// Explanation is the score breakdown of search result,
+// the factors are normalized but not weighted.
+type Explanation struct {
+	Relevance  float64
+	PageRank   float64
+	Freshness  float64
+	TitleMatch float64
+	URLDepth   float64
+
+	// weighted sum of the factors
+	Score float64
+}
+
+// Explain normalize the factors and blend them into the score,
+// freshness is measured at now.
+// the indexer that compute the score itself must use the same normalization.
+func (r Ranking) Explain(f RankFactors, now time.Time) *Explanation {
+	r = r.WithDefaults()
+
+	e := Explanation{
+		Relevance: saturate(f.Relevance, 1),
+		PageRank:  saturate(f.PageRank, r.PageRankPivot),
+		URLDepth:  1 / float64(1+f.URLDepth),
+	}
+	if f.TitleMatch {
+		e.TitleMatch = 1
+	}
+	age := now.Sub(f.IndexedAt)
+	if age < 0 {
+		age = 0
+	}
+	e.Freshness = math.Pow(0.5, age.Seconds()/r.FreshnessHalfLife.Seconds())
+
+	e.Score = r.Relevance*e.Relevance +
+		r.PageRank*e.PageRank +
+		r.Freshness*e.Freshness +
+		r.TitleMatch*e.TitleMatch +
+		r.URLDepth*e.URLDepth
+	return &e
+}
+
+// x / (x + pivot), 0.5 at the pivot and approach 1 for large x
+func saturate(x, pivot float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return x / (x + pivot)
+}
+
+// URLDepth is the number of non-empty path segments of the url,
+// url without host has zero depth.
+func URLDepth(rawURL string) int {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return 0
+	}
+	depth := 0
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
+// ExplainIterator is implemented by iterator that can explain the score of its documents
+type ExplainIterator interface {
+	Iterator
+
+	// score breakdown of the current document,
+	// it is nil unless Query.Explain is set.
+	Explain() *Explanation
+}
@@ -0,0 +1,48 @@
+package index
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_ranking_explain(t *testing.T) {
+	now := time.Now()
+	r := Ranking{Relevance: 1, PageRank: 2, Freshness: 1, TitleMatch: 0.5, URLDepth: 1, PageRankPivot: 0.1, FreshnessHalfLife: time.Hour}
+	e := r.Explain(RankFactors{
+		Relevance:  1,
+		PageRank:   0.1,
+		IndexedAt:  now.Add(-time.Hour),
+		TitleMatch: true,
+		URLDepth:   3,
+	}, now)
+
+	expect := Explanation{Relevance: 0.5, PageRank: 0.5, Freshness: 0.5, TitleMatch: 1, URLDepth: 0.25}
+	expect.Score = 0.5 + 2*0.5 + 0.5 + 0.5*1 + 0.25
+	if math.Abs(e.Score-expect.Score) > 1e-9 || math.Abs(e.Freshness-expect.Freshness) > 1e-9 ||
+		e.Relevance != expect.Relevance || e.PageRank != expect.PageRank || e.TitleMatch != expect.TitleMatch || e.URLDepth != expect.URLDepth {
+		t.Fatalf("\ngot:%+v\nexpect:%+v", *e, expect)
+	}
+
+	// text relevance no longer only break the tie of pagerank
+	popular := DefaultRanking().Explain(RankFactors{Relevance: 0.01, PageRank: 0.01, IndexedAt: now}, now)
+	relevant := DefaultRanking().Explain(RankFactors{Relevance: 2, PageRank: 0.0005, IndexedAt: now, TitleMatch: true}, now)
+	if relevant.Score <= popular.Score {
+		t.Fatalf("\ngot:%v\nexpect greater than:%v", relevant.Score, popular.Score)
+	}
+}
+
+func Test_url_depth(t *testing.T) {
+	tests := map[string]int{
+		"https://go.dev":             0,
+		"https://go.dev/":            0,
+		"https://go.dev/doc//faq/":   2,
+		"https://go.dev/a/b/c?x=/y/": 3,
+		"www.example.com/a":          0,
+	}
+	for u, expect := range tests {
+		if got := URLDepth(u); got != expect {
+			t.Fatalf("\nurl:%v\ngot:%v\nexpect:%v", u, got, expect)
+		}
+	}
+}
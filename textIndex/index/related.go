@@ -0,0 +1,20 @@
+package index
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// number of the best weighted terms of a document kept for finding its related documents
+const RelatedTerms = 25
+
+// RelatedIndexer is implemented by indexer that can find the documents similar to a document.
+// the top terms of every document weighted by tf-idf are stored at index time,
+// the related documents are the ones that match those terms.
+type RelatedIndexer interface {
+	// Related return at most n documents similar to the document of linkID, most similar first.
+	// the document itself is excluded, ErrNotFound if the document is not indexed.
+	Related(linkID uuid.UUID, n int) ([]*Document, error)
+	RelatedContext(ctx context.Context, linkID uuid.UUID, n int) ([]*Document, error)
+}
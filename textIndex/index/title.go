@@ -0,0 +1,11 @@
+package index
+
+import "context"
+
+// TitleIndexer is implemented by indexer that can list the titles of its documents
+// without paging through the search result.
+type TitleIndexer interface {
+	// Titles call fn with the title and pagerank score of every document that has title, in no particular order.
+	// it stop at the first error returned by fn.
+	Titles(ctx context.Context, fn func(title string, pageRank float64) error) error
+}
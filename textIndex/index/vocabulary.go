@@ -0,0 +1,11 @@
+package index
+
+import "context"
+
+// VocabularyIndexer is implemented by indexer that can list the words of its documents,
+// the words are lowercase but not stemmed so they can be shown to the user.
+type VocabularyIndexer interface {
+	// Vocabulary call fn with every distinct word and the number of documents contain it,
+	// it stop at the first error returned by fn.
+	Vocabulary(ctx context.Context, fn func(word string, docCount int) error) error
+}
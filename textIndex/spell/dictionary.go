@@ -0,0 +1,159 @@
+package spell
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// candidate further than this many edits is not a correction
+	maxEditDistance = 2
+
+	// only this many leading runes generate the delete variants,
+	// longer word is compared by distance after its prefix matched
+	prefixLength = 7
+
+	// shorter word has too many neighbours, "go" is not a typo of "to"
+	minWordLength = 3
+
+	// longer word is an id or hash rather than a word
+	maxWordLength = 24
+)
+
+// dictionary is symmetric delete spelling dictionary (SymSpell),
+// every word and query is reduced into its variants with up to maxEditDistance runes deleted.
+// word that share a variant with the query is a candidate, so lookup need no scan.
+// it is immutable after built so it is read without lock.
+type dictionary struct {
+	// document count of the words
+	words map[string]int
+
+	// delete variants of the prefix of words into the words
+	deletes map[string][]string
+}
+
+func newDictionary() *dictionary {
+	return &dictionary{
+		words:   make(map[string]int),
+		deletes: make(map[string][]string),
+	}
+}
+
+func (d *dictionary) add(word string, docCount int) {
+	if _, ok := d.words[word]; ok {
+		d.words[word] += docCount
+		return
+	}
+	d.words[word] = docCount
+	for variant := range deleteVariants(prefix(word)) {
+		d.deletes[variant] = append(d.deletes[variant], word)
+	}
+}
+
+// the closest word of the dictionary, the one in most documents break the tie.
+// known word is returned as is, ok is false if no word within maxEditDistance.
+func (d *dictionary) lookup(word string) (string, bool) {
+	if _, ok := d.words[word]; ok {
+		return word, true
+	}
+
+	var (
+		best      string
+		bestDist  = maxEditDistance + 1
+		bestCount int
+	)
+	input := []rune(word)
+	for variant := range deleteVariants(prefix(word)) {
+		for _, candidate := range d.deletes[variant] {
+			dist := editDistance(input, []rune(candidate))
+			count := d.words[candidate]
+			if dist < bestDist || (dist == bestDist && (count > bestCount || (count == bestCount && candidate < best))) {
+				best, bestDist, bestCount = candidate, dist, count
+			}
+		}
+	}
+	return best, bestDist <= maxEditDistance
+}
+
+// word that is worth to be in the dictionary or corrected
+func isWord(s string) bool {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+		n++
+	}
+	return n >= minWordLength && n <= maxWordLength
+}
+
+func prefix(word string) string {
+	n := 0
+	for i := range word {
+		if n == prefixLength {
+			return word[:i]
+		}
+		n++
+	}
+	return word
+}
+
+// word itself and every string made by deleting up to maxEditDistance runes of it
+func deleteVariants(word string) map[string]struct{} {
+	variants := map[string]struct{}{word: {}}
+	frontier := []string{word}
+	for dist := 0; dist < maxEditDistance; dist++ {
+		var next []string
+		for _, w := range frontier {
+			runes := []rune(w)
+			if len(runes) <= 1 {
+				continue
+			}
+			for i := range runes {
+				variant := string(runes[:i]) + string(runes[i+1:])
+				if _, ok := variants[variant]; !ok {
+					variants[variant] = struct{}{}
+					next = append(next, variant)
+				}
+			}
+		}
+		frontier = next
+	}
+	return variants
+}
+
+// optimal string alignment distance, transposed adjacent runes is one edit
+func editDistance(a, b []rune) int {
+	if diff := len(a) - len(b); diff > maxEditDistance || -diff > maxEditDistance {
+		return maxEditDistance + 1
+	}
+
+	// rows of the previous two and the current
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// lowercase the letters only token of the text, other token is not corrected
+func correctable(token string) (string, bool) {
+	lower := strings.ToLower(token)
+	return lower, isWord(lower)
+}
@@ -0,0 +1,133 @@
+package spell
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+// word in fewer documents may be a typo itself
+const defaultMinDocCount = 2
+
+// Config encapsulates the settings for the spelling correction service
+type Config struct {
+	// the vocabulary of indexed documents is the dictionary
+	IndexAPI index.VocabularyIndexer
+
+	// time between subsequent rebuild of the dictionary
+	Interval time.Duration
+
+	// word that appear in fewer documents is not in the dictionary,
+	// zero use the default
+	MinDocCount int
+
+	Logger *log.Logger
+}
+
+func (cfg *Config) validate() error {
+	if cfg.IndexAPI == nil {
+		return fmt.Errorf("index API has not been provided")
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("invalid value for interval")
+	}
+	if cfg.MinDocCount <= 0 {
+		cfg.MinDocCount = defaultMinDocCount
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stdout, "[spell]", log.Ldate|log.Ltime)
+	}
+	return nil
+}
+
+// Service correct misspelled query from the vocabulary of the index,
+// the dictionary live in memory and rebuilt every interval.
+type Service struct {
+	cfg Config
+
+	mu   sync.RWMutex
+	dict *dictionary
+}
+
+func New(cfg Config) (*Service, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("spell: config validation failed: %w", err)
+	}
+	return &Service{cfg: cfg, dict: newDictionary()}, nil
+}
+
+// Name implements service.Service
+func (svc *Service) Name() string { return "spell" }
+
+// Run implements service.Service
+func (svc *Service) Run(ctx context.Context) error {
+	svc.cfg.Logger.Printf("rebuild interval: %v\n", svc.cfg.Interval)
+	ticker := time.NewTicker(svc.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := svc.rebuild(ctx); err != nil {
+			// keep the previous dictionary, try again next pass
+			svc.cfg.Logger.Printf("[ERROR] %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Correct replace every unknown word of the text with the closest known word,
+// the text is returned unchanged if nothing is corrected.
+// only letters token is corrected, so search operator and quoted phrase are kept.
+func (svc *Service) Correct(text string) string {
+	svc.mu.RLock()
+	dict := svc.dict
+	svc.mu.RUnlock()
+
+	tokens := strings.Fields(text)
+	corrected := false
+	for i, token := range tokens {
+		word, ok := correctable(token)
+		if !ok {
+			continue
+		}
+		if correction, ok := dict.lookup(word); ok && correction != word {
+			tokens[i] = correction
+			corrected = true
+		}
+	}
+	if !corrected {
+		return text
+	}
+	return strings.Join(tokens, " ")
+}
+
+// build new dictionary from the vocabulary and swap it in
+func (svc *Service) rebuild(ctx context.Context) error {
+	dict := newDictionary()
+	err := svc.cfg.IndexAPI.Vocabulary(ctx, func(word string, docCount int) error {
+		word = strings.ToLower(word)
+		if docCount >= svc.cfg.MinDocCount && isWord(word) {
+			dict.add(word, docCount)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("spell: read vocabulary: %v", err)
+	}
+
+	svc.mu.Lock()
+	svc.dict = dict
+	svc.mu.Unlock()
+
+	svc.cfg.Logger.Printf("[INFO] indexed %v words\n", len(dict.words))
+	return nil
+}
@@ -0,0 +1,90 @@
+package spell
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+type vocabulary map[string]int
+
+func (v vocabulary) Vocabulary(_ context.Context, fn func(word string, docCount int) error) error {
+	for word, count := range v {
+		if err := fn(word, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_correct(t *testing.T) {
+	svc, err := New(Config{
+		IndexAPI: vocabulary{
+			"gopher":               40,
+			"golang":               30,
+			"programming":          20,
+			"language":             25,
+			"languages":            3,
+			"server":               10,
+			"serve":                12,
+			"internationalization": 5,
+			"typo":                 1,
+			"1234":                 9,
+		},
+		Interval: time.Hour,
+		Logger:   log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.rebuild(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text   string
+		expect string
+	}{
+		{"gopher", "gopher"},
+		{"Gopher", "Gopher"},
+		{"gophre", "gopher"},
+		{"goher", "gopher"},
+		{"GOLNAG programing", "golang programming"},
+		{"langauge", "language"},
+		{"servr", "serve"},
+		{"internationalizaton", "internationalization"},
+		{"internatoinalization", "internationalization"},
+		{"typos", "typos"},
+		{"qwerty", "qwerty"},
+		{"site:gophr.dev gophr", "site:gophr.dev gopher"},
+		{`"gophr" -gophr`, `"gophr" -gophr`},
+		{"go", "go"},
+	}
+	for _, test := range tests {
+		if got := svc.Correct(test.text); got != test.expect {
+			t.Fatalf("\ntext:%v\ngot:%v\nexpect:%v", test.text, got, test.expect)
+		}
+	}
+}
+
+func Test_editDistance(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect int
+	}{
+		{"gopher", "gopher", 0},
+		{"gopher", "gophre", 1},
+		{"gopher", "goher", 1},
+		{"gopher", "gopherr", 1},
+		{"gopher", "gphre", 2},
+		{"gopher", "go", maxEditDistance + 1},
+		{"über", "uber", 1},
+	}
+	for _, test := range tests {
+		if got := editDistance([]rune(test.a), []rune(test.b)); got != test.expect {
+			t.Fatalf("\n%v %v\ngot:%v\nexpect:%v", test.a, test.b, got, test.expect)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package memory
 
 import (
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/numeric"
 	"github.com/blevesearch/bleve/v2/search/query"
 	"github.com/google/uuid"
 	"github.com/odit-bit/invoker/textIndex/index"
@@ -91,6 +93,49 @@ func (bm *bleveMemory) Index(inputDoc *index.Document) error {
 	return nil
 }
 
+// IndexBatch implements index.Indexer.
+func (bm *bleveMemory) IndexBatch(inputDocs []*index.Document) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	batch := bm.idx.NewBatch()
+	dCopies := make([]*index.Document, 0, len(inputDocs))
+	for _, inputDoc := range inputDocs {
+		if inputDoc.LinkID == uuid.Nil {
+			return fmt.Errorf("missing doc Link ID")
+		}
+
+		inputDoc.IndexedAt = time.Now()
+		dCopy := new(index.Document)
+		*dCopy = *inputDoc
+
+		key := dCopy.LinkID.String()
+		// preserve existing PageRank score
+		if origin, ok := bm.docs[key]; ok {
+			dCopy.PageRank = origin.PageRank
+		}
+
+		err := batch.Index(key, bleveDoc{
+			Title:    dCopy.Title,
+			Content:  dCopy.Content,
+			PageRank: dCopy.PageRank,
+		})
+		if err != nil {
+			return err
+		}
+		dCopies = append(dCopies, dCopy)
+	}
+
+	if err := bm.idx.Batch(batch); err != nil {
+		return err
+	}
+
+	for _, dCopy := range dCopies {
+		bm.docs[dCopy.LinkID.String()] = dCopy
+	}
+	return nil
+}
+
 // Lookup implements index.Indexer.
 func (bm *bleveMemory) Lookup(linkID uuid.UUID) (*index.Document, error) {
 	return bm.lookupUUIDString(linkID.String())
@@ -106,6 +151,16 @@ func (bm *bleveMemory) lookupUUIDString(linkID string) (*index.Document, error)
 
 // Search implements index.Indexer.
 func (bm *bleveMemory) Search(q index.Query) (index.Iterator, error) {
+	if !q.Filters.IsZero() || len(q.Phrases) > 0 || len(q.Exclude) > 0 {
+		return nil, fmt.Errorf("index search: filters not supported by in-memory indexer")
+	}
+	if q.CollapseByHost > 0 {
+		return nil, fmt.Errorf("index search: collapse by host not supported by in-memory indexer")
+	}
+	if q.Tree() != nil {
+		return nil, fmt.Errorf("index search: query type %v not supported by in-memory indexer", q.Type)
+	}
+
 	var query query.Query
 	switch q.Type {
 	case index.QueryTypePhrase:
@@ -115,9 +170,32 @@ func (bm *bleveMemory) Search(q index.Query) (index.Iterator, error) {
 	}
 
 	sr := bleve.NewSearchRequest(query)
-	sr.SortBy([]string{"PageRank", "-_score"})
+	sr.SortBy([]string{"PageRank", "-_score", "-_id"})
 	sr.Size = bacthSize
+	if q.Limit > 0 {
+		sr.Size = q.Limit
+	}
 	sr.From = int(q.Offset)
+	if q.Cursor != "" {
+		after, err := index.ParseCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		// the result is not ordered by ranking score,
+		// the score of the cursor is the relevance and pagerank is taken from the document
+		bm.mu.RLock()
+		doc, ok := bm.docs[after.LinkID.String()]
+		bm.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown document %v", index.ErrInvalidCursor, after.LinkID)
+		}
+		sr.From = 0
+		sr.SearchAfter = []string{
+			string(numeric.MustNewPrefixCodedInt64(numeric.Float64ToInt64(doc.PageRank), 0)),
+			strconv.FormatFloat(after.Score, 'g', -1, 64),
+			after.LinkID.String(),
+		}
+	}
 
 	rs, err := bm.idx.Search(sr)
 
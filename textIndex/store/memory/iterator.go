@@ -1,7 +1,10 @@
 package memory
 
 import (
+	"strconv"
+
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
 	"github.com/odit-bit/invoker/textIndex/index"
 )
 
@@ -17,6 +20,8 @@ type IndexIterator struct {
 	lastDoc *index.Document
 	// last error if any
 	lastErr error
+	// relevance score of lastDoc
+	lastScore float64
 
 	// provide information about both the total number of matched results and
 	// the number of documents in the current result batch.
@@ -73,27 +78,55 @@ func (it *IndexIterator) Next() bool {
 	// indicate need to fecthed the next batch ??
 	if it.resultIdx >= it.result.Hits.Len() {
 		//request doc per page size
-		it.request.From += it.request.Size
+		if it.request.SearchAfter != nil {
+			// paging by cursor continue after the last hit
+			it.request.SearchAfter = afterHit(it.result.Hits[len(it.result.Hits)-1])
+		} else {
+			it.request.From += it.request.Size
+		}
 		if it.result, it.lastErr = it.store.idx.Search(it.request); it.lastErr != nil {
 			return false
 		}
+		if it.result.Hits.Len() == 0 {
+			return false
+		}
 
 		// reset current page idx
 		it.resultIdx = 0
 	}
 
 	// get next id of doc from result
-	nextID := it.result.Hits[it.resultIdx].ID
-	it.lastDoc, it.lastErr = it.store.lookupUUIDString(nextID)
+	hit := it.result.Hits[it.resultIdx]
+	it.lastDoc, it.lastErr = it.store.lookupUUIDString(hit.ID)
 	if it.lastErr != nil {
 		return false
 	}
+	it.lastScore = hit.Score
 
 	it.resultIdx++
 	it.globalIdx++
 	return true
 }
 
+// Cursor implements index.Iterator.
+func (it *IndexIterator) Cursor() string {
+	if it.lastDoc == nil {
+		return ""
+	}
+	return index.Cursor{Score: it.lastScore, LinkID: it.lastDoc.LinkID}.String()
+}
+
+// Highlight implements index.Iterator.
+// highlight is not supported by in-memory indexer, the content is always returned.
+func (it *IndexIterator) Highlight() *index.Highlight {
+	return nil
+}
+
+// sort values of the hit, score sort value is placeholder so take the hit score
+func afterHit(hit *search.DocumentMatch) []string {
+	return []string{hit.Sort[0], strconv.FormatFloat(hit.Score, 'g', -1, 64), hit.ID}
+}
+
 // TotalCount implements index.Iterator.
 func (it *IndexIterator) TotalCount() uint64 {
 	if it.result == nil {
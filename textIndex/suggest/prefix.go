@@ -0,0 +1,122 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// most suggestion returned for one prefix
+	maxSuggestions = 10
+
+	// prefix up to this many runes has its best suggestions precomputed,
+	// it match too many entries to rank on every keystroke.
+	shortPrefix = 3
+
+	// longer text is cut, suggestion is for the search box
+	maxTextLength = 100
+)
+
+type entry struct {
+	text  string
+	score float64
+}
+
+// prefixIndex is immutable after built so it is read without lock.
+// the entries of a prefix are a contiguous range of the sorted entries,
+// the range of long prefix is small enough to be ranked when looked up.
+type prefixIndex struct {
+	// sorted by text
+	entries []entry
+
+	// best entries of the short prefixes, highest score first
+	top map[string][]int
+}
+
+func buildPrefixIndex(scores map[string]float64) *prefixIndex {
+	idx := &prefixIndex{
+		entries: make([]entry, 0, len(scores)),
+		top:     make(map[string][]int),
+	}
+	for text, score := range scores {
+		idx.entries = append(idx.entries, entry{text: text, score: score})
+	}
+	sort.Slice(idx.entries, func(i, j int) bool { return idx.entries[i].text < idx.entries[j].text })
+
+	for i, e := range idx.entries {
+		n := 0
+		for end := range e.text {
+			if n > 0 {
+				idx.top[e.text[:end]] = idx.insertBest(idx.top[e.text[:end]], i, maxSuggestions)
+			}
+			if n++; n > shortPrefix {
+				break
+			}
+		}
+		if n <= shortPrefix {
+			idx.top[e.text] = idx.insertBest(idx.top[e.text], i, maxSuggestions)
+		}
+	}
+	return idx
+}
+
+// the best n text start with the normalized prefix
+func (idx *prefixIndex) lookup(prefix string, n int) []string {
+	if prefix == "" || n <= 0 {
+		return nil
+	}
+	if n > maxSuggestions {
+		n = maxSuggestions
+	}
+
+	var best []int
+	if utf8.RuneCountInString(prefix) <= shortPrefix {
+		best = idx.top[prefix]
+	} else {
+		start := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].text >= prefix })
+		for i := start; i < len(idx.entries) && strings.HasPrefix(idx.entries[i].text, prefix); i++ {
+			best = idx.insertBest(best, i, n)
+		}
+	}
+	if len(best) > n {
+		best = best[:n]
+	}
+
+	texts := make([]string, len(best))
+	for i, e := range best {
+		texts[i] = idx.entries[e].text
+	}
+	return texts
+}
+
+// insert entry i into the list ordered by score, the list is kept at most n long
+func (idx *prefixIndex) insertBest(best []int, i, n int) []int {
+	pos := sort.Search(len(best), func(j int) bool { return idx.entries[best[j]].score < idx.entries[i].score })
+	if pos >= n {
+		return best
+	}
+	if len(best) < n {
+		best = append(best, 0)
+	}
+	copy(best[pos+1:], best[pos:])
+	best[pos] = i
+	return best
+}
+
+// lowercase and collapse the spaces, so the typed prefix match regardless of case
+func normalize(s string) string {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if len(s) > maxTextLength {
+		// cut at rune boundary
+		end := 0
+		for i := range s {
+			if i > maxTextLength {
+				break
+			}
+			end = i
+		}
+		s = strings.TrimSpace(s[:end])
+	}
+	return s
+}
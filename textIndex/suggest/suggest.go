@@ -0,0 +1,188 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+const (
+	// distinct recorded queries kept between rebuild
+	defaultMaxQueries = 10000
+
+	// query searched this many times weight the same as title at the pagerank pivot
+	queryCountPivot = 10
+
+	// recorded query is suggested after it is searched this many times
+	defaultMinQueryCount = 3
+)
+
+// Config encapsulates the settings for the suggestion service
+type Config struct {
+	// titles of every indexed document are suggested
+	IndexAPI index.TitleIndexer
+
+	// time between subsequent rebuild of the prefix index
+	Interval time.Duration
+
+	// pagerank of the title normalized into 0.5, zero use the pivot of index.DefaultRanking
+	PageRankPivot float64
+
+	// number of distinct recorded queries kept, new query is ignored once the limit is reached.
+	// nothing is dropped by the limit, the count fade on every rebuild
+	// and query that fade to zero make room for the new one. zero use the default
+	MaxQueries int
+
+	// recorded query is suggested only after it is counted this many times (before fading),
+	// so query searched once by single user is not shown to others. zero use the default
+	MinQueryCount int
+
+	Logger *log.Logger
+}
+
+func (cfg *Config) validate() error {
+	if cfg.IndexAPI == nil {
+		return fmt.Errorf("index API has not been provided")
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("invalid value for interval")
+	}
+	if cfg.PageRankPivot <= 0 {
+		cfg.PageRankPivot = index.DefaultRanking().PageRankPivot
+	}
+	if cfg.MaxQueries <= 0 {
+		cfg.MaxQueries = defaultMaxQueries
+	}
+	if cfg.MinQueryCount <= 0 {
+		cfg.MinQueryCount = defaultMinQueryCount
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.New(os.Stdout, "[suggest]", log.Ldate|log.Ltime)
+	}
+	return nil
+}
+
+// Service suggest completion of partially typed query from the document titles
+// and popular past queries. suggestion is served from in-memory prefix index
+// that rebuilt every interval, query recorded in the meantime is counted on the next rebuild.
+type Service struct {
+	cfg Config
+
+	mu  sync.RWMutex
+	idx *prefixIndex
+
+	// search count of recorded queries, halved every rebuild so old popularity fade
+	queryMu sync.Mutex
+	queries map[string]int
+}
+
+func New(cfg Config) (*Service, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("suggest: config validation failed: %w", err)
+	}
+	return &Service{
+		cfg:     cfg,
+		idx:     buildPrefixIndex(nil),
+		queries: make(map[string]int),
+	}, nil
+}
+
+// Name implements service.Service
+func (svc *Service) Name() string { return "suggest" }
+
+// Run implements service.Service
+func (svc *Service) Run(ctx context.Context) error {
+	svc.cfg.Logger.Printf("rebuild interval: %v\n", svc.cfg.Interval)
+	ticker := time.NewTicker(svc.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if err := svc.rebuild(ctx); err != nil {
+			// keep serving the previous index, try again next pass
+			svc.cfg.Logger.Printf("[ERROR] %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Suggest return at most n completion of the prefix, best first.
+func (svc *Service) Suggest(prefix string, n int) []string {
+	svc.mu.RLock()
+	idx := svc.idx
+	svc.mu.RUnlock()
+	return idx.lookup(normalize(prefix), n)
+}
+
+// RecordQuery count the query that returned result,
+// new query is ignored when MaxQueries distinct queries already recorded.
+func (svc *Service) RecordQuery(query string) {
+	query = normalize(query)
+	if query == "" {
+		return
+	}
+
+	svc.queryMu.Lock()
+	defer svc.queryMu.Unlock()
+	if _, ok := svc.queries[query]; !ok && len(svc.queries) >= svc.cfg.MaxQueries {
+		return
+	}
+	svc.queries[query]++
+}
+
+// build new prefix index from the titles and recorded queries and swap it in
+func (svc *Service) rebuild(ctx context.Context) error {
+	scores := make(map[string]float64)
+
+	err := svc.cfg.IndexAPI.Titles(ctx, func(title string, pageRank float64) error {
+		title = normalize(title)
+		if title == "" {
+			return nil
+		}
+		// the same title on many pages is suggested by the best of them
+		if s := saturate(pageRank, svc.cfg.PageRankPivot); s > scores[title] {
+			scores[title] = s
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("suggest: list titles: %v", err)
+	}
+
+	svc.queryMu.Lock()
+	for q, count := range svc.queries {
+		if count >= svc.cfg.MinQueryCount {
+			scores[q] += saturate(float64(count), queryCountPivot)
+		}
+		if count /= 2; count == 0 {
+			delete(svc.queries, q)
+		} else {
+			svc.queries[q] = count
+		}
+	}
+	svc.queryMu.Unlock()
+
+	idx := buildPrefixIndex(scores)
+	svc.mu.Lock()
+	svc.idx = idx
+	svc.mu.Unlock()
+
+	svc.cfg.Logger.Printf("[INFO] indexed %v suggestions\n", len(idx.entries))
+	return nil
+}
+
+// x / (x + pivot), 0.5 at the pivot and approach 1 for large x
+func saturate(x, pivot float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return x / (x + pivot)
+}
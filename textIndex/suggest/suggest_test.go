@@ -0,0 +1,109 @@
+package suggest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/odit-bit/invoker/textIndex/index"
+)
+
+type docsIndex []*index.Document
+
+func (di docsIndex) Titles(ctx context.Context, fn func(title string, pageRank float64) error) error {
+	for _, doc := range di {
+		if doc.Title == "" {
+			continue
+		}
+		if err := fn(doc.Title, doc.PageRank); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_suggest(t *testing.T) {
+	docs := docsIndex{
+		{Title: "The Go Programming Language", PageRank: 0.002},
+		{Title: "Go  by Example", PageRank: 0.0001},
+		{Title: "gopher", PageRank: 0.0005},
+		{Title: "", PageRank: 1},
+	}
+	svc, err := New(Config{
+		IndexAPI: docs,
+		Interval: time.Hour,
+		Logger:   log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing before the first rebuild
+	if got := svc.Suggest("go", 5); len(got) != 0 {
+		t.Fatalf("\ngot:%v\nexpect empty", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		svc.RecordQuery("Go tutorial")
+	}
+	// searched less than the minimum count
+	svc.RecordQuery("go generics")
+	svc.RecordQuery("go generics")
+	if err := svc.rebuild(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix string
+		n      int
+		expect []string
+	}{
+		{"go", 5, []string{"go tutorial", "gopher", "go by example"}},
+		{"GO", 1, []string{"go tutorial"}},
+		{"go g", 5, []string{}},
+		{"go b", 5, []string{"go by example"}},
+		{"gop", 5, []string{"gopher"}},
+		{"the go p", 5, []string{"the go programming language"}},
+		{"rust", 5, []string{}},
+		{"", 5, []string{}},
+	}
+	for _, test := range tests {
+		got := svc.Suggest(test.prefix, test.n)
+		if fmt.Sprint(got) != fmt.Sprint(test.expect) {
+			t.Fatalf("\nprefix:%q\ngot:%v\nexpect:%v", test.prefix, got, test.expect)
+		}
+	}
+
+	// recorded count fade on every rebuild
+	svc.RecordQuery("gopher")
+	if err := svc.rebuild(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if count := svc.queries["go tutorial"]; count != 5 {
+		t.Fatalf("\ngot:%v\nexpect:%v", count, 5)
+	}
+	if _, ok := svc.queries["gopher"]; ok {
+		t.Fatal("expect query searched once to be dropped")
+	}
+}
+
+func Test_prefixIndex_top(t *testing.T) {
+	scores := map[string]float64{}
+	for i := 0; i < 50; i++ {
+		scores[fmt.Sprintf("a%02d", i)] = float64(i)
+	}
+	idx := buildPrefixIndex(scores)
+
+	// short prefix is served from the precomputed list, long one from the range
+	short := idx.lookup("a", 20)
+	long := idx.lookup("a4", 3)
+	if len(short) != maxSuggestions || short[0] != "a49" || short[maxSuggestions-1] != "a40" {
+		t.Fatalf("\ngot:%v", short)
+	}
+	if fmt.Sprint(long) != "[a49 a48 a47]" {
+		t.Fatalf("\ngot:%v\nexpect:%v", long, "[a49 a48 a47]")
+	}
+}